@@ -13,6 +13,29 @@ func taskWithCmd(cmd *cobra.Command, args []string) *taskpoet.Task {
 		taskpoet.WithEffortImpact(taskpoet.EffortImpact(mustGetCmd[uint](cmd, "effort-impact"))),
 		// taskpoet.WithDescription(strings.Join(args, " ")),
 		taskpoet.WithTags(mustGetCmd[[]string](cmd, "tag")),
+		taskpoet.WithProject(mustGetCmd[string](cmd, "project")),
+	}
+
+	_, context, err := poetC.ActiveContext()
+	checkErr(err)
+	opts = append(opts, taskpoet.WithContextDefaults(context))
+
+	udas, err := udaFlags(cmd)
+	checkErr(err)
+	for name, value := range udas {
+		opts = append(opts, taskpoet.WithUDA(name, value))
+	}
+
+	for _, partial := range mustGetCmd[[]string](cmd, "depends") {
+		blocker, err := poetC.Task.GetWithPartialID(partial, "", "")
+		checkErr(err)
+		opts = append(opts, taskpoet.WithDependsOn(blocker.ID))
+	}
+
+	if estimateIn := mustGetCmd[string](cmd, "estimate"); estimateIn != "" {
+		estimate, err := taskpoet.ParseDuration(estimateIn)
+		checkErr(err)
+		opts = append(opts, taskpoet.WithEstimate(estimate))
 	}
 
 	cal := taskpoet.NewCalendar()
@@ -29,6 +52,12 @@ func taskWithCmd(cmd *cobra.Command, args []string) *taskpoet.Task {
 		opts = append(opts, taskpoet.WithHideUntil(hide))
 	}
 
+	if untilIn := mustGetCmd[string](cmd, "until"); untilIn != "" {
+		until, err := cal.Date(untilIn)
+		checkErr(err)
+		opts = append(opts, taskpoet.WithCancelAfter(until))
+	}
+
 	return taskpoet.MustNewTask(strings.Join(args, " "), opts...)
 }
 
@@ -46,7 +75,22 @@ For us lazy folks, you can also leave the quotes out:
 $ taskpoet add Learn a new skill
 
 Set an Effort/Impact to a new task:
-$ taskpoet add --effort-impact 2 Rebuild all the remote servers`,
+$ taskpoet add --effort-impact 2 Rebuild all the remote servers
+
+Put a task in a project, using dots for subprojects:
+$ taskpoet add --project work.infra.dns Move the zone to the new provider
+
+Set user defined attributes from the udas section of the config:
+$ taskpoet add --uda ticket=OPS-123 --uda env=prod Rotate the certificates
+
+Estimate how long a task will take:
+$ taskpoet add --estimate 4h --effort-impact 2 Write the migration plan
+
+Drop a task automatically if it isn't done within a week:
+$ taskpoet add --until 1w Grab tickets for the show
+
+Block a new task until another is done:
+$ taskpoet add --depends 3fa8 Deploy the new certificates`,
 		Long:              `Add new task`,
 		ValidArgsFunction: noComplete,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -63,6 +107,7 @@ $ taskpoet add --effort-impact 2 Rebuild all the remote servers`,
 					checkErr(poetC.Task.AddParent(added, parent))
 				}
 			}
+			poetC.SetShortIDs(added)
 			log.Info("Added task", "description", added.Description, "id", added.ShortID())
 		},
 	}
@@ -87,13 +132,21 @@ func bindAdd(cmd *cobra.Command) error {
 
 	cmd.PersistentFlags().StringP("parent", "p", "", "ID of parent task")
 	if err := cmd.RegisterFlagCompletionFunc("parent", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return poetC.CompleteIDsWithPrefix("/active", toComplete), cobra.ShellCompDirectiveNoFileComp
+		return completeOpenIDs(toComplete), cobra.ShellCompDirectiveNoFileComp
 	}); err != nil {
 		return err
 	}
 
 	cmd.PersistentFlags().StringP("due", "d", "", "How long before this is due?")
 	cmd.PersistentFlags().StringP("wait", "w", "", "Wait until given duration to actually show up as active")
+	cmd.PersistentFlags().String("until", "", "Expire the task, moving it to the trash, if it isn't done by then")
 	cmd.PersistentFlags().StringSliceP("tag", "t", []string{}, "Tags to include in this task")
-	return nil
+	cmd.PersistentFlags().StringP("project", "P", "", "Project for this task, use dots for subprojects, like work.infra")
+	bindUDA(cmd, "Set a user defined attribute, as name=value")
+	cmd.PersistentFlags().String("estimate", "", "How long this should take, like 2h or 3d")
+	cmd.PersistentFlags().StringSlice("depends", []string{}, "IDs of tasks that must be completed before this one can start")
+	checkErr(cmd.RegisterFlagCompletionFunc("depends", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeOpenIDs(toComplete), cobra.ShellCompDirectiveNoFileComp
+	}))
+	return cmd.RegisterFlagCompletionFunc("project", completeProject)
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newBackupCmd writes a copy of the database to a file
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup FILE",
+		Short: "Back up the database to a file",
+		Long: `Write a consistent copy of the whole database, every namespace included, to FILE.
+This is safe to run while taskpoet is being used elsewhere. Files ending in .gz
+are gzipped, or use --gzip`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			compress := mustGetCmd[bool](cmd, "gzip") || strings.HasSuffix(args[0], ".gz")
+			f, err := os.OpenFile(args[0], os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+			checkErr(err)
+			if err := poetC.Backup(f, compress); err != nil {
+				_ = f.Close()
+				_ = os.Remove(args[0])
+				checkErr(err)
+			}
+			checkErr(f.Close())
+			log.Info("Backed up database", "file", args[0], "gzip", compress)
+		},
+	}
+	cmd.Flags().BoolP("gzip", "z", false, "Gzip the backup")
+	return cmd
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+// newCalibrateCmd compares estimates with actuals
+func newCalibrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "calibrate",
+		Short: "Compare estimates with how long completed tasks actually took",
+		Long: `Compare the estimates of completed tasks with how long they actually took,
+grouped by Effort/Impact and by tag. Tracked time from start and stop is used
+when there is any, otherwise the time from adding to completing the task.
+
+A ratio of 2.00x means tasks took twice as long as estimated`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			since := time.Time{}
+			if sinceIn := mustGetCmd[string](cmd, "since"); sinceIn != "" {
+				got, err := taskpoet.NewCalendar().Past(sinceIn)
+				checkErr(err)
+				since = *got
+			}
+			c, err := poetC.Calibrate(since)
+			checkErr(err)
+			if c.Total.Tasks == 0 {
+				log.Info("No completed tasks have an estimate yet")
+				return
+			}
+			fmt.Print(poetC.CalibrationTable(*c))
+		},
+	}
+	cmd.Flags().String("since", "", "Only include tasks completed since this, like 4w or socm")
+	return cmd
+}
@@ -9,9 +9,13 @@ import (
 // commentCmd represents the comment command
 func newCommentCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:               "comment",
-		Short:             "Add a comment to a task",
-		Long:              `Comments are just little text notes, with a date of when they were entered`,
+		Use:   "comment ID TEXT...",
+		Short: "Add a comment to a task",
+		Long: `Comments are just little text notes, with a date of when they were entered.
+They are written in markdown, and rendered as such when describing a task.
+
+Each comment has its own ID, which the list subcommand shows, so it can be
+edited or deleted later.`,
 		Args:              cobra.MinimumNArgs(2),
 		ValidArgsFunction: completeActive,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -22,5 +26,8 @@ func newCommentCmd() *cobra.Command {
 			checkErr(err)
 		},
 	}
+	cmd.AddCommand(newCommentListCmd())
+	cmd.AddCommand(newCommentEditCmd())
+	cmd.AddCommand(newCommentDeleteCmd())
 	return cmd
 }
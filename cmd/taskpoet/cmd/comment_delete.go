@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newCommentDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "delete ID COMMENT-ID",
+		Short:             "Delete a comment",
+		Long:              `Delete a comment from a task. This can be reversed with undo`,
+		Aliases:           []string{"rm"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeComment,
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := poetC.Task.GetWithPartialID(args[0], "", "")
+			checkErr(err)
+			checkErr(task.DeleteComment(args[1]))
+			_, err = poetC.Task.Edit(task)
+			checkErr(err)
+			log.Info("Deleted comment", "task", task.Description, "comment", args[1])
+		},
+	}
+	return cmd
+}
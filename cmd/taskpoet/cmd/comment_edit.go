@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newCommentEditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "edit ID COMMENT-ID TEXT...",
+		Short:             "Replace the text of a comment",
+		Long:              `Replace the text of a comment, keeping when it was first added`,
+		Args:              cobra.MinimumNArgs(3),
+		ValidArgsFunction: completeComment,
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := poetC.Task.GetWithPartialID(args[0], "", "")
+			checkErr(err)
+			checkErr(task.EditComment(args[1], strings.Join(args[2:], " ")))
+			_, err = poetC.Task.Edit(task)
+			checkErr(err)
+			log.Info("Edited comment", "task", task.Description, "comment", args[1])
+		},
+	}
+	return cmd
+}
+
+// completeComment completes the task, then the comment on it
+func completeComment(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeActive(cmd, args, toComplete)
+	case 1:
+		task, err := poetC.Task.GetWithPartialID(args[0], "", "")
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ret := []string{}
+		for _, c := range task.Comments {
+			if strings.HasPrefix(c.ID, toComplete) {
+				ret = append(ret, c.ShortID()+"\t"+c.Text)
+			}
+		}
+		return ret, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
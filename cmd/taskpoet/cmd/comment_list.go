@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newCommentListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list ID",
+		Short:             "List the comments on a task",
+		Long:              `List the comments on a task, along with the IDs used to edit or delete them`,
+		Aliases:           []string{"ls"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeActive,
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := poetC.Task.GetWithPartialID(args[0], "", "")
+			checkErr(err)
+			fmt.Print(poetC.CommentTable(*task))
+		},
+	}
+	return cmd
+}
@@ -15,10 +15,17 @@ func newCompleteCmd() *cobra.Command {
 		Aliases: []string{"c", "complete", "finish"},
 		Args:    cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			task, err := poetC.Task.GetWithPartialID(args[0], "", "/active")
+			task, err := poetC.Task.GetOpenWithPartialID(args[0], "")
 			checkErr(err)
 			checkErr(poetC.Task.Complete(task))
+			poetC.SetShortIDs(task)
 			log.Info("Completed task, nice work!", "task", task.Description, "id", task.ShortID())
+			unblocked, err := poetC.Unblocked(task)
+			checkErr(err)
+			poetC.SetShortIDs(unblocked...)
+			for _, t := range unblocked {
+				log.Info("Unblocked task", "task", t.Description, "id", t.ShortID())
+			}
 		},
 		ValidArgsFunction: completeActive,
 	}
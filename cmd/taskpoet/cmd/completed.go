@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"sort"
 
 	"github.com/drewstinnett/taskpoet/taskpoet"
 	"github.com/spf13/cobra"
@@ -23,21 +22,16 @@ func newCompletedCmd() *cobra.Command {
 			// tableOpts := mustTableOptsWithCmd(cmd, args)
 			tableOpts := &taskpoet.TableOpts{
 				Prefix:  "/completed",
-				Columns: []string{"ID", "Description", "Completed", "Tags"},
+				Columns: []string{"ID", "Description", "Completed", "Project", "Tags", "Spent"},
 				SortBy:  taskpoet.ByCompleted{},
 				Filters: []taskpoet.Filter{
-					taskpoet.FilterRegex,
+					taskpoet.FilterQuery,
+					taskpoet.FilterProject,
+					taskpoet.FilterUDA,
 					taskpoet.FilterHidden,
 				},
 			}
 			checkErr(applyCobra(cmd, args, tableOpts))
-			var results []*taskpoet.Task
-
-			results, err := poetC.Task.List("/completed")
-			checkErr(err)
-			sort.Slice(results, func(i, j int) bool {
-				return results[i].Added.Before(results[j].Added)
-			})
 
 			table := poetC.TaskTable(*tableOpts)
 			fmt.Print(table)
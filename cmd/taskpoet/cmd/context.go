@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newContextCmd is the parent of the commands that deal with contexts
+func newContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Switch between contexts, like work and home",
+		Long: `Contexts are named filters from the contexts section of the config. While one
+is set, task tables and ID completion only show the tasks matching its filter,
+and new tasks get its project and tags. The context stays set until it is
+cleared with 'taskpoet context none'`,
+		Aliases: []string{"contexts", "ctx"},
+		Args:    cobra.NoArgs,
+	}
+	cmd.AddCommand(newContextListCmd())
+	cmd.AddCommand(newContextSetCmd())
+	cmd.AddCommand(newContextNoneCmd())
+	cmd.AddCommand(newContextShowCmd())
+	return cmd
+}
+
+func completeContext(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ret := []string{}
+	for _, name := range poetC.Contexts.Names() {
+		ret = append(ret, name+"\t"+poetC.Contexts[name].Filter)
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
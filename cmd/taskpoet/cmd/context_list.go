@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newContextListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List contexts, with a * next to the active one",
+		Aliases:           []string{"ls"},
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(poetC.Contexts) == 0 {
+				log.Info("No contexts yet, add some to the contexts section of the config")
+				return
+			}
+			table, err := poetC.ContextTable()
+			checkErr(err)
+			fmt.Print(table)
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newContextNoneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "none",
+		Short:             "Clear the active context, so every task shows up again",
+		Aliases:           []string{"clear"},
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			checkErr(poetC.SetContext(""))
+			log.Info("Cleared context")
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newContextSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "set NAME",
+		Short:             "Set the active context",
+		Example:           `$ taskpoet context set work`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeContext,
+		Run: func(cmd *cobra.Command, args []string) {
+			checkErr(poetC.SetContext(args[0]))
+			log.Info("Set context", "context", args[0], "filter", poetC.Contexts[args[0]].Filter)
+		},
+	}
+	return cmd
+}
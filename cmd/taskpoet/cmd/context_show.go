@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newContextShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "show",
+		Short:             "Show the active context",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, c, err := poetC.ActiveContext()
+			checkErr(err)
+			if c == nil {
+				fmt.Println("No context is set")
+				return
+			}
+			fmt.Printf("%v: %v\n", name, c.Filter)
+		},
+	}
+	return cmd
+}
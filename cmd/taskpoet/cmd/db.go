@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newDBCmd is the parent of the database maintenance commands
+func newDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Database maintenance",
+		Long:  `Commands for looking after the database itself, instead of the tasks in it`,
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newDBMigrateCmd())
+	cmd.AddCommand(newDBReindexCmd())
+	return cmd
+}
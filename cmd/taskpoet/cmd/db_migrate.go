@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// skipMigrateAnnotation marks commands that must open the database without
+// migrating it first
+const skipMigrateAnnotation = "taskpoet/skip-migrate"
+
+// newDBMigrateCmd upgrades the database schema
+func newDBMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the database to the current schema version",
+		Long: `Apply any pending schema migrations to the stored tasks. This normally happens
+automatically when the database is opened, but running it by hand lets you see
+what is going to change first`,
+		Example: `See what would change, without changing anything:
+$ taskpoet db migrate --dry-run`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Annotations:       map[string]string{skipMigrateAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun := mustGetCmd[bool](cmd, "dry-run")
+			report, err := poetC.Migrate(dryRun)
+			checkErr(err)
+			if report.From == report.To {
+				log.Info("Database schema is already up to date", "version", report.To)
+				return
+			}
+			for _, result := range report.Results {
+				fmt.Printf("%v: %v (%v tasks)\n", result.Version, result.Description, len(result.Changed))
+				for _, path := range result.Changed {
+					fmt.Printf("  %v\n", path)
+				}
+			}
+			if dryRun {
+				log.Info("Dry run, nothing was changed", "from", report.From, "to", report.To)
+				return
+			}
+			log.Info("Migrated database schema", "from", report.From, "to", report.To)
+		},
+	}
+	cmd.PersistentFlags().Bool("dry-run", false, "Report what would change without changing anything")
+	return cmd
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newDBReindexCmd rebuilds the secondary indexes
+func newDBReindexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Rebuild the tag, project, due and plugin indexes",
+		Long: `Throw away the secondary indexes and build them again from the stored tasks.
+Databases created before indexes existed need this once, after that they are
+kept up to date automatically`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			count, err := poetC.RebuildIndexes()
+			checkErr(err)
+			log.Info("Rebuilt indexes", "tasks", count)
+		},
+	}
+	return cmd
+}
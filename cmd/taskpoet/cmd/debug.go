@@ -3,8 +3,8 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/drewstinnett/taskpoet/taskpoet"
 	"github.com/spf13/cobra"
-	bolt "go.etcd.io/bbolt"
 )
 
 // debugCmd represents the debug command
@@ -15,16 +15,20 @@ func newDebugCmd() *cobra.Command {
 		Long:   `Barf the DB to stdout for debugging purposes 🤮`,
 		Hidden: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := poetC.DB.View(func(tx *bolt.Tx) error {
-				return tx.ForEach(func(bucketName []byte, bucket *bolt.Bucket) error {
+			err := poetC.Store.View(func(tx taskpoet.StoreTx) error {
+				buckets, err := tx.Buckets()
+				if err != nil {
+					return err
+				}
+				for _, bucketName := range buckets {
 					fmt.Println("Bucket: ", string(bucketName))
-					err := bucket.ForEach(func(k, v []byte) error {
+					err := tx.ForEach(bucketName, nil, func(k, v []byte) error {
 						fmt.Println(string(k), " -> ", string(v))
 						return nil
 					})
 					checkErr(err)
-					return nil
-				})
+				}
+				return nil
 			})
 			checkErr(err)
 		},
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+// newExpiredCmd lists tasks that expired because their until date passed
+func newExpiredCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "expired",
+		Short: "List tasks that expired before they were done",
+		Long: `List tasks that expired because their until date passed before they were
+done. Expired tasks live in the trash, so they can be brought back with
+'taskpoet trash restore', and are purged along with the rest of the trash`,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			tableOpts := &taskpoet.TableOpts{
+				Prefix:  "/deleted",
+				Columns: []string{"ID", "Description", "Until", "Deleted", "Project", "Tags"},
+				SortBy:  taskpoet.ByDeleted{},
+				Filters: []taskpoet.Filter{
+					taskpoet.FilterExpired,
+					taskpoet.FilterQuery,
+					taskpoet.FilterProject,
+					taskpoet.FilterUDA,
+				},
+			}
+			checkErr(applyCobra(cmd, args, tableOpts))
+			fmt.Print(poetC.TaskTable(*tableOpts))
+		},
+	}
+	bindTableOpts(cmd)
+	return cmd
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newFsckCmd checks and repairs the links between tasks
+func newFsckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check the links between parent and child tasks",
+		Long: `Check the parent and child links of every task, in every state, for links to
+tasks that don't exist, links only recorded on one side, duplicate links and
+cycles. Use --fix to repair them, which can be reverted with 'taskpoet undo'`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			report, err := poetC.Fsck(mustGetCmd[bool](cmd, "fix"))
+			checkErr(err)
+			for _, problem := range report.Problems {
+				fmt.Printf("%-10v %v %v %v\n", problem.Kind, problem.TaskID, problem.Other, problem.Detail)
+			}
+			switch {
+			case len(report.Problems) == 0:
+				log.Info("No problems found", "checked", report.Checked)
+			case report.Fixed:
+				log.Info("Fixed problems", "checked", report.Checked, "problems", len(report.Problems))
+			default:
+				log.Error("Found problems, use --fix to repair them", "checked", report.Checked, "problems", len(report.Problems))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().Bool("fix", false, "Repair any problems found")
+	return cmd
+}
@@ -2,10 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
-	"github.com/charmbracelet/log"
 	"github.com/drewstinnett/taskpoet/taskpoet"
 	"github.com/spf13/cobra"
 )
@@ -13,34 +10,69 @@ import (
 // newGetCmd is the new get command
 func newGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "active",
+		Use:     "active [QUERY...]",
 		Short:   "Get Active tasks, waiting to be completed",
 		Aliases: []string{"g", "a", "get"},
 		Version: version,
-		Long: `Get Active Tasks
+		Long: `Get Active Tasks, optionally only the ones matching a query.
+
+A query is a list of terms that all have to match, which can be combined with
+and, or, not and parentheses. Plain words match the description as a regex,
++tag and -tag match tags, and field:value matches a field, like project:work,
+state:waiting, ei:1, urgency>5, desc~"dns server", due.before:eow or
+added.after:1w. Quote anything the shell would take for itself, like > and
+parentheses. Since -tag looks like a flag, put it after a --, or use 'not +tag'
+instead.
 `,
+		Example: `Urgent work that's due this week:
+$ taskpoet active +urgent project:work due.before:eow
+
+Everything about DNS that isn't someday:
+$ taskpoet active -- dns -someday
+
+Either project, with a high urgency:
+$ taskpoet active '(project:work or project:home) urgency>5'`,
 		Run: func(cmd *cobra.Command, args []string) {
-			tableOpts := mustTableOptsWithCmd(cmd, args)
-			tableOpts.Prefix = "/active"
-			tableOpts.Columns = []string{"ID", "Age", "Due", "Description", "Urgency", "Tags"}
+			tableOpts, err := tableOptsWithCmd(cmd, args)
+			checkErr(err)
+			tableOpts.Prefixes = poetC.OpenStatePaths()
+			tableOpts.Columns = []string{"ID", "Age", "Due", "Description", "Urgency", "Project", "Tags", "Blocked", "Remaining", "Active"}
+			if len(poetC.WorkflowStates.Names()) > 0 {
+				tableOpts.Columns = append(tableOpts.Columns, "State")
+			}
+			extra, err := extraColumns(cmd)
+			checkErr(err)
+			tableOpts.Columns = append(tableOpts.Columns, extra...)
 			tableOpts.SortBy = taskpoet.ByUrgency{}
 			tableOpts.Filters = []taskpoet.Filter{
 				taskpoet.FilterHidden,
-				taskpoet.FilterRegex,
+				taskpoet.FilterQuery,
+				taskpoet.FilterProject,
+				taskpoet.FilterUDA,
+				taskpoet.FilterBlocked,
+				taskpoet.FilterStale,
+				taskpoet.FilterState,
 			}
-
-			var re *regexp.Regexp
-			if len(args) > 0 {
-				tableOpts.FilterParams.Regex = regexp.MustCompile(fmt.Sprintf("(?i)%v", strings.Join(args, " ")))
-				log.Debug("Showing tasks that match", "regex", re)
-			} else {
-				tableOpts.FilterParams.Regex = regexp.MustCompile(".*")
+			tableOpts.FilterParams.States = mustGetCmd[[]string](cmd, "state")
+			if blocked, unblocked := mustGetCmd[bool](cmd, "blocked"), mustGetCmd[bool](cmd, "unblocked"); blocked || unblocked {
+				tableOpts.FilterParams.Blocked = &blocked
+			}
+			if staleIn := mustGetCmd[string](cmd, "stale"); staleIn != "" {
+				stale, err := taskpoet.ParseDuration(staleIn)
+				checkErr(err)
+				tableOpts.FilterParams.StaleAfter = stale
 			}
-			// table := poetC.TaskTable("/active", *fp, taskpoet.FilterHidden, taskpoet.FilterRegex)
+
 			table := poetC.TaskTable(*tableOpts)
 			fmt.Print(table)
 		},
 	}
 	bindTableOpts(cmd)
+	cmd.PersistentFlags().Bool("blocked", false, "Only show tasks waiting on another task")
+	cmd.PersistentFlags().Bool("unblocked", false, "Only show tasks that are not waiting on another task")
+	cmd.MarkFlagsMutuallyExclusive("blocked", "unblocked")
+	cmd.PersistentFlags().String("stale", "", "Only show tasks that haven't been reviewed in this long, like 2w")
+	cmd.PersistentFlags().StringSlice("state", []string{}, "Only show tasks in these states, like active or in-review")
+	checkErr(cmd.RegisterFlagCompletionFunc("state", completeOpenStates))
 	return cmd
 }
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// newHistoryCmd shows every change made to a task
+func newHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "history ID",
+		Short:             "Show the revision history of a task",
+		Long:              `Show every change made to a task, oldest first, including when it was added, edited, completed and deleted`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeActive,
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := poetC.Task.GetWithPartialID(args[0], "", "")
+			checkErr(err)
+			revs, err := poetC.History(*task)
+			checkErr(err)
+			fmt.Println(poetC.DescribeHistory(revs))
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// tagArgsAnnotation marks commands that take -tag arguments, which are moved
+// after a -- before the flags are parsed
+const tagArgsAnnotation = "taskpoet/tag-args"
+
+func newModifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "modify [ID|RANGE...] [+tag...] [-tag...]",
+		Short: "Change one or more active tasks",
+		Long: `Change the due date, wait, Effort/Impact, description, project, tags or
+dependencies of one or more active tasks, all at once. Give the tasks as IDs, separated by
+spaces or commas, or pick them with --filter. A range like 3a-3f picks every
+task with an ID starting with 3a through 3f.
+
+Tags are added with +tag and taken off with -tag. Since -tag looks like a
+flag, flags given with a single dash have to be one letter, with a space
+before the value, like -d 1w.
+
+When more than --confirm-over tasks would change, the changes are shown first
+and need confirming`,
+		Annotations: map[string]string{tagArgsAnnotation: "true"},
+		Aliases:     []string{"mod", "m"},
+		Example: `Push a task back a week:
+$ taskpoet modify 3fa8 --due 1w
+
+Tag a few tasks and take a tag off of them:
+$ taskpoet modify 3fa8,9c1d 7e2 +urgent -someday
+
+Wait on every task with an ID from 30 through 3f:
+$ taskpoet modify 30-3f -w monday
+
+Move a few tasks to a subproject:
+$ taskpoet modify 3fa8,9c1d -P work.infra.dns
+
+Wait on another task before starting, and stop waiting on a third:
+$ taskpoet modify 3fa8 --depends 9c1d --remove-depends 7e2
+
+Reword a task:
+$ taskpoet modify 3fa8 --description "Rotate the staging certificates"
+
+Set the Effort/Impact of everything about DNS:
+$ taskpoet modify --filter dns --effort-impact 1`,
+		ValidArgsFunction: completeActive,
+		Run: func(cmd *cobra.Command, args []string) {
+			rest, add, remove := taskpoet.ParseTagArgs(args)
+			m, err := modificationWithCmd(cmd)
+			checkErr(err)
+			m.AddTags, m.RemoveTags = add, remove
+			tasks, err := modifyTargets(rest, mustGetCmd[string](cmd, "filter"))
+			checkErr(err)
+
+			if len(tasks) > mustGetCmd[int](cmd, "confirm-over") && !mustGetCmd[bool](cmd, "yes") {
+				preview, err := poetC.ModifyPreview(tasks, *m)
+				checkErr(err)
+				fmt.Print(preview)
+				fmt.Printf("Modify %v tasks? [y/N] ", len(tasks))
+				answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				checkErr(err)
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					log.Fatal("Not modifying anything")
+				}
+			}
+			checkErr(poetC.Modify(tasks, *m))
+			poetC.SetShortIDs(tasks...)
+			for _, t := range tasks {
+				log.Info("Modified task", "task", t.Description, "id", t.ShortID())
+			}
+		},
+	}
+	cmd.Flags().StringP("due", "d", "", "New due date, like 3d or eow")
+	cmd.Flags().StringP("wait", "w", "", "Hide until this, like 1w or monday")
+	cmd.Flags().UintP("effort-impact", "e", 0, "New Effort/Impact Score Assessment")
+	cmd.Flags().String("description", "", "New description")
+	cmd.Flags().StringP("project", "P", "", "New project, use dots for subprojects, like work.infra")
+	checkErr(cmd.RegisterFlagCompletionFunc("project", completeProject))
+	cmd.Flags().StringSlice("depends", []string{}, "IDs of tasks that must be completed before these can start")
+	cmd.Flags().StringSlice("remove-depends", []string{}, "IDs of tasks to stop waiting on")
+	for _, name := range []string{"depends", "remove-depends"} {
+		checkErr(cmd.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeOpenIDs(toComplete), cobra.ShellCompDirectiveNoFileComp
+		}))
+	}
+	cmd.Flags().StringP("filter", "f", "", "Modify every active task matching this query, like '+oncall due.before:eow', instead of giving IDs")
+	cmd.Flags().Int("confirm-over", 3, "Ask before modifying more than this many tasks")
+	cmd.Flags().BoolP("yes", "y", false, "Modify without asking for confirmation")
+	return cmd
+}
+
+// modificationWithCmd builds a modification out of the flags that were set
+func modificationWithCmd(cmd *cobra.Command) (*taskpoet.Modification, error) {
+	m := &taskpoet.Modification{
+		Description: mustGetCmd[string](cmd, "description"),
+		Project:     mustGetCmd[string](cmd, "project"),
+	}
+	cal := taskpoet.NewCalendar()
+	if dueIn := mustGetCmd[string](cmd, "due"); dueIn != "" {
+		due, err := cal.Date(dueIn)
+		if err != nil {
+			return nil, err
+		}
+		m.Due = due
+	}
+	if waitIn := mustGetCmd[string](cmd, "wait"); waitIn != "" {
+		wait, err := cal.Date(waitIn)
+		if err != nil {
+			return nil, err
+		}
+		m.HideUntil = wait
+	}
+	if cmd.Flags().Changed("effort-impact") {
+		ei := taskpoet.EffortImpact(mustGetCmd[uint](cmd, "effort-impact"))
+		m.EffortImpact = &ei
+	}
+	var err error
+	if m.AddDepends, err = dependIDs(mustGetCmd[[]string](cmd, "depends")); err != nil {
+		return nil, err
+	}
+	if m.RemoveDepends, err = dependIDs(mustGetCmd[[]string](cmd, "remove-depends")); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// dependIDs looks up the full IDs of the tasks given as partial IDs
+func dependIDs(partials []string) ([]string, error) {
+	ids := []string{}
+	for _, partial := range partials {
+		t, err := poetC.Task.GetWithPartialID(partial, "", "")
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// modifyTargets looks up the tasks given as IDs, or matching the filter
+func modifyTargets(ids []string, filter string) (taskpoet.Tasks, error) {
+	if filter != "" {
+		if len(ids) > 0 {
+			return nil, fmt.Errorf("give either IDs or --filter, not both")
+		}
+		q, err := poetC.ParseQuery(filter)
+		if err != nil {
+			return nil, err
+		}
+		return poetC.ListQuery(q)
+	}
+	tasks, err := poetC.OpenWithIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("give the IDs of the tasks to modify, or a --filter")
+	}
+	return tasks, nil
+}
+
+// tagArgsLast moves -tag arguments after a --, so they aren't read as flags.
+// Anything with a single dash and more than one letter is a tag, so flags with
+// a single dash have to be one letter, like -d 1w
+func tagArgsLast(cmd *cobra.Command, args []string) []string {
+	rest, tags := []string{}, []string{}
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		switch {
+		case arg == "--":
+			rest = append(rest, args[idx:]...)
+			idx = len(args)
+		case strings.HasPrefix(arg, "--"), len(arg) == 2 && arg[0] == '-':
+			rest = append(rest, arg)
+			if flagTakesValue(cmd, arg) && idx+1 < len(args) {
+				idx++
+				rest = append(rest, args[idx])
+			}
+		case len(arg) > 2 && arg[0] == '-':
+			tags = append(tags, arg)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if len(tags) == 0 {
+		return args
+	}
+	if !slices.Contains(rest, "--") {
+		rest = append(rest, "--")
+	}
+	return append(rest, tags...)
+}
+
+// flagTakesValue is true when arg is a flag of cmd that is given its value in
+// the next argument
+func flagTakesValue(cmd *cobra.Command, arg string) bool {
+	name := strings.TrimLeft(arg, "-")
+	if strings.Contains(name, "=") {
+		return false
+	}
+	for _, fs := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()} {
+		f := fs.Lookup(name)
+		if len(arg) == 2 {
+			f = fs.ShorthandLookup(name)
+		}
+		if f != nil {
+			return f.NoOptDefVal == ""
+		}
+	}
+	return false
+}
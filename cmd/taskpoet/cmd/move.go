@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newMoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "move ID STATE",
+		Short: "Move a task to another workflow state",
+		Long: `Move an open task to another state. The states, and which ones a task can move
+between, come from the states section of the config. Moving a task to
+completed or deleted is the same as completing or deleting it.`,
+		Example: `Start reviewing a task:
+$ taskpoet move 3fa8 in-review
+
+Put it back with the rest of the active tasks:
+$ taskpoet move 3fa8 active`,
+		Aliases:           []string{"mv"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeMove,
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := poetC.Task.GetOpenWithPartialID(args[0], "")
+			checkErr(err)
+			from := task.State()
+			checkErr(poetC.Move(task, args[1]))
+			poetC.SetShortIDs(task)
+			log.Info("Moved task", "task", task.Description, "id", task.ShortID(), "from", from, "to", task.State())
+		},
+	}
+	return cmd
+}
+
+// completeMove completes the task, then the states it can move to
+func completeMove(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeActive(cmd, args, toComplete)
+	case 1:
+		task, err := poetC.Task.GetOpenWithPartialID(args[0], "")
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ret := []string{}
+		for _, state := range poetC.Task.GetStates() {
+			if poetC.WorkflowStates.CanMove(task.State(), state) == nil {
+				ret = append(ret, state)
+			}
+		}
+		return ret, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeOpenStates completes the states an open task can be in
+func completeOpenStates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ret := []string{}
+	for _, path := range poetC.OpenStatePaths() {
+		ret = append(ret, path[1:])
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newNamespaceCmd is the parent of the namespace management commands
+func newNamespaceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "namespace",
+		Short:   "Manage namespaces",
+		Aliases: []string{"namespaces", "ns"},
+		Long:    `List, copy, rename, merge and delete the namespaces in the database`,
+		Args:    cobra.NoArgs,
+	}
+	cmd.AddCommand(newNamespaceListCmd())
+	cmd.AddCommand(newNamespaceCopyCmd())
+	cmd.AddCommand(newNamespaceRenameCmd())
+	cmd.AddCommand(newNamespaceMergeCmd())
+	cmd.AddCommand(newNamespaceDeleteCmd())
+	return cmd
+}
+
+// completeNamespace completes with the names of existing namespaces
+func completeNamespace(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	namespaces, err := poetC.Namespaces()
+	if err != nil {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	ret := []string{}
+	for _, ns := range namespaces {
+		ret = append(ret, ns.Name)
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
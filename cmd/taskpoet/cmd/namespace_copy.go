@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newNamespaceCopyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "copy SRC DST",
+		Short:             "Copy a namespace to a new one",
+		Aliases:           []string{"cp"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeNamespace,
+		Run: func(cmd *cobra.Command, args []string) {
+			checkErr(poetC.CopyNamespace(args[0], args[1]))
+			log.Info("Copied namespace", "from", args[0], "to", args[1])
+		},
+	}
+	return cmd
+}
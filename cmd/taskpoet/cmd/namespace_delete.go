@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newNamespaceDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "delete NAMESPACE",
+		Short:             "Delete a namespace and every task in it",
+		Aliases:           []string{"rm"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNamespace,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !mustGetCmd[bool](cmd, "yes") {
+				fmt.Printf("This deletes every task in %v, and cannot be undone. Type the namespace name to confirm: ", args[0])
+				answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				checkErr(err)
+				if strings.TrimSpace(answer) != args[0] {
+					log.Fatal("Not deleting, confirmation did not match")
+				}
+			}
+			checkErr(poetC.DeleteNamespace(args[0]))
+			log.Info("Deleted namespace", "namespace", args[0])
+		},
+	}
+	cmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+	return cmd
+}
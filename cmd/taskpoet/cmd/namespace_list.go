@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newNamespaceListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List namespaces and how many tasks are in each",
+		Aliases:           []string{"ls"},
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			namespaces, err := poetC.Namespaces()
+			checkErr(err)
+			states := poetC.Task.GetStates()
+			fmt.Printf("%-20v %v total\n", "namespace", strings.Join(states, " "))
+			for _, ns := range namespaces {
+				counts := make([]string, len(states))
+				for idx, state := range states {
+					counts[idx] = fmt.Sprintf("%*d", len(state), ns.Counts[state])
+				}
+				current := ""
+				if ns.Name == poetC.Namespace {
+					current = " *"
+				}
+				fmt.Printf("%-20v %v %5d%v\n", ns.Name, strings.Join(counts, " "), ns.Total(), current)
+			}
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+func newNamespaceMergeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge SRC DST",
+		Short: "Merge the tasks of one namespace in to another",
+		Long: `Add every task in SRC to DST, along with its history. SRC is left as it is, use
+--delete-source to remove it after a successful merge.
+
+When a task ID is in both namespaces, --on-collision decides what happens:
+
+fail      - Stop without changing anything (default)
+skip      - Keep the task already in DST
+overwrite - Replace the task in DST with the one from SRC
+new-id    - Give the task from SRC a new ID`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeNamespace,
+		Run: func(cmd *cobra.Command, args []string) {
+			policy := taskpoet.CollisionPolicy(mustGetCmd[string](cmd, "on-collision"))
+			report, err := poetC.MergeNamespace(args[0], args[1], policy)
+			checkErr(err)
+			log.Info("Merged namespace", "from", args[0], "to", args[1], "merged", report.Merged,
+				"skipped", len(report.Skipped), "overwritten", len(report.Overwritten), "renamed", len(report.Renamed))
+			for oldID, newID := range report.Renamed {
+				log.Info("Renamed task", "from", oldID, "to", newID)
+			}
+			if mustGetCmd[bool](cmd, "delete-source") {
+				checkErr(poetC.DeleteNamespace(args[0]))
+				log.Info("Deleted namespace", "namespace", args[0])
+			}
+		},
+	}
+	cmd.Flags().String("on-collision", string(taskpoet.CollisionFail), fmt.Sprintf("What to do when a task is in both namespaces, one of %v", taskpoet.CollisionPolicies()))
+	cmd.Flags().Bool("delete-source", false, "Delete the source namespace after merging")
+	return cmd
+}
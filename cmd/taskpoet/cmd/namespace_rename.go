@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newNamespaceRenameCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "rename SRC DST",
+		Short:             "Rename a namespace",
+		Aliases:           []string{"mv"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeNamespace,
+		Run: func(cmd *cobra.Command, args []string) {
+			checkErr(poetC.RenameNamespace(args[0], args[1]))
+			log.Info("Renamed namespace", "from", args[0], "to", args[1])
+		},
+	}
+	return cmd
+}
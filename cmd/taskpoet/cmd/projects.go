@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newProjectsCmd summarizes the projects
+func newProjectsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "projects",
+		Short: "Summarize projects and how far along they are",
+		Long: `Summarize every project with active or completed tasks. Subprojects are shown
+under their parents, and their tasks count towards the parents too`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			summaries, err := poetC.Projects()
+			checkErr(err)
+			if len(summaries) == 0 {
+				log.Info("No tasks have a project yet")
+				return
+			}
+			fmt.Println(poetC.ProjectsTable(summaries))
+		},
+	}
+	return cmd
+}
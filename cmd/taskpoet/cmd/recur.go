@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newRecurCmd is the parent of the commands that deal with recurring tasks
+func newRecurCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recur",
+		Short: "Manage recurring tasks",
+		Long: `List, add, pause and resume recurring tasks. A recurring task is a template
+that adds a new task each time its rule comes around, as long as the last one
+it added is done`,
+		Args: cobra.NoArgs,
+	}
+	cmd.AddCommand(newRecurListCmd())
+	cmd.AddCommand(newRecurAddCmd())
+	cmd.AddCommand(newRecurPauseCmd())
+	cmd.AddCommand(newRecurResumeCmd())
+	return cmd
+}
+
+func completeRecur(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	templates, err := poetC.RecurTemplates()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ret := []string{}
+	for _, r := range templates {
+		if !r.IsConfig() && strings.HasPrefix(r.ID, toComplete) {
+			ret = append(ret, r.ID+"\t"+r.Description)
+		}
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
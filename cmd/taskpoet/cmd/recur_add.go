@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+func newRecurAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add DESCRIPTION",
+		Short: "Add a recurring task",
+		Long: `Add a recurring task. The rule says when a new task is added:
+
+  daily, weekly, every 3d     a fixed interval
+  weekdays                    every Monday through Friday
+  every friday                weekly on a given day
+  monthly 15, monthly last    a day of the month
+  last friday                 the last given day of the month
+  after 3d                    this long after the last one was done`,
+		Example: `Water the plants every few days, after they were last watered:
+$ taskpoet recur add --rule "after 3d" Water the plants
+
+Pay rent on the 1st, due by the 5th:
+$ taskpoet recur add --rule "monthly 1" --due 5d --tag home Pay rent
+
+Send the status report on the last Friday of the month:
+$ taskpoet recur add --rule "last friday" --project work Send the status report`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			rule, err := taskpoet.ParseRecurRule(mustGetCmd[string](cmd, "rule"))
+			checkErr(err)
+			opts := []taskpoet.RecurOption{
+				taskpoet.WithRecurTags(mustGetCmd[[]string](cmd, "tag")),
+				taskpoet.WithRecurProject(mustGetCmd[string](cmd, "project")),
+				taskpoet.WithRecurEffortImpact(taskpoet.EffortImpact(mustGetCmd[uint](cmd, "effort-impact"))),
+			}
+			if dueIn := mustGetCmd[string](cmd, "due"); dueIn != "" {
+				due, err := taskpoet.ParseDuration(dueIn)
+				checkErr(err)
+				opts = append(opts, taskpoet.WithRecurDueOffset(due))
+			}
+			r, err := taskpoet.NewRecurTemplate(strings.Join(args, " "), rule, opts...)
+			checkErr(err)
+			checkErr(poetC.AddRecurTemplate(r))
+			log.Info("Added recurring task", "description", r.Description, "rule", r.Rule, "id", r.ID)
+		},
+	}
+	cmd.Flags().StringP("rule", "r", "", "When a new task is added, like weekdays, monthly 15 or after 3d")
+	checkErr(cmd.MarkFlagRequired("rule"))
+	checkErr(cmd.RegisterFlagCompletionFunc("rule", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"daily", "weekly", "weekdays", "every ", "monthly ", "last ", "after "}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}))
+	cmd.Flags().StringP("due", "d", "", "How long after being added each task is due, like 2d")
+	cmd.Flags().StringSliceP("tag", "t", []string{}, "Tags to include in each task")
+	cmd.Flags().StringP("project", "P", "", "Project for each task, use dots for subprojects, like work.infra")
+	checkErr(cmd.RegisterFlagCompletionFunc("project", completeProject))
+	cmd.Flags().UintP("effort-impact", "e", 0, "Effort/Impact Score Assessment for each task")
+	return cmd
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newRecurListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List recurring tasks",
+		Aliases:           []string{"ls"},
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			templates, err := poetC.RecurTemplates()
+			checkErr(err)
+			if len(templates) == 0 {
+				log.Info("No recurring tasks yet, add one with 'taskpoet recur add'")
+				return
+			}
+			fmt.Print(poetC.RecurTable(templates))
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newRecurPauseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "pause ID",
+		Short:             "Stop a recurring task from adding new tasks",
+		Long:              `Stop a recurring task from adding new tasks, until it is resumed. Tasks it already added are left alone`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeRecur,
+		Run: func(cmd *cobra.Command, args []string) {
+			r, err := poetC.GetRecurTemplate(args[0])
+			checkErr(err)
+			checkErr(poetC.PauseRecurTemplate(r, true))
+			log.Info("Paused recurring task", "description", r.Description, "id", r.ID)
+		},
+	}
+	return cmd
+}
+
+func newRecurResumeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "resume ID",
+		Short:             "Let a paused recurring task add new tasks again",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeRecur,
+		Run: func(cmd *cobra.Command, args []string) {
+			r, err := poetC.GetRecurTemplate(args[0])
+			checkErr(err)
+			checkErr(poetC.PauseRecurTemplate(r, false))
+			log.Info("Resumed recurring task", "description", r.Description, "id", r.ID)
+		},
+	}
+	return cmd
+}
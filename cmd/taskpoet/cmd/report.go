@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const reportGroupID = "reports"
+
+// reportsWithArgs reads the reports from the config before the command line
+// is parsed, so each one can be registered as a command
+func reportsWithArgs(args []string) taskpoet.Reports {
+	fs := pflag.NewFlagSet("reports", pflag.ContinueOnError)
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetOutput(io.Discard)
+	file := fs.String("config", "", "")
+	_ = fs.Parse(args)
+
+	v := viper.New()
+	setConfigPath(v, *file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil
+	}
+	var reports taskpoet.Reports
+	if err := v.UnmarshalKey("reports", &reports); err != nil {
+		log.Warn("Could not read the reports from the config", "error", err)
+		return nil
+	}
+	return reports
+}
+
+// addReportCmds adds a command for each report. Reports can't replace a built
+// in command
+func addReportCmds(root *cobra.Command, reports taskpoet.Reports) {
+	if len(reports) == 0 {
+		return
+	}
+	root.AddGroup(&cobra.Group{ID: reportGroupID, Title: "Reports from the config:"})
+	for _, name := range reports.Names() {
+		if found, _, err := root.Find([]string{name}); err == nil && found != root {
+			log.Warn("Skipping report with the same name as a command", "report", name)
+			continue
+		}
+		root.AddCommand(newReportCmd(name, reports[name]))
+	}
+}
+
+// newReportCmd shows a report from the config
+func newReportCmd(name string, r taskpoet.Report) *cobra.Command {
+	short := r.Description
+	if short == "" {
+		short = fmt.Sprintf("Show the %v report", name)
+	}
+	cmd := &cobra.Command{
+		Use:     name + " [QUERY...]",
+		Short:   short,
+		GroupID: reportGroupID,
+		Long: fmt.Sprintf(`%v
+
+This report is defined in the reports section of the config. A query given
+here narrows it down further, see 'taskpoet active --help' for how to write one`, short),
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			tableOpts, err := r.TableOpts(poetC)
+			checkErr(err)
+			checkErr(applyCobra(cmd, args, tableOpts))
+			tableOpts.Filters = append(tableOpts.Filters,
+				taskpoet.FilterQuery,
+				taskpoet.FilterProject,
+				taskpoet.FilterUDA,
+			)
+			fmt.Print(poetC.TaskTable(*tableOpts))
+		},
+	}
+	bindTableOpts(cmd)
+	if r.Limit > 0 {
+		limit := cmd.PersistentFlags().Lookup("limit")
+		checkErr(limit.Value.Set(strconv.Itoa(r.Limit)))
+		limit.DefValue = limit.Value.String()
+	}
+	return cmd
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/charmbracelet/log"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+)
+
+// newRestoreCmd replaces the database with a backup
+func newRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore FILE",
+		Short: "Restore the database from a backup",
+		Long: `Replace everything in the database with the contents of a backup made with
+'taskpoet backup'. Before anything is replaced, a safety copy of the current
+database is written, so a bad restore can itself be restored`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			in, err := os.Open(args[0])
+			checkErr(err)
+			defer func() {
+				_ = in.Close()
+			}()
+
+			safety := mustGetCmd[string](cmd, "safety-copy")
+			if safety == "" {
+				home, herr := homedir.Dir()
+				checkErr(herr)
+				safety = path.Join(home, fmt.Sprintf(".taskpoet.pre-restore-%v.db.gz", time.Now().Format("20060102T150405")))
+			}
+			out, err := os.OpenFile(safety, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+			checkErr(err)
+			checkErr(poetC.Backup(out, true))
+			checkErr(out.Close())
+			log.Info("Saved a safety copy of the current database", "file", safety)
+
+			checkErr(poetC.Restore(in))
+			log.Info("Restored database", "file", args[0])
+		},
+	}
+	cmd.Flags().String("safety-copy", "", "Where to write the safety copy of the current database (default ~/.taskpoet.pre-restore-TIMESTAMP.db.gz)")
+	return cmd
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/internal/ui"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+// newReviewCmd steps through stale tasks
+func newReviewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "review",
+		Short: "Step through active tasks that haven't been reviewed in a while",
+		Long: `Step through active tasks that haven't been reviewed in a while, least
+recently reviewed first. Tasks that were never reviewed count from when they
+were added.
+
+For each task, press a single key to keep it as is, reschedule the due date,
+set the Effort/Impact, add a comment, snooze it until later, complete it or
+delete it. Any of these marks the task as reviewed`,
+		Example: `Review anything not looked at in the last week:
+$ taskpoet review
+
+Only review tasks that have gone a month without a look:
+$ taskpoet review --age 4w`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			age, err := taskpoet.ParseDuration(mustGetCmd[string](cmd, "age"))
+			checkErr(err)
+			tasks, err := poetC.StaleTasks(age)
+			checkErr(err)
+			if len(tasks) == 0 {
+				log.Info("Nothing to review", "age", age)
+				return
+			}
+			final, err := ui.NewReview(poetC, tasks).Run()
+			checkErr(err)
+			log.Info("Review finished", "reviewed", ui.Reviewed(final), "stale", len(tasks))
+		},
+	}
+	cmd.Flags().String("age", "7d", "Review tasks that haven't been reviewed in this long")
+	return cmd
+}
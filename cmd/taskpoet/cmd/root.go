@@ -4,10 +4,11 @@ Package cmd is the command line utility
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
@@ -23,11 +24,12 @@ import (
 )
 
 var (
-	cfgFile   string
-	namespace string
-	poetC     *taskpoet.Poet
-	verbose   bool
-	version   string = "dev"
+	cfgFile     string
+	namespace   string
+	poetC       *taskpoet.Poet
+	verbose     bool
+	version     string = "dev"
+	autoMigrate bool   = true
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -59,18 +61,40 @@ Effort/Impact Assessment, based on Limoncelli concept
 	cmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	addCmds(cmd,
 		newAddCmd(),
+		newBackupCmd(),
 		newFakeitCmd(),
+		newCalibrateCmd(),
 		newCommentCmd(),
 		newCompleteCmd(),
 		newCompletedCmd(),
+		newContextCmd(),
+		newDBCmd(),
 		newDebugCmd(),
 		newDescribeCmd(),
+		newExpiredCmd(),
+		newFsckCmd(),
 		newGetCmd(),
+		newHistoryCmd(),
 		newImportCmd(),
 		newLogCmd(),
+		newModifyCmd(),
+		newMoveCmd(),
+		newNamespaceCmd(),
 		newPluginsCmd(),
+		newProjectsCmd(),
+		newRecurCmd(),
+		newRestoreCmd(),
+		newReviewCmd(),
 		newServerCmd(),
+		newStartCmd(),
+		newStatesCmd(),
+		newStopCmd(),
+		newTemplateCmd(),
+		newTimesheetCmd(),
+		newTrashCmd(),
 		newUICmd(),
+		newUndoCmd(),
+		newVerifyCmd(),
 	)
 	return cmd
 }
@@ -89,7 +113,14 @@ func Execute() {
 	// rootCmd := NewRootCmd()
 
 	rootCmd := NewRootCmd()
+	addReportCmds(rootCmd, reportsWithArgs(os.Args[1:]))
 	cmd, _, err := rootCmd.Find(os.Args[1:])
+	if err == nil && cmd.Annotations[skipMigrateAnnotation] != "" {
+		autoMigrate = false
+	}
+	if err == nil && cmd.Annotations[tagArgsAnnotation] != "" {
+		rootCmd.SetArgs(tagArgsLast(cmd, os.Args[1:]))
+	}
 	// default cmd if no cmd is given
 	if err == nil && cmd.Use == rootCmd.Use && cmd.Flags().Parse(os.Args[1:]) != pflag.ErrHelp {
 		args := append([]string{"active"}, os.Args[1:]...)
@@ -114,22 +145,28 @@ func init() {
 	// will be global for your application.
 }
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	if cfgFile != "" {
+// setConfigPath points v at the config file, which is $HOME/.taskpoet.yaml
+// unless one is given
+func setConfigPath(v *viper.Viper, file string) {
+	if file != "" {
 		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
+		v.SetConfigFile(file)
 	} else {
 		// Find home directory.
 		home, err := homedir.Dir()
 		cobra.CheckErr(err)
 
 		// Search config in home directory with name ".cli" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigName(".taskpoet")
+		v.AddConfigPath(home)
+		v.SetConfigName(".taskpoet")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	v.AutomaticEnv() // read in environment variables that match
+}
+
+// initConfig reads in config file and ENV variables if set.
+func initConfig() {
+	setConfigPath(viper.GetViper(), cfgFile)
 	var err error
 
 	// set global logger with custom options
@@ -143,11 +180,44 @@ func initConfig() {
 	if cerr := viper.ReadInConfig(); cerr == nil {
 		log.Debug("Using config file", "file", viper.ConfigFileUsed())
 	}
-	poetC, err = taskpoet.New(
+	opts := []taskpoet.Option{
 		taskpoet.WithDatabasePath(viper.GetString("dbpath")),
 		taskpoet.WithNamespace(namespace),
 		taskpoet.WithStyling(getTheme(viper.GetString("theme"))),
-	)
+		taskpoet.WithAutoMigrate(autoMigrate),
+		taskpoet.WithCommentLimit(viper.GetInt("comments.limit")),
+	}
+	var udas taskpoet.UDAs
+	checkErr(viper.UnmarshalKey("udas", &udas))
+	if len(udas) > 0 {
+		opts = append(opts, taskpoet.WithUDAs(udas))
+	}
+	var states taskpoet.WorkflowStates
+	checkErr(viper.UnmarshalKey("states", &states))
+	if len(states) > 0 {
+		opts = append(opts, taskpoet.WithWorkflowStates(states))
+	}
+	var templates taskpoet.TaskTemplates
+	checkErr(viper.UnmarshalKey("templates", &templates))
+	if len(templates) > 0 {
+		opts = append(opts, taskpoet.WithTaskTemplates(templates))
+	}
+	var contexts taskpoet.Contexts
+	checkErr(viper.UnmarshalKey("contexts", &contexts))
+	if len(contexts) > 0 {
+		opts = append(opts, taskpoet.WithContexts(contexts))
+	}
+	var reports taskpoet.Reports
+	checkErr(viper.UnmarshalKey("reports", &reports))
+	if len(reports) > 0 {
+		opts = append(opts, taskpoet.WithReports(reports))
+	}
+	store, err := storeWithConfig(viper.GetString("dbtype"), viper.GetString("dbpath"))
+	checkErr(err)
+	if store != nil {
+		opts = append(opts, taskpoet.WithStore(store))
+	}
+	poetC, err = taskpoet.New(opts...)
 	checkErr(err)
 
 	// Declare defaults
@@ -161,7 +231,37 @@ func initConfig() {
 	}
 }
 
+// storeWithConfig returns the configured storage backend. A nil store means
+// use the default, which is bolt
+func storeWithConfig(dbtype, dbpath string) (taskpoet.Store, error) {
+	switch dbtype {
+	case "", "bolt":
+		return nil, nil
+	case "sqlite":
+		if dbpath == "" {
+			home, err := homedir.Dir()
+			if err != nil {
+				return nil, err
+			}
+			dbpath = path.Join(home, ".taskpoet.sqlite")
+		}
+		return taskpoet.NewSQLiteStore(dbpath)
+	case "memory":
+		return taskpoet.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown dbtype: %v", dbtype)
+	}
+}
+
 func checkErr(err error) {
+	var ambiguous *taskpoet.AmbiguousIDError
+	if errors.As(err, &ambiguous) {
+		log.Error("That ID matches more than one task, use more of the ID", "id", ambiguous.PartialID)
+		for _, c := range ambiguous.Candidates {
+			fmt.Fprintf(os.Stderr, "  %-36v  %-10v  %v\n", c.ID, c.State(), c.Description)
+		}
+		os.Exit(1)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -218,14 +318,30 @@ func applyCobra(cmd *cobra.Command, args []string, opts *taskpoet.TableOpts) err
 	if opts.FilterParams.Limit, err = cmd.PersistentFlags().GetInt("limit"); err != nil {
 		return err
 	}
-	var re *regexp.Regexp
-	if len(args) > 0 {
-		opts.FilterParams.Regex = regexp.MustCompile(fmt.Sprintf("(?i)%v", strings.Join(args, " ")))
-		log.Debug("Showing tasks that match", "regex", re)
-	} else {
-		opts.FilterParams.Regex = regexp.MustCompile(".*")
+	if opts.FilterParams.Project, err = cmd.PersistentFlags().GetString("project"); err != nil {
+		return err
+	}
+	if opts.FilterParams.UDAs, err = udaFlags(cmd); err != nil {
+		return err
 	}
-	return nil
+	extra, err := extraColumns(cmd)
+	if err != nil {
+		return err
+	}
+	opts.Columns = append(opts.Columns, extra...)
+	opts.FilterParams.Query, err = queryArgs(args)
+	return err
+}
+
+// queryArgs joins the arguments of a command together and parses them as a
+// single query
+func queryArgs(args []string) (*taskpoet.TaskQuery, error) {
+	q, err := poetC.ParseQuery(strings.Join(args, " "))
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Showing tasks that match", "query", q)
+	return q, nil
 }
 
 func mustTableOptsWithCmd(cmd *cobra.Command, args []string) *taskpoet.TableOpts {
@@ -244,18 +360,68 @@ func tableOptsWithCmd(cmd *cobra.Command, args []string) (*taskpoet.TableOpts, e
 	if opts.FilterParams.Limit, err = cmd.PersistentFlags().GetInt("limit"); err != nil {
 		return nil, err
 	}
-	var re *regexp.Regexp
-	if len(args) > 0 {
-		opts.FilterParams.Regex = regexp.MustCompile(fmt.Sprintf("(?i)%v", strings.Join(args, " ")))
-		log.Debug("Showing tasks that match", "regex", re)
-	} else {
-		opts.FilterParams.Regex = regexp.MustCompile(".*")
+	if opts.FilterParams.Project, err = cmd.PersistentFlags().GetString("project"); err != nil {
+		return nil, err
+	}
+	if opts.FilterParams.UDAs, err = udaFlags(cmd); err != nil {
+		return nil, err
+	}
+	if opts.FilterParams.Query, err = queryArgs(args); err != nil {
+		return nil, err
 	}
 	return opts, nil
 }
 
 func bindTableOpts(cmd *cobra.Command) {
 	cmd.PersistentFlags().IntP("limit", "l", 40, "Limit to N results")
+	cmd.PersistentFlags().StringP("project", "P", "", "Only show tasks in this project, or its subprojects")
+	checkErr(cmd.RegisterFlagCompletionFunc("project", completeProject))
+	bindUDA(cmd, "Only show tasks with this user defined attribute value, as name=value")
+	cmd.PersistentFlags().StringSliceP("column", "C", []string{}, "Extra columns to show, such as a user defined attribute")
+	checkErr(cmd.RegisterFlagCompletionFunc("column", completeUDAName))
+}
+
+// bindUDA adds the repeatable --uda flag
+func bindUDA(cmd *cobra.Command, usage string) {
+	cmd.PersistentFlags().StringArrayP("uda", "u", []string{}, usage)
+	checkErr(cmd.RegisterFlagCompletionFunc("uda", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		ret := []string{}
+		udas := poetC.UDAs
+		for _, name := range udas.Names() {
+			if udas[name].Type != taskpoet.UDAEnum {
+				ret = append(ret, name+"=")
+				continue
+			}
+			for _, value := range udas[name].Values {
+				ret = append(ret, name+"="+value)
+			}
+		}
+		return ret, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}))
+}
+
+// extraColumns returns the --column flags, making sure each one exists
+func extraColumns(cmd *cobra.Command) ([]string, error) {
+	columns := mustGetCmd[[]string](cmd, "column")
+	for _, c := range columns {
+		if err := poetC.ValidateColumn(c); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+// udaFlags parses the --uda flags
+func udaFlags(cmd *cobra.Command) (map[string]string, error) {
+	pairs, err := cmd.Flags().GetStringArray("uda")
+	if err != nil {
+		return nil, err
+	}
+	return poetC.UDAs.ParseFlags(pairs)
+}
+
+func completeUDAName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return poetC.UDAs.Names(), cobra.ShellCompDirectiveNoFileComp
 }
 
 // themeMap maps a string to Theme generators
@@ -272,9 +438,32 @@ func getTheme(n string) themes.Styling {
 	return themes.New()
 }
 
+// completeProject completes with the projects that have tasks
+func completeProject(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projects, err := poetC.Projects()
+	if err != nil {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	ret := []string{}
+	for _, p := range projects {
+		ret = append(ret, p.Name)
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
 func completeActive(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) != 0 {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
-	return poetC.CompleteIDsWithPrefix("/active", toComplete), cobra.ShellCompDirectiveNoFileComp
+	return completeOpenIDs(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeOpenIDs completes the IDs of active tasks, and those in any workflow
+// state
+func completeOpenIDs(toComplete string) []string {
+	ret := []string{}
+	for _, prefix := range poetC.OpenStatePaths() {
+		ret = append(ret, poetC.CompleteIDsWithPrefix(prefix, toComplete)...)
+	}
+	return ret
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRoot returns a function that runs the root command with args, against
+// an empty config and a database only this test uses
+func newTestRoot(t *testing.T) func(args ...string) {
+	config := filepath.Join(t.TempDir(), "taskpoet.yaml")
+	require.NoError(t, os.WriteFile(config, []byte{}, 0o600))
+	t.Setenv("DBPATH", filepath.Join(t.TempDir(), "taskpoet.db"))
+	return func(args ...string) {
+		root := NewRootCmd()
+		root.SetArgs(append([]string{"--config", config}, args...))
+		require.NoError(t, root.Execute())
+		// The next run opens the database again
+		require.NoError(t, poetC.Close())
+	}
+}
+
+func TestFlagsDoNotCollide(t *testing.T) {
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		// Merging in the persistent flags panics on a reused shorthand
+		require.NotPanics(t, func() { c.LocalFlags() }, c.CommandPath())
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(NewRootCmd())
+}
+
+// openTestDB opens the database the test root runs against
+func openTestDB(t *testing.T) *taskpoet.Poet {
+	p := taskpoet.MustNew(taskpoet.WithDatabasePath(os.Getenv("DBPATH")))
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestUndoCmd(t *testing.T) {
+	run := newTestRoot(t)
+	run("add", "water the plants")
+	run("add", "feed the cat")
+	run("undo", "--number", "2")
+	run("undo", "--help")
+	require.Empty(t, openTestDB(t).MustList("/active"))
+}
+
+func TestModifyProjectCmd(t *testing.T) {
+	run := newTestRoot(t)
+	run("add", "move the zone")
+	p := openTestDB(t)
+	id := p.MustList("/active")[0].ID
+	require.NoError(t, p.Close())
+
+	run("modify", id, "-P", "work.infra", "+dns")
+	got, err := openTestDB(t).Task.GetWithID(id, "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, "work.infra", got.Project)
+	require.Equal(t, []string{"dns"}, got.Tags)
+}
+
+func TestModifyDependsCmd(t *testing.T) {
+	run := newTestRoot(t)
+	run("add", "get certs")
+	run("add", "deploy certs")
+	p := openTestDB(t)
+	ids := map[string]string{}
+	for _, task := range p.MustList("/active") {
+		ids[task.Description] = task.ID
+	}
+	require.NoError(t, p.Close())
+
+	run("modify", ids["deploy certs"], "--depends", ids["get certs"])
+	got, err := openTestDB(t).Task.GetWithID(ids["deploy certs"], "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, []string{ids["get certs"]}, got.DependsOn)
+}
@@ -1,16 +1,23 @@
 package cmd
 
 import (
+	"context"
+	"time"
+
 	"github.com/drewstinnett/taskpoet/taskpoet"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // serverCmd represents the server command
 func newServerCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "server",
-		Short:  "Run http server",
-		Long:   `Run an http server capable of hosting an API as well as remote sync and stuff`,
+		Use:   "server",
+		Short: "Run http server",
+		Long: `Run an http server capable of hosting an API as well as remote sync and stuff
+
+With --trash-retention (or trash_retention in the config), deleted tasks older
+than the retention are purged automatically, checking once an hour`,
 		Hidden: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			debug, _ := cmd.PersistentFlags().GetBool("debug")
@@ -18,10 +25,19 @@ func newServerCmd() *cobra.Command {
 				Debug:       debug,
 				LocalClient: poetC,
 			}
+			if retentionS := viper.GetString("trash_retention"); retentionS != "" {
+				retention, err := taskpoet.ParseDuration(retentionS)
+				checkErr(err)
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				go poetC.AutoPurgeTrash(ctx, retention, time.Hour)
+			}
 			r := taskpoet.NewRouter(c)
 			checkErr(r.Run())
 		},
 	}
 	cmd.PersistentFlags().BoolP("debug", "d", false, "Run GIN server in Debug mode")
+	cmd.PersistentFlags().String("trash-retention", "", "Purge deleted tasks older than this, for example 90d")
+	checkErr(viper.BindPFlag("trash_retention", cmd.PersistentFlags().Lookup("trash-retention")))
 	return cmd
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newStartCmd starts the timer on a task
+func newStartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "start TASK",
+		Short: "Start working on a task",
+		Long: `Start the timer on an active task. Only one task runs at a time, so whatever
+was running before is stopped`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeActive,
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := poetC.Task.GetOpenWithPartialID(args[0], "")
+			checkErr(err)
+			stopped, err := poetC.Start(task)
+			checkErr(err)
+			poetC.SetShortIDs(task)
+			if stopped != nil {
+				poetC.SetShortIDs(stopped)
+				log.Info("Stopped task", "task", stopped.Description, "id", stopped.ShortID())
+			}
+			log.Info("Started task", "task", task.Description, "id", task.ShortID())
+		},
+	}
+	return cmd
+}
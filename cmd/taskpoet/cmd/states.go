@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newStatesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "states",
+		Short: "List the states a task can be in",
+		Long: `List the built in states, along with the workflow states from the config, how
+many tasks are in each, and which states a task can be moved to from them`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			table, err := poetC.WorkflowTable()
+			checkErr(err)
+			fmt.Print(table)
+		},
+	}
+	return cmd
+}
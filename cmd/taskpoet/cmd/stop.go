@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newStopCmd stops the running timer
+func newStopCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "stop",
+		Short:             "Stop working on the running task",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			stopped, err := poetC.Stop()
+			checkErr(err)
+			poetC.SetShortIDs(stopped)
+			log.Info("Stopped task", "task", stopped.Description, "id", stopped.ShortID(), "spent", stopped.Intervals[len(stopped.Intervals)-1].Duration().Round(time.Second))
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newTemplateCmd is the parent of the commands that deal with task templates
+func newTemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage task templates for repeatable procedures",
+		Long: `Task templates are repeatable procedures, like an on-call handoff, that
+expand in to a parent task with a child task for each step. Define them under
+templates in the config, or import them in to the database`,
+		Aliases: []string{"templates", "tmpl"},
+		Args:    cobra.NoArgs,
+	}
+	cmd.AddCommand(newTemplateListCmd())
+	cmd.AddCommand(newTemplateApplyCmd())
+	cmd.AddCommand(newTemplateImportCmd())
+	cmd.AddCommand(newTemplateDeleteCmd())
+	return cmd
+}
+
+func completeTemplate(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	templates, err := poetC.Templates()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ret := []string{}
+	for _, name := range templates.Names() {
+		ret = append(ret, name+"\t"+templates[name].Description)
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
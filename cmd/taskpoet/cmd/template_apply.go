@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+func newTemplateApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply NAME [key=value...]",
+		Short: "Add the tasks of a template",
+		Long: `Add the parent task of a template along with a child task for each step, all
+at once. Variables are given as key=value, and override the defaults of the
+template`,
+		Example: `$ taskpoet template apply provision host=db3 env=prod`,
+		Args:    cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completeTemplate(cmd, args, toComplete)
+			}
+			templates, err := poetC.Templates()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			ret := []string{}
+			for _, v := range templates[args[0]].Variables() {
+				ret = append(ret, v+"=")
+			}
+			return ret, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			vars, err := taskpoet.ParseTemplateVars(args[1:])
+			checkErr(err)
+			tasks, err := poetC.ApplyTemplate(args[0], vars)
+			checkErr(err)
+			log.Info("Applied template", "template", args[0], "task", tasks[0].Description, "id", tasks[0].ID, "steps", len(tasks)-1)
+		},
+	}
+	return cmd
+}
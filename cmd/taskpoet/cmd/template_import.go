@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newTemplateImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Store the templates from a file in the database",
+		Long: `Store the templates from a file in the database, replacing any stored ones
+with the same names. The file holds a templates section, just like the config`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			v := viper.New()
+			v.SetConfigFile(args[0])
+			checkErr(v.ReadInConfig())
+			var templates taskpoet.TaskTemplates
+			checkErr(v.UnmarshalKey("templates", &templates))
+			if len(templates) == 0 {
+				log.Warn("No templates found", "file", args[0])
+				return
+			}
+			for _, name := range templates.Names() {
+				checkErr(poetC.SaveTemplate(name, templates[name]))
+				log.Info("Imported template", "name", name)
+			}
+		},
+	}
+	return cmd
+}
+
+func newTemplateDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "delete NAME",
+		Short:             "Remove a template from the database",
+		Aliases:           []string{"rm"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTemplate,
+		Run: func(cmd *cobra.Command, args []string) {
+			checkErr(poetC.DeleteTemplate(args[0]))
+			log.Info("Deleted template", "name", args[0])
+		},
+	}
+	return cmd
+}
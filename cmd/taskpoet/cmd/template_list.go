@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newTemplateListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List task templates",
+		Aliases:           []string{"ls"},
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			templates, err := poetC.Templates()
+			checkErr(err)
+			if len(templates) == 0 {
+				log.Info("No templates yet, add some to the config or use 'taskpoet template import'")
+				return
+			}
+			fmt.Print(poetC.TemplateTable(templates))
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+// newTimesheetCmd reports on time spent
+func newTimesheetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "timesheet",
+		Short:   "Report time spent on tasks, by day, tag and project",
+		Aliases: []string{"ts"},
+		Long: `Report the time spent on tasks between --since and --until, grouped by day,
+tag and project. Both take a date synonym, like socw or yesterday, or a
+duration, which counts back from now`,
+		Example: `Time spent so far this week:
+$ taskpoet timesheet --since socw
+
+Time spent over the last 2 weeks:
+$ taskpoet timesheet --since 2w`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			cal := taskpoet.NewCalendar()
+			since, err := cal.Past(mustGetCmd[string](cmd, "since"))
+			checkErr(err)
+			until, err := cal.Past(mustGetCmd[string](cmd, "until"))
+			checkErr(err)
+			sheet, err := poetC.Timesheet(*since, *until)
+			checkErr(err)
+			fmt.Printf("  %v to %v\n", since.Format("2006-01-02 15:04"), until.Format("2006-01-02 15:04"))
+			fmt.Print(poetC.TimesheetTable(*sheet))
+		},
+	}
+	cmd.Flags().String("since", "socw", "Start of the report")
+	cmd.Flags().String("until", "now", "End of the report")
+	return cmd
+}
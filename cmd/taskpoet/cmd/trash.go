@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newTrashCmd is the parent of the commands that deal with deleted tasks
+func newTrashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage deleted tasks",
+		Long:  `List, restore and purge the tasks that have been deleted`,
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newTrashListCmd())
+	cmd.AddCommand(newTrashRestoreCmd())
+	cmd.AddCommand(newTrashEmptyCmd())
+	return cmd
+}
+
+func completeDeleted(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return poetC.CompleteIDsWithPrefix("/deleted", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+func newTrashEmptyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Purge deleted tasks for good",
+		Long: `Purge deleted tasks from the database. With --older-than, only tasks deleted
+longer ago than that are purged, for example:
+
+$ taskpoet trash empty --older-than 90d`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			olderThan, err := taskpoet.ParseDuration(mustGetCmd[string](cmd, "older-than"))
+			checkErr(err)
+			purged, err := poetC.EmptyTrash(olderThan)
+			checkErr(err)
+			for _, task := range purged {
+				log.Debug("Purged task", "task", task.Description, "id", task.ShortID())
+			}
+			log.Info("Emptied trash", "purged", len(purged))
+		},
+	}
+	cmd.Flags().String("older-than", "0s", "Only purge tasks deleted longer ago than this")
+	return cmd
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/spf13/cobra"
+)
+
+func newTrashListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List deleted tasks",
+		Aliases:           []string{"ls"},
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			tableOpts := &taskpoet.TableOpts{
+				Prefix:  "/deleted",
+				Columns: []string{"ID", "Description", "Deleted", "Reason", "Tags"},
+				SortBy:  taskpoet.ByDeleted{},
+				Filters: []taskpoet.Filter{
+					taskpoet.FilterQuery,
+					taskpoet.FilterProject,
+					taskpoet.FilterUDA,
+				},
+			}
+			checkErr(applyCobra(cmd, args, tableOpts))
+			fmt.Print(poetC.TaskTable(*tableOpts))
+		},
+	}
+	bindTableOpts(cmd)
+	return cmd
+}
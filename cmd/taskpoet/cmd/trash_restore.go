@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func newTrashRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "restore ID",
+		Short:             "Bring a deleted task back",
+		Long:              `Bring a deleted task back to the state it was in before it was deleted`,
+		Aliases:           []string{"undelete"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeDeleted,
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := poetC.Task.GetWithPartialID(args[0], "", "/deleted")
+			checkErr(err)
+			checkErr(poetC.RestoreDeleted(task))
+			poetC.SetShortIDs(task)
+			log.Info("Restored task", "task", task.Description, "id", task.ShortID(), "path", string(task.DetectKeyPath()))
+		},
+	}
+	return cmd
+}
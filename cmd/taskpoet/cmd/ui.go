@@ -11,12 +11,14 @@ import (
 // uiCmd represents the ui command
 func newUICmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "ui",
+		Use:    "ui [QUERY...]",
 		Short:  "Run the UI",
 		Hidden: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("ui called")
-			p := ui.NewUI(poetC)
+			q, err := queryArgs(args)
+			checkErr(err)
+			p := ui.NewUI(poetC, q)
 			if _, err := p.Run(); err != nil {
 				fmt.Printf("Alas, there's been an error: %v", err)
 				os.Exit(1)
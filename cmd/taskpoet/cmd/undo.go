@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newUndoCmd reverses the most recent operations
+func newUndoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Undo the last operations",
+		Long: `Undo the last N adds, edits, completes, deletes, purges or imports, newest first.
+Completed and deleted tasks are moved back to where they were. If a task has
+been changed since, nothing is undone. Recurring tasks that show up, and tasks
+that expire, on their own are left out, so they are never undone`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cmd.Flags().GetInt("number")
+			checkErr(err)
+			undone, err := poetC.Undo(n)
+			checkErr(err)
+			for _, entry := range undone {
+				log.Info("Undid", "operation", entry.Description(), "when", entry.Time.Format("2006-01-02 15:04"))
+			}
+		},
+	}
+	cmd.Flags().Int("number", 1, "Number of operations to undo")
+	return cmd
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// newVerifyCmd checks the stored tasks for problems
+func newVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the database for problems",
+		Long: `Check that every stored task decodes, is stored at the path it belongs at, and
+that no ID is used in more than one state`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: noComplete,
+		Run: func(cmd *cobra.Command, args []string) {
+			report, err := poetC.Verify()
+			checkErr(err)
+			for _, problem := range report.Problems {
+				fmt.Printf("%v: %v\n", problem.Path, problem.Problem)
+			}
+			if !report.OK() {
+				log.Error("Found problems", "checked", report.Checked, "problems", len(report.Problems))
+				os.Exit(1)
+			}
+			log.Info("No problems found", "checked", report.Checked)
+		},
+	}
+	return cmd
+}
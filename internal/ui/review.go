@@ -0,0 +1,267 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/drewstinnett/taskpoet/taskpoet"
+	"github.com/dustin/go-humanize"
+)
+
+var (
+	reviewTitleStyle = lipgloss.NewStyle().Bold(true)
+	reviewLabelStyle = lipgloss.NewStyle().Faint(true).Width(12)
+	reviewHelpStyle  = lipgloss.NewStyle().Faint(true)
+	reviewErrStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// reviewMode is what the review is waiting on from the user
+type reviewMode int
+
+const (
+	reviewChoose reviewMode = iota
+	reviewEffortImpact
+	reviewDue
+	reviewSnooze
+	reviewComment
+)
+
+var reviewPrompts = map[reviewMode]string{
+	reviewDue:     "Due (like 3d or eow): ",
+	reviewSnooze:  "Hide until (like 1w or eom): ",
+	reviewComment: "Comment: ",
+}
+
+type reviewModel struct {
+	client *taskpoet.Poet
+	tasks  taskpoet.Tasks
+	cur    int
+	mode   reviewMode
+	input  textinput.Model
+	status string
+	err    error
+	// reviewed counts the tasks that got an action, as opposed to quitting
+	reviewed int
+}
+
+func newReviewModel(p *taskpoet.Poet, tasks taskpoet.Tasks) reviewModel {
+	return reviewModel{
+		client: p,
+		tasks:  tasks,
+		input:  textinput.New(),
+	}
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m reviewModel) task() *taskpoet.Task {
+	return m.tasks[m.cur]
+}
+
+func (m reviewModel) done() bool {
+	return m.cur >= len(m.tasks)
+}
+
+// next moves on to the next task, quitting once there are none left
+func (m reviewModel) next(status string) (tea.Model, tea.Cmd) {
+	m.status = status
+	m.err = nil
+	m.mode = reviewChoose
+	m.reviewed++
+	m.cur++
+	if m.done() {
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// act runs an action against the current task, moving on if it worked
+func (m reviewModel) act(status string, fn func(*taskpoet.Task) error) (tea.Model, tea.Cmd) {
+	if err := fn(m.task()); err != nil {
+		m.err = err
+		m.mode = reviewChoose
+		return m, nil
+	}
+	return m.next(status)
+}
+
+func (m reviewModel) prompt(mode reviewMode) (tea.Model, tea.Cmd) {
+	m.mode = mode
+	m.err = nil
+	m.input.Reset()
+	m.input.Prompt = reviewPrompts[mode]
+	return m, m.input.Focus()
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if key.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+	switch m.mode {
+	case reviewChoose:
+		return m.updateChoose(key)
+	case reviewEffortImpact:
+		return m.updateEffortImpact(key)
+	default:
+		return m.updateInput(key)
+	}
+}
+
+func (m reviewModel) updateChoose(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "k":
+		return m.act("Kept", m.client.Review)
+	case "x":
+		return m.act("Completed", m.client.ReviewComplete)
+	case "D":
+		return m.act("Deleted", m.client.ReviewDelete)
+	case "e":
+		m.mode = reviewEffortImpact
+		m.err = nil
+		return m, nil
+	case "d":
+		return m.prompt(reviewDue)
+	case "s":
+		return m.prompt(reviewSnooze)
+	case "c":
+		return m.prompt(reviewComment)
+	}
+	return m, nil
+}
+
+func (m reviewModel) updateEffortImpact(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := key.String()
+	if s == "esc" {
+		m.mode = reviewChoose
+		return m, nil
+	}
+	if len(s) != 1 || s[0] < '0' || s[0] > '4' {
+		return m, nil
+	}
+	ei := taskpoet.EffortImpact(s[0] - '0')
+	return m.act(fmt.Sprintf("Set Effort/Impact to %v", ei), func(t *taskpoet.Task) error {
+		t.EffortImpact = ei
+		return m.client.Review(t)
+	})
+}
+
+func (m reviewModel) updateInput(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.Type { //nolint:exhaustive
+	case tea.KeyEsc:
+		m.mode = reviewChoose
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		m.input.Blur()
+		return m.submit(strings.TrimSpace(m.input.Value()))
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(key)
+	return m, cmd
+}
+
+// submit applies whatever was typed at the prompt
+func (m reviewModel) submit(value string) (tea.Model, tea.Cmd) {
+	switch m.mode { //nolint:exhaustive
+	case reviewComment:
+		return m.act("Commented", func(t *taskpoet.Task) error {
+			if err := t.AddComment(value); err != nil {
+				return err
+			}
+			return m.client.Review(t)
+		})
+	case reviewDue, reviewSnooze:
+		when, err := taskpoet.NewCalendar().Date(value)
+		if err != nil {
+			m.err = err
+			m.mode = reviewChoose
+			return m, nil
+		}
+		if m.mode == reviewDue {
+			return m.act("Due "+humanize.Time(*when), func(t *taskpoet.Task) error {
+				t.Due = when
+				return m.client.Review(t)
+			})
+		}
+		return m.act("Hidden until "+humanize.Time(*when), func(t *taskpoet.Task) error {
+			t.HideUntil = when
+			return m.client.Review(t)
+		})
+	}
+	return m, nil
+}
+
+func (m reviewModel) View() string {
+	if m.done() {
+		return ""
+	}
+	t := m.task()
+	var b strings.Builder
+	b.WriteString(reviewTitleStyle.Render(fmt.Sprintf("Reviewing %v of %v", m.cur+1, len(m.tasks))))
+	b.WriteString("\n\n")
+	rows := [][]string{
+		{"Description", t.Description},
+		{"Added", humanize.Time(t.Added)},
+	}
+	if t.Reviewed != nil {
+		rows = append(rows, []string{"Reviewed", humanize.Time(*t.Reviewed)})
+	}
+	if t.Due != nil {
+		rows = append(rows, []string{"Due", humanize.Time(*t.Due)})
+	}
+	rows = append(rows, []string{"E/I", fmt.Sprintf("%v %v", t.EffortImpact.Emoji(), t.EffortImpact)})
+	if t.Project != "" {
+		rows = append(rows, []string{"Project", t.Project})
+	}
+	if len(t.Tags) > 0 {
+		rows = append(rows, []string{"Tags", strings.Join(t.Tags, ",")})
+	}
+	for _, c := range t.Comments {
+		rows = append(rows, []string{"Comment", c.Text})
+	}
+	for _, row := range rows {
+		b.WriteString(reviewLabelStyle.Render(row[0]) + row[1] + "\n")
+	}
+	b.WriteString("\n")
+
+	switch m.mode { //nolint:exhaustive
+	case reviewChoose:
+		b.WriteString(reviewHelpStyle.Render("k keep • d due • e effort/impact • c comment • s snooze • x complete • D delete • q quit"))
+	case reviewEffortImpact:
+		b.WriteString(reviewHelpStyle.Render("0 unset • 1 sweet spot • 2 homework • 3 busywork • 4 charity • esc back"))
+	default:
+		b.WriteString(m.input.View())
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(reviewErrStyle.Render(m.err.Error()) + "\n")
+	} else if m.status != "" {
+		b.WriteString(reviewHelpStyle.Render(m.status) + "\n")
+	}
+	return b.String()
+}
+
+// NewReview returns a program stepping through the given tasks for review.
+// Run returns the final model, which Reviewed can count
+func NewReview(p *taskpoet.Poet, tasks taskpoet.Tasks) *tea.Program {
+	return tea.NewProgram(newReviewModel(p, tasks))
+}
+
+// Reviewed returns how many tasks got an action in a finished review
+func Reviewed(m tea.Model) int {
+	if rm, ok := m.(reviewModel); ok {
+		return rm.reviewed
+	}
+	return 0
+}
@@ -35,30 +35,30 @@ type model struct {
 	client *taskpoet.Poet
 }
 
-func initialModel(l *taskpoet.Poet) model {
+func initialModel(l *taskpoet.Poet, q *taskpoet.TaskQuery) model {
 	m := model{
 		client: l,
 	}
-	results, _ := m.client.Task.List("/active")
+	results, _ := m.client.ListQuery(q)
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Added.Before(results[j].Added)
 	})
 	var tasks []list.Item
 	for _, r := range results {
-		// m.list = append(m.choices, r.Description)
+		due := "none"
+		if r.Due != nil {
+			due = humanize.Time(*r.Due)
+		}
 		ti := taskItem{
 			title:       r.Description,
-			description: fmt.Sprintf("Due: %v Age: %v", humanize.Time(*r.Due), humanize.Time(r.Added)),
+			description: fmt.Sprintf("Due: %v Age: %v", due, humanize.Time(r.Added)),
 		}
 		tasks = append(tasks, ti)
-		m.list = list.New(tasks, list.NewDefaultDelegate(), 0, 0)
-		m.list.Title = "TODO Tasks"
-		/*
-			i := list.Item{
-				ti,
-			}
-		*/
-		// tasks = append(tasks, list.Item{name: r.Description, description: r.ID})
+	}
+	m.list = list.New(tasks, list.NewDefaultDelegate(), 0, 0)
+	m.list.Title = "TODO Tasks"
+	if q.String() != "" {
+		m.list.Title = fmt.Sprintf("TODO Tasks matching %v", q)
 	}
 	return m
 }
@@ -88,9 +88,10 @@ func (m model) View() string {
 	return docStyle.Render(m.list.View())
 }
 
-// NewUI returns a new instance of the UI
-func NewUI(l *taskpoet.Poet) *tea.Program {
-	p := tea.NewProgram(initialModel(l), tea.WithAltScreen())
+// NewUI returns a new instance of the UI, showing the open tasks matching the
+// query
+func NewUI(l *taskpoet.Poet, q *taskpoet.TaskQuery) *tea.Program {
+	p := tea.NewProgram(initialModel(l, q), tea.WithAltScreen())
 	return p
 }
 
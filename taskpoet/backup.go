@@ -0,0 +1,186 @@
+package taskpoet
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+/*
+Backups are always a bolt database file, no matter which Store they came from,
+optionally gzipped. A backup holds every namespace, not just the current one.
+*/
+
+// Backuper is a Store that knows how to write a consistent copy of itself as a
+// bolt database
+type Backuper interface {
+	Backup(w io.Writer) error
+}
+
+// Backup writes the database using a single bolt read transaction, so it is
+// consistent even while other transactions are running
+func (s *BoltStore) Backup(w io.Writer) error {
+	return s.DB.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	})
+}
+
+// Backup writes a copy of the whole database to w, gzipped if compress is set
+func (p *Poet) Backup(w io.Writer, compress bool) error {
+	if compress {
+		gz := gzip.NewWriter(w)
+		if err := p.backup(gz); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
+	return p.backup(w)
+}
+
+func (p *Poet) backup(w io.Writer) error {
+	if b, ok := p.Store.(Backuper); ok {
+		return b.Backup(w)
+	}
+	// Anything else gets copied in to a scratch bolt database first
+	return withScratchBolt(func(scratch *BoltStore) error {
+		if err := p.Store.View(func(src StoreTx) error {
+			return scratch.Update(func(dst StoreTx) error {
+				return copyStore(dst, src)
+			})
+		}); err != nil {
+			return err
+		}
+		return scratch.Backup(w)
+	})
+}
+
+// Restore replaces everything in the database with the contents of a backup.
+// Gzipped backups are detected automatically
+func (p *Poet) Restore(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil {
+		return fmt.Errorf("could not read backup: %w", err)
+	}
+	var src io.Reader = br
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		src = gz
+	}
+	return withScratchDir(func(dir string) error {
+		// bolt needs a real file, so write the backup out and open that
+		f, err := os.Create(path.Join(dir, "restore.db"))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, src); err != nil {
+			return errors.Join(err, f.Close())
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		restored, err := NewBoltStore(f.Name())
+		if err != nil {
+			return fmt.Errorf("backup is not a valid database: %w", err)
+		}
+		defer func() {
+			_ = restored.Close()
+		}()
+		if err := restored.View(func(src StoreTx) error {
+			v, err := schemaVersion(src)
+			if err != nil {
+				return err
+			}
+			if v > CurrentSchemaVersion() {
+				return fmt.Errorf("backup schema version %v is newer than this taskpoet supports (%v)", v, CurrentSchemaVersion())
+			}
+			return p.Store.Update(func(dst StoreTx) error {
+				buckets, err := dst.Buckets()
+				if err != nil {
+					return err
+				}
+				for _, b := range buckets {
+					if err := dst.DeleteBucket(b); err != nil {
+						return err
+					}
+				}
+				if err := copyStore(dst, src); err != nil {
+					return err
+				}
+				// Make sure the buckets for this namespace are around, even
+				// if the backup didn't have them
+				for _, b := range namespaceBucketList(p.Namespace) {
+					if err := dst.CreateBucket(b); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}); err != nil {
+			return err
+		}
+		if p.autoMigrate {
+			_, err := p.Migrate(false)
+			return err
+		}
+		return nil
+	})
+}
+
+// withScratchDir runs fn with a temporary directory that is cleaned up after
+func withScratchDir(fn func(dir string) error) error {
+	dir, err := os.MkdirTemp("", "taskpoet-backup")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	return fn(dir)
+}
+
+// withScratchBolt runs fn with an empty bolt database in a temporary directory
+func withScratchBolt(fn func(*BoltStore) error) error {
+	return withScratchDir(func(dir string) error {
+		scratch, err := NewBoltStore(path.Join(dir, "scratch.db"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = scratch.Close()
+		}()
+		return fn(scratch)
+	})
+}
+
+// copyStore copies every bucket and key from src in to dst
+func copyStore(dst, src StoreTx) error {
+	buckets, err := src.Buckets()
+	if err != nil {
+		return err
+	}
+	for _, b := range buckets {
+		if err := dst.CreateBucket(b); err != nil {
+			return err
+		}
+		if err := src.ForEach(b, nil, func(k, v []byte) error {
+			return dst.Put(b, copyBytes(k), copyBytes(v))
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
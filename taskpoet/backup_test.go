@@ -0,0 +1,81 @@
+package taskpoet
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	for name, s := range testStores(t) {
+		for _, compress := range []bool{false, true} {
+			p, err := New(WithStore(s))
+			require.NoError(t, err, name)
+			task, err := p.Task.Add(MustNewTask("keep me", WithTags([]string{"work"})))
+			require.NoError(t, err, name)
+
+			var buf bytes.Buffer
+			require.NoError(t, p.Backup(&buf, compress), name)
+			if compress {
+				require.Equal(t, []byte{0x1f, 0x8b}, buf.Bytes()[0:2], name)
+			}
+
+			// Things go wrong after the backup
+			require.NoError(t, p.Task.Purge(task), name)
+			_, err = p.Task.Add(MustNewTask("should not survive"))
+			require.NoError(t, err, name)
+
+			require.NoError(t, p.Restore(&buf), name)
+			got := p.MustList("")
+			require.Equal(t, 1, len(got), name)
+			require.Equal(t, "keep me", got[0].Description, name)
+			tagged, err := p.Task.ListWithTag("/active", "work")
+			require.NoError(t, err, name)
+			require.Equal(t, 1, len(tagged), name)
+
+			require.NoError(t, p.Task.Purge(task), name)
+		}
+	}
+}
+
+func TestRestoreGarbage(t *testing.T) {
+	p := newTestPoet(t)
+	_, err := p.Task.Add(MustNewTask("keep me"))
+	require.NoError(t, err)
+	require.Error(t, p.Restore(bytes.NewReader([]byte("this is not a database"))))
+	require.Equal(t, 1, len(p.MustList("")))
+}
+
+func TestVerify(t *testing.T) {
+	p := newTestPoet(t)
+	task, err := p.Task.Add(MustNewTask("fine", WithID("fine")))
+	require.NoError(t, err)
+	report, err := p.Verify()
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.Equal(t, 1, report.Checked)
+
+	require.NoError(t, p.Store.Update(func(tx StoreTx) error {
+		require.NoError(t, tx.Put(p.bucket, []byte("/active/builtin/garbage"), []byte("nope")))
+		// Same ID in a second state
+		require.NoError(t, tx.Put(p.bucket, []byte("/completed/builtin/fine"), mustJSON(t, task)))
+		return nil
+	}))
+	report, err = p.Verify()
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, []VerifyProblem{
+		{Path: "/active/builtin/garbage", Problem: "does not decode as a task: invalid character 'o' in literal null (expecting 'u')"},
+		{Path: "/completed/builtin/fine", Problem: "stored at the wrong path, should be /active/builtin/fine"},
+		{Path: "/active/builtin/fine", Problem: "duplicate ID builtin/fine, also at /completed/builtin/fine"},
+		{Path: "/completed/builtin/fine", Problem: "duplicate ID builtin/fine, also at /active/builtin/fine"},
+	}, report.Problems)
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,175 @@
+package taskpoet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+// WithCommentLimit sets how many of the latest comments are shown along with
+// the description in table views. 0 shows all of them
+func WithCommentLimit(n int) Option {
+	if n < 0 {
+		return failure(errors.New("comment limit cannot be negative"))
+	}
+	return success(func(p *Poet) {
+		p.commentLimit = n
+	})
+}
+
+// DescriptionDetails is the description of a task with its latest comments, as
+// many as the comment limit allows
+func (p *Poet) DescriptionDetails(t Task) string {
+	return t.descriptionDetails(p.commentLimit)
+}
+
+// ShortID is the first few characters of the comment ID
+func (c Comment) ShortID() string {
+	return c.ID[0:min(len(c.ID), 5)]
+}
+
+// commentIndex returns the index of the comment whose ID starts with id
+func (t Task) commentIndex(id string) (int, error) {
+	if id == "" {
+		return -1, errors.New("comment id must not be empty")
+	}
+	found := -1
+	for idx, c := range t.Comments {
+		if c.ID == id {
+			return idx, nil
+		}
+		if strings.HasPrefix(c.ID, id) {
+			if found != -1 {
+				return -1, fmt.Errorf("comment id %v matches more than one comment", id)
+			}
+			found = idx
+		}
+	}
+	if found == -1 {
+		return -1, fmt.Errorf("no comment matching %v on task %v", id, t.ShortID())
+	}
+	return found, nil
+}
+
+// GetComment returns the comment whose ID starts with id
+func (t Task) GetComment(id string) (*Comment, error) {
+	idx, err := t.commentIndex(id)
+	if err != nil {
+		return nil, err
+	}
+	return &t.Comments[idx], nil
+}
+
+// EditComment replaces the text of a comment, keeping its ID and when it was
+// added
+func (t *Task) EditComment(id, s string) error {
+	if strings.TrimSpace(s) == "" {
+		return errors.New("text must not be empty")
+	}
+	idx, err := t.commentIndex(id)
+	if err != nil {
+		return err
+	}
+	t.Comments[idx].Text = s
+	t.Comments[idx].Edited = nowPTR()
+	return nil
+}
+
+// DeleteComment removes a comment from the task
+func (t *Task) DeleteComment(id string) error {
+	idx, err := t.commentIndex(id)
+	if err != nil {
+		return err
+	}
+	t.Comments = append(t.Comments[:idx], t.Comments[idx+1:]...)
+	if len(t.Comments) == 0 {
+		t.Comments = nil
+	}
+	return nil
+}
+
+// CommentTable returns a table of the comments on a task
+func (p *Poet) CommentTable(t Task) string {
+	rows := make([][]string, len(t.Comments))
+	for idx, c := range t.Comments {
+		edited := ""
+		if c.Edited != nil {
+			edited = c.Edited.Format("2006-01-02")
+		}
+		rows[idx] = []string{c.ShortID(), c.Added.Format("2006-01-02"), edited, c.Text}
+	}
+	return p.listTable([]string{"ID", "Added", "Edited", "Text"}, rows)
+}
+
+// renderComments renders each comment as markdown, under a line with its ID
+// and dates. Output that isn't going to a terminal gets no colors
+func renderComments(comments []Comment, width int) (string, error) {
+	style := glamour.WithAutoStyle()
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		style = glamour.WithStandardStyle("notty")
+	}
+	r, err := glamour.NewTermRenderer(style, glamour.WithWordWrap(width))
+	if err != nil {
+		return "", err
+	}
+	doc := strings.Builder{}
+	for _, c := range comments {
+		header := fmt.Sprintf("  %v %v", c.ShortID(), descDate(c.Added))
+		if c.Edited != nil {
+			header += fmt.Sprintf(", edited %v", c.Edited.Format("2006-01-02 15:04"))
+		}
+		doc.WriteString(header + "\n")
+		body, err := r.Render(c.Text)
+		if err != nil {
+			return "", err
+		}
+		doc.WriteString(strings.TrimRight(body, "\n") + "\n\n")
+	}
+	return doc.String(), nil
+}
+
+// commentIDMigrate gives an ID to every stored comment that doesn't have one
+func commentIDMigrate(record map[string]any) (bool, error) {
+	raw, ok := record["comments"].([]any)
+	if !ok {
+		return false, nil
+	}
+	changed := false
+	for idx, item := range raw {
+		c, ok := item.(map[string]any)
+		if !ok {
+			return false, fmt.Errorf("comment %v is not an object", idx)
+		}
+		if id, _ := c["id"].(string); id != "" {
+			continue
+		}
+		c["id"] = uuid.New().String()
+		changed = true
+	}
+	return changed, nil
+}
+
+// commentRenderWidth is the width comments are wrapped at in DescribeTask when
+// the terminal size is not known
+const commentRenderWidth = 80
+
+func commentWidth(w int) int {
+	if w <= 0 {
+		return commentRenderWidth
+	}
+	return max(w-4, 20)
+}
+
+// plainComments is the comments without any markdown rendering
+func plainComments(comments []Comment) string {
+	doc := strings.Builder{}
+	for _, c := range comments {
+		doc.WriteString(fmt.Sprintf("  %v %v\n%v\n\n", c.ShortID(), descDate(c.Added), c.Text))
+	}
+	return doc.String()
+}
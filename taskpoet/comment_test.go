@@ -0,0 +1,113 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDeleteComment(t *testing.T) {
+	p := newTestPoet(t)
+	task := MustNewTask("comment on me")
+	require.NoError(t, task.AddComment("frist"))
+	require.NoError(t, task.AddComment("second"))
+	task.Comments[0].ID = "aaaa-1"
+	task.Comments[1].ID = "aaab-2"
+	_, err := p.Task.Add(task)
+	require.NoError(t, err)
+
+	require.EqualError(t, task.EditComment("aaa", "first"), "comment id aaa matches more than one comment")
+	require.EqualError(t, task.EditComment("aaaa", ""), "text must not be empty")
+	require.Error(t, task.EditComment("zzz", "first"))
+
+	added := task.Comments[0].Added
+	require.NoError(t, task.EditComment("aaaa", "first"))
+	_, err = p.Task.Edit(task)
+	require.NoError(t, err)
+
+	got, err := p.Task.GetWithID(task.ID, "", "")
+	require.NoError(t, err)
+	c, err := got.GetComment("aaaa")
+	require.NoError(t, err)
+	require.Equal(t, "first", c.Text)
+	require.Equal(t, "aaaa-1", c.ID)
+	require.True(t, added.Equal(c.Added))
+	require.NotNil(t, c.Edited)
+
+	require.NoError(t, got.DeleteComment("aaab-2"))
+	require.Equal(t, 1, len(got.Comments))
+	require.NoError(t, got.DeleteComment("aaaa"))
+	require.Nil(t, got.Comments)
+}
+
+func TestNewCommentHasID(t *testing.T) {
+	a, err := NewComment("one")
+	require.NoError(t, err)
+	b, err := NewComment("two")
+	require.NoError(t, err)
+	require.NotEmpty(t, a.ID)
+	require.NotEqual(t, a.ID, b.ID)
+}
+
+func TestCommentLimit(t *testing.T) {
+	task := MustNewTask("chatty")
+	for _, s := range []string{"one", "two", "three"} {
+		require.NoError(t, task.AddComment(s))
+	}
+	require.Contains(t, task.DescriptionDetails(), "one")
+
+	_, err := New(WithDatabasePath(mustTempDB(t)), WithCommentLimit(-1))
+	require.Error(t, err)
+
+	p := MustNew(WithDatabasePath(mustTempDB(t)), WithCommentLimit(2))
+	got := p.DescriptionDetails(*task)
+	require.NotContains(t, got, "one")
+	require.Contains(t, got, "(1 earlier comments)")
+	require.Contains(t, got, "three")
+	require.Equal(t, got, p.mustColumnValue("Description", *task))
+
+	// Other Poets keep their own limit
+	require.Contains(t, newTestPoet(t).DescriptionDetails(*task), "one")
+}
+
+func TestDescribeTaskComments(t *testing.T) {
+	p := newTestPoet(t)
+	task := MustNewTask("described")
+	require.NoError(t, task.AddComment("- first step\n- second step"))
+	got := p.DescribeTask(*task)
+	require.Contains(t, got, task.Comments[0].ShortID())
+	require.Contains(t, got, "• first step")
+	require.NotContains(t, got, "- first step")
+}
+
+func TestMigrateCommentIDs(t *testing.T) {
+	s := NewMemoryStore()
+	putRaw(t, s, "/default/tasks", "/active/builtin/old", `{"id":"old","description":"old","comments":[{"added":"2023-01-02T00:00:00Z","text":"no id"},{"id":"kept","text":"has id"}]}`)
+
+	p, err := New(WithStore(s))
+	require.NoError(t, err)
+	got, err := p.Task.GetWithID("old", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(got.Comments))
+	require.NotEmpty(t, got.Comments[0].ID)
+	require.Equal(t, "kept", got.Comments[1].ID)
+}
+
+func TestTWImportAnnotations(t *testing.T) {
+	p := newTestPoet(t)
+	var ts TaskWarriorTasks
+	require.NoError(t, json.Unmarshal([]byte(`[{"uuid":"annotated","description":"annotated","status":"pending","entry":"20230928T211203Z","annotations":[{"entry":"20230929T101500Z","description":"first note"},{"description":"no entry"},{"description":""}]}]`), &ts))
+	imported, err := p.ImportTaskWarrior(ts, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, imported)
+
+	got, err := p.Task.GetWithID("annotated", "", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(got.Comments))
+	require.Equal(t, "first note", got.Comments[0].Text)
+	require.Equal(t, "2023-09-29", got.Comments[0].Added.Format("2006-01-02"))
+	require.Equal(t, "no entry", got.Comments[1].Text)
+	require.True(t, got.Added.Equal(got.Comments[1].Added))
+	require.NotEmpty(t, got.Comments[1].ID)
+}
@@ -0,0 +1,189 @@
+package taskpoet
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+/*
+Contexts are named queries for switching between modes, like work and home.
+They are declared in the config, like:
+
+	contexts:
+	  work:
+	    filter: project:work or +oncall
+	    project: work
+	  home:
+	    filter: project:home
+	    tags: [home]
+
+Setting a context stores it in the /meta bucket, so it stays set until it is
+cleared. While it is set, task tables and ID completion only show tasks that
+match its filter, and new tasks get its tags, along with its project unless
+they already have their own.
+*/
+
+// activeContextKey is where the name of the active context is stored in the
+// /meta bucket
+const activeContextKey = "context"
+
+// Context is a named filter, with defaults for new tasks
+type Context struct {
+	Filter  string   `mapstructure:"filter" yaml:"filter"`
+	Project string   `mapstructure:"project" yaml:"project,omitempty"`
+	Tags    []string `mapstructure:"tags" yaml:"tags,omitempty"`
+}
+
+// Contexts maps the name of each context to its definition
+type Contexts map[string]Context
+
+// Names returns the context names, sorted
+func (c Contexts) Names() []string {
+	ret := make([]string, 0, len(c))
+	for name := range c {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Validate makes sure the project is valid. The filter may use UDAs, so it is
+// checked by the Poet
+func (c Context) Validate() error {
+	return ValidateProject(c.Project)
+}
+
+// validateContexts makes sure the filter of every context parses
+func (p *Poet) validateContexts() error {
+	for _, name := range p.Contexts.Names() {
+		if _, err := p.ParseQuery(p.Contexts[name].Filter); err != nil {
+			return fmt.Errorf("context %v: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// WithContexts sets the contexts from the config
+func WithContexts(c Contexts) Option {
+	for _, name := range c.Names() {
+		if name == "none" {
+			return failure(fmt.Errorf("context can't be called none, that is how a context is cleared"))
+		}
+		if err := c[name].Validate(); err != nil {
+			return failure(fmt.Errorf("context %v: %w", name, err))
+		}
+	}
+	return success(func(p *Poet) {
+		p.Contexts = c
+	})
+}
+
+// WithContextDefaults gives a new task the project and tags of a context. A
+// project the task already has is kept
+func WithContextDefaults(c *Context) TaskOption {
+	return func(t *Task) {
+		if c == nil {
+			return
+		}
+		if t.Project == "" {
+			t.Project = c.Project
+		}
+		for _, tag := range c.Tags {
+			if !containsString(t.Tags, tag) {
+				t.Tags = append(t.Tags, tag)
+			}
+		}
+	}
+}
+
+// SetContext makes the named context the active one. An empty name, or none,
+// clears it
+func (p *Poet) SetContext(name string) error {
+	if name == "" || name == "none" {
+		return p.Store.Update(func(tx StoreTx) error {
+			return tx.Delete(metaBucket, []byte(activeContextKey))
+		})
+	}
+	if _, ok := p.Contexts[name]; !ok {
+		return fmt.Errorf("no such context: %v", name)
+	}
+	return p.Store.Update(func(tx StoreTx) error {
+		return tx.Put(metaBucket, []byte(activeContextKey), []byte(name))
+	})
+}
+
+// ActiveContext returns the name and definition of the active context, or an
+// empty name when there isn't one. A stored context that has since been
+// taken out of the config is ignored
+func (p Poet) ActiveContext() (string, *Context, error) {
+	var name string
+	if err := p.Store.View(func(tx StoreTx) error {
+		got, err := tx.Get(metaBucket, []byte(activeContextKey))
+		name = string(got)
+		return err
+	}); err != nil {
+		return "", nil, err
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+	c, ok := p.Contexts[name]
+	if !ok {
+		log.Warn("The active context is not in the config, ignoring it", "context", name)
+		return "", nil, nil
+	}
+	return name, &c, nil
+}
+
+// contextQuery returns the query of the active context, which is nil when
+// there isn't one
+func (p Poet) contextQuery() (string, *TaskQuery) {
+	name, c, err := p.ActiveContext()
+	if err != nil || c == nil {
+		if err != nil {
+			log.Warn("Could not look up the active context", "error", err)
+		}
+		return "", nil
+	}
+	// Parsed each time it is used, so dates like eow stay current
+	q, err := p.ParseQuery(c.Filter)
+	if err != nil {
+		log.Warn("Could not parse the filter of the active context", "context", name, "error", err)
+		return "", nil
+	}
+	return name, q
+}
+
+// ContextTable returns a table of the contexts, marking the active one
+func (p *Poet) ContextTable() (string, error) {
+	active, _, err := p.ActiveContext()
+	if err != nil {
+		return "", err
+	}
+	rows := [][]string{}
+	for _, name := range p.Contexts.Names() {
+		c := p.Contexts[name]
+		marker := ""
+		if name == active {
+			marker = "*"
+		}
+		rows = append(rows, []string{marker, name, c.Filter, c.Project, strings.Join(c.Tags, ",")})
+	}
+	return p.listTable([]string{"", "Name", "Filter", "Project", "Tags"}, rows), nil
+}
+
+func addContextFooter(doc io.StringWriter, width int, name string) {
+	if name == "" {
+		return
+	}
+	_, _ = doc.WriteString("\n")
+	_, _ = doc.WriteString(
+		lipgloss.NewStyle().Italic(true).Width(width - 3).Align(lipgloss.Right).Render(
+			fmt.Sprintf("Context: %v", name)),
+	)
+}
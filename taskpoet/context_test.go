@@ -0,0 +1,98 @@
+package taskpoet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newContextPoet(t *testing.T) *Poet {
+	return MustNew(
+		WithDatabasePath(mustTempDB(t)),
+		WithContexts(Contexts{
+			"work": {Filter: "project:work or +oncall", Project: "work", Tags: []string{"office"}},
+			"home": {Filter: "project:home"},
+		}),
+	)
+}
+
+func TestWithContextsValidates(t *testing.T) {
+	_, err := New(WithDatabasePath(mustTempDB(t)), WithContexts(Contexts{"bad": {Filter: "(work"}}))
+	require.EqualError(t, err, "context bad: invalid query at column 1: missing closing parenthesis, near \"(work\"")
+
+	_, err = New(WithDatabasePath(mustTempDB(t)), WithContexts(Contexts{"none": {Filter: "work"}}))
+	require.Error(t, err)
+}
+
+func TestSetContext(t *testing.T) {
+	p := newContextPoet(t)
+	name, c, err := p.ActiveContext()
+	require.NoError(t, err)
+	require.Equal(t, "", name)
+	require.Nil(t, c)
+
+	require.EqualError(t, p.SetContext("school"), "no such context: school")
+	require.NoError(t, p.SetContext("work"))
+	name, c, err = p.ActiveContext()
+	require.NoError(t, err)
+	require.Equal(t, "work", name)
+	require.Equal(t, "work", c.Project)
+
+	require.NoError(t, p.SetContext("none"))
+	name, _, err = p.ActiveContext()
+	require.NoError(t, err)
+	require.Equal(t, "", name)
+}
+
+func TestContextTaskTable(t *testing.T) {
+	p := newContextPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("file the expense report", WithProject("work")),
+		MustNewTask("answer the page", WithTags([]string{"oncall"})),
+		MustNewTask("mow the lawn", WithProject("home")),
+	}))
+	opts := TableOpts{Prefix: "/active", Columns: []string{"ID", "Description"}}
+
+	got := p.TaskTable(opts)
+	require.Contains(t, got, "mow the lawn")
+	require.NotContains(t, got, "Context:")
+
+	require.NoError(t, p.SetContext("work"))
+	got = p.TaskTable(opts)
+	require.Contains(t, got, "file the expense report")
+	require.Contains(t, got, "answer the page")
+	require.NotContains(t, got, "mow the lawn")
+	require.Contains(t, got, "Context: work")
+
+	completions := p.CompleteIDsWithPrefix("/active", "")
+	require.Equal(t, 2, len(completions))
+}
+
+func TestCompleteIDsRefreshesForContext(t *testing.T) {
+	p := MustNew(
+		WithDatabasePath(mustTempDB(t)),
+		WithContexts(Contexts{"ready": {Filter: "blocked:false"}}),
+	)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("order the parts", WithID("parts-order")),
+		MustNewTask("build the shed", WithID("shed-build"), WithDependsOn("parts-order")),
+	}))
+	require.NoError(t, p.SetContext("ready"))
+
+	completions := p.CompleteIDsWithPrefix("/active", "")
+	require.Equal(t, []string{"part\torder the parts"}, completions)
+}
+
+func TestWithContextDefaults(t *testing.T) {
+	c := &Context{Project: "work", Tags: []string{"office"}}
+	got := MustNewTask("plain", WithContextDefaults(c))
+	require.Equal(t, "work", got.Project)
+	require.Equal(t, []string{"office"}, got.Tags)
+
+	got = MustNewTask("own project", WithProject("home"), WithTags([]string{"office", "yard"}), WithContextDefaults(c))
+	require.Equal(t, "home", got.Project)
+	require.Equal(t, []string{"office", "yard"}, got.Tags)
+
+	got = MustNewTask("no context", WithContextDefaults(nil))
+	require.Equal(t, "", got.Project)
+}
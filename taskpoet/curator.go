@@ -31,6 +31,18 @@ var defaultWeightMap = weightMap{
 		}
 		return 0, 0, ""
 	},
+	"blocked": func(t Task) (float64, int, string) {
+		if t.IsBlocked() {
+			return blockedUrgency, 1, "blocked"
+		}
+		return 0, 0, ""
+	},
+	"blocking": func(t Task) (float64, int, string) {
+		if t.blocking > 0 {
+			return blockingUrgency, 1, fmt.Sprintf("blocking %v", t.blocking)
+		}
+		return 0, 0, ""
+	},
 	"next": func(t Task) (float64, int, string) {
 		for _, tag := range t.Tags {
 			if tag == "next" {
@@ -68,6 +80,20 @@ var defaultWeightMap = weightMap{
 // Curator decides how important (or Urgent) something is
 type Curator struct {
 	weights weightMap
+	// stateUrgency is added to tasks in each workflow state
+	stateUrgency map[string]float64
+}
+
+// stateWeight is the urgency adjustment for the workflow state of an open task
+func (c Curator) stateWeight(t Task) (float64, int, string) {
+	if !t.IsOpen() {
+		return 0, 0, ""
+	}
+	state := t.State()
+	if u, ok := c.stateUrgency[state]; ok {
+		return u, 1, state
+	}
+	return 0, 0, ""
 }
 
 // Weigh returns the weight of a task by a curator
@@ -77,7 +103,8 @@ func (c Curator) Weigh(t Task) float64 {
 		co, multi, _ := wr(t)
 		ret += co * float64(multi)
 	}
-	return ret
+	co, multi, _ := c.stateWeight(t)
+	return ret + co*float64(multi)
 }
 
 // WeighAndDescribe returns not only the weight, but descriptions of how we got there
@@ -96,6 +123,10 @@ func (c Curator) WeighAndDescribe(t Task) (float64, []WeightDescription) {
 			})
 		}
 	}
+	if co, multi, unit := c.stateWeight(t); multi != 0 {
+		ret += co * float64(multi)
+		ds = append(ds, WeightDescription{Name: "state", Coefficient: co, Multiplier: multi, Unit: unit})
+	}
 	return ret, ds
 }
 
@@ -114,6 +145,13 @@ func WithWeights(w weightMap) func(*Curator) {
 	}
 }
 
+// WithStateUrgency sets the urgency added to tasks in each workflow state
+func WithStateUrgency(u map[string]float64) func(*Curator) {
+	return func(c *Curator) {
+		c.stateUrgency = u
+	}
+}
+
 // NewCurator returns a new Curator instance with functional options
 func NewCurator(options ...func(*Curator)) *Curator {
 	c := &Curator{
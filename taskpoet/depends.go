@@ -0,0 +1,152 @@
+package taskpoet
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+Dependencies are separate from parents and children. A task that depends on
+another can't be started until the other is completed. They are stored on the
+blocked task only, in DependsOn, and may point at a task in any state. A task
+is blocked while any of the tasks it depends on are still active.
+*/
+
+const (
+	blockedUrgency  = -5
+	blockingUrgency = 8
+)
+
+// WithDependsOn sets the IDs of the tasks that must be completed first on
+// create
+func WithDependsOn(ids ...string) TaskOption {
+	return func(t *Task) {
+		t.DependsOn = append(t.DependsOn, ids...)
+	}
+}
+
+// IsBlocked is true when the task depends on an active task. Only known after
+// the task has been refreshed
+func (t Task) IsBlocked() bool {
+	return len(t.blockedBy) > 0
+}
+
+// DependOn makes t depend on blocker, refusing anything that would mean a task
+// ends up waiting on itself
+func (p *Poet) DependOn(t, blocker *Task) error {
+	all, err := p.Task.List("")
+	if err != nil {
+		return err
+	}
+	if err := addDependency(linkGraph(all), t, blocker.ID); err != nil {
+		return err
+	}
+	return p.Task.EditSet([]Task{*t})
+}
+
+// addDependency makes t depend on blocker, as long as it doesn't already and
+// that doesn't leave a task in graph waiting on itself
+func addDependency(graph map[string]*Task, t *Task, blocker string) error {
+	if t.ID == blocker {
+		return fmt.Errorf("%v cannot depend on itself: %w", t.ID, ErrLinkCycle)
+	}
+	if containsString(t.DependsOn, blocker) {
+		return fmt.Errorf("%v already depends on %v: %w", t.ID, blocker, ErrDuplicateLink)
+	}
+	// The task we were handed may be newer than what is in the graph
+	graph[t.ID] = t
+	if dependsOn(graph, blocker, t.ID) {
+		return fmt.Errorf("%v already depends on %v: %w", blocker, t.ID, ErrLinkCycle)
+	}
+	t.DependsOn = append(t.DependsOn, blocker)
+	return nil
+}
+
+// dependsOn is true if from waits on to, directly or through other tasks
+func dependsOn(graph map[string]*Task, from, to string) bool {
+	seen := map[string]bool{}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == to {
+			return true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if t, ok := graph[id]; ok {
+			queue = append(queue, t.DependsOn...)
+		}
+	}
+	return false
+}
+
+// setDependencies works out which tasks are blocked, and how many tasks each
+// one is blocking, using every active task
+func (p Poet) setDependencies(ts Tasks) {
+	active, err := p.ListOpen()
+	if err != nil {
+		return
+	}
+	ids := make([]string, len(active))
+	byID := make(map[string]bool, len(active))
+	for idx, t := range active {
+		ids[idx] = t.ID
+		byID[t.ID] = true
+	}
+	short := uniquePrefixes(ids, minShortIDLen)
+	blocking := map[string]int{}
+	for _, t := range active {
+		for _, id := range t.DependsOn {
+			if byID[id] {
+				blocking[id]++
+			}
+		}
+	}
+	for _, t := range ts {
+		t.blockedBy = nil
+		if t.Completed == nil && t.Deleted == nil {
+			for _, id := range t.DependsOn {
+				if byID[id] {
+					t.blockedBy = append(t.blockedBy, short[id])
+				}
+			}
+		}
+		t.blocking = blocking[t.ID]
+	}
+}
+
+// Unblocked returns the active tasks that were waiting on t, and are not
+// waiting on anything else anymore. Use it after completing t
+func (p *Poet) Unblocked(t *Task) (Tasks, error) {
+	active, err := p.ListOpen()
+	if err != nil {
+		return nil, err
+	}
+	waiting := Tasks{}
+	for _, item := range active {
+		if containsString(item.DependsOn, t.ID) {
+			waiting = append(waiting, item)
+		}
+	}
+	p.setDependencies(waiting)
+	ret := Tasks{}
+	for _, item := range waiting {
+		if !item.IsBlocked() {
+			ret = append(ret, item)
+		}
+	}
+	sort.Sort(ret)
+	return ret, nil
+}
+
+// FilterBlocked keeps blocked or unblocked tasks, when the FilterParams ask
+// for one or the other
+func FilterBlocked(p *FilterParams, task Task) bool {
+	if p.Blocked == nil {
+		return true
+	}
+	return task.IsBlocked() == *p.Blocked
+}
@@ -0,0 +1,106 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependOn(t *testing.T) {
+	p := newTestPoet(t)
+	certs, err := p.Task.Add(MustNewTask("get certs", WithID("certs")))
+	require.NoError(t, err)
+	deploy, err := p.Task.Add(MustNewTask("deploy certs", WithID("deploy"), WithDependsOn("certs")))
+	require.NoError(t, err)
+	announce, err := p.Task.Add(MustNewTask("announce", WithID("announce")))
+	require.NoError(t, err)
+
+	require.NoError(t, p.DependOn(announce, deploy))
+	require.ErrorIs(t, p.DependOn(announce, deploy), ErrDuplicateLink)
+	require.ErrorIs(t, p.DependOn(certs, certs), ErrLinkCycle)
+	require.ErrorIs(t, p.DependOn(certs, announce), ErrLinkCycle)
+	got, err := p.Task.GetWithID("announce", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, []string{"deploy"}, got.DependsOn)
+
+	_, err = NewTask("self", WithID("self"), WithDependsOn("self"))
+	require.EqualError(t, err, "task cannot depend on itself")
+}
+
+func TestBlockedAndUnblocked(t *testing.T) {
+	p := newTestPoet(t)
+	certs, err := p.Task.Add(MustNewTask("get certs", WithID("certs")))
+	require.NoError(t, err)
+	dns, err := p.Task.Add(MustNewTask("update dns", WithID("dns")))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("deploy certs", WithID("deploy"), WithDependsOn("certs", "dns")))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("renew", WithID("renew"), WithDependsOn("certs")))
+	require.NoError(t, err)
+
+	tasks := p.MustList("/active")
+	p.refresh(tasks)
+	byID := linkGraph(tasks)
+	require.True(t, byID["deploy"].IsBlocked())
+	require.False(t, byID["certs"].IsBlocked())
+	require.Equal(t, 2, byID["certs"].blocking)
+	require.Equal(t, float64(blockedUrgency), byID["renew"].Urgency)
+	require.Equal(t, float64(blockingUrgency), byID["dns"].Urgency)
+
+	blocked := true
+	got := p.TaskTable(TableOpts{
+		Prefix:       "/active",
+		Columns:      []string{"Description", "Blocked"},
+		FilterParams: FilterParams{Blocked: &blocked, Regex: regexp.MustCompile(".*")},
+		Filters:      []Filter{FilterRegex, FilterBlocked},
+	})
+	require.Contains(t, got, "deploy certs")
+	require.NotContains(t, got, "get certs")
+
+	// Renew only waits on certs, deploy is still waiting on dns
+	require.NoError(t, p.Task.Complete(certs))
+	unblocked, err := p.Unblocked(certs)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(unblocked))
+	require.Equal(t, "renew", unblocked[0].ID)
+
+	require.NoError(t, p.Task.Complete(dns))
+	unblocked, err = p.Unblocked(dns)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(unblocked))
+	require.Equal(t, "deploy", unblocked[0].ID)
+}
+
+func TestPurgeRemovesDependencies(t *testing.T) {
+	p := newTestPoet(t)
+	certs, err := p.Task.Add(MustNewTask("get certs", WithID("certs")))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("deploy certs", WithID("deploy"), WithDependsOn("certs")))
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Purge(certs))
+	got, err := p.Task.GetWithID("deploy", "", "/active")
+	require.NoError(t, err)
+	require.Empty(t, got.DependsOn)
+}
+
+func TestTWDepends(t *testing.T) {
+	var got TaskWarriorTasks
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"uuid": "a", "description": "old style", "depends": "b, c"},
+		{"uuid": "d", "description": "new style", "depends": ["b", "c"]}
+	]`), &got))
+	require.Equal(t, TWDepends{"b", "c"}, got[0].Depends)
+	require.Equal(t, TWDepends{"b", "c"}, got[1].Depends)
+
+	p := newTestPoet(t)
+	_, err := p.ImportTaskWarrior(TaskWarriorTasks{
+		{UUID: "b", Description: "blocker", Status: "pending"},
+		{UUID: "a", Description: "blocked", Status: "pending", Depends: TWDepends{"b"}},
+	}, nil)
+	require.NoError(t, err)
+	imported, err := p.Task.GetWithID("a", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, imported.DependsOn)
+}
@@ -139,6 +139,19 @@ func (c Calendar) Date(s string) (*time.Time, error) {
 	return &syn, nil
 }
 
+// Past is like Date, except durations count back from the present, so 7d is a
+// week ago
+func (c Calendar) Past(s string) (*time.Time, error) {
+	if _, err := c.Synonym(s); err == nil {
+		return c.Date(s)
+	}
+	d, err := c.Date(s)
+	if err != nil {
+		return nil, err
+	}
+	return datePTR(c.present.Add(c.present.Sub(*d))), nil
+}
+
 func (c Calendar) calcDay(twd time.Weekday) time.Time {
 	cwd := c.present.Weekday()
 	switch {
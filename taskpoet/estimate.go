@@ -0,0 +1,128 @@
+package taskpoet
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WithEstimate sets how long a task is expected to take on create
+func WithEstimate(d time.Duration) TaskOption {
+	return func(t *Task) {
+		t.Estimate = d
+	}
+}
+
+// Actual is how long the task really took. Tracked time is used when there is
+// any, otherwise a completed task took from when it was added to when it was
+// completed
+func (t Task) Actual() time.Duration {
+	if len(t.Intervals) > 0 || t.Completed == nil {
+		return t.Spent()
+	}
+	return t.Completed.Sub(t.Added)
+}
+
+// Remaining is how much of the estimate is left, never less than 0
+func (t Task) Remaining() time.Duration {
+	return max(t.Estimate-t.Spent(), 0)
+}
+
+// CalibrationRow compares estimates with actuals for a group of tasks
+type CalibrationRow struct {
+	Name      string
+	Tasks     int
+	Estimated time.Duration
+	Actual    time.Duration
+}
+
+// Ratio is how many times longer the tasks took than estimated, so 2 means
+// they took twice as long
+func (r CalibrationRow) Ratio() float64 {
+	if r.Estimated == 0 {
+		return 0
+	}
+	return float64(r.Actual) / float64(r.Estimated)
+}
+
+func (r *CalibrationRow) add(t Task) {
+	r.Tasks++
+	r.Estimated += t.Estimate
+	r.Actual += t.Actual()
+}
+
+// Calibration is how estimates compared with actuals for completed tasks
+type Calibration struct {
+	ByEffortImpact []CalibrationRow
+	ByTag          []CalibrationRow
+	Total          CalibrationRow
+}
+
+// Calibrate compares the estimates of tasks completed since the given time
+// with how long they actually took
+func (p *Poet) Calibrate(since time.Time) (*Calibration, error) {
+	completed, err := p.Task.List("/completed")
+	if err != nil {
+		return nil, err
+	}
+	quadrants := map[EffortImpact]*CalibrationRow{}
+	tags := map[string]*CalibrationRow{}
+	c := &Calibration{Total: CalibrationRow{Name: "Total"}}
+	for _, t := range completed {
+		if t.Estimate == 0 || t.Completed.Before(since) {
+			continue
+		}
+		c.Total.add(*t)
+		if _, ok := quadrants[t.EffortImpact]; !ok {
+			quadrants[t.EffortImpact] = &CalibrationRow{Name: t.EffortImpact.String()}
+		}
+		quadrants[t.EffortImpact].add(*t)
+		taskTags := t.Tags
+		if len(taskTags) == 0 {
+			taskTags = []string{noneName}
+		}
+		for _, tag := range taskTags {
+			if _, ok := tags[tag]; !ok {
+				tags[tag] = &CalibrationRow{Name: tag}
+			}
+			tags[tag].add(*t)
+		}
+	}
+
+	eis := make([]EffortImpact, 0, len(quadrants))
+	for ei := range quadrants {
+		eis = append(eis, ei)
+	}
+	sort.Slice(eis, func(i, j int) bool { return eis[i] < eis[j] })
+	for _, ei := range eis {
+		c.ByEffortImpact = append(c.ByEffortImpact, *quadrants[ei])
+	}
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.ByTag = append(c.ByTag, *tags[name])
+	}
+	return c, nil
+}
+
+// CalibrationTable renders a calibration, by quadrant and then by tag
+func (p *Poet) CalibrationTable(c Calibration) string {
+	table := func(header string, rows []CalibrationRow) string {
+		cells := make([][]string, 0, len(rows)+1)
+		for _, row := range append(rows, c.Total) {
+			cells = append(cells, []string{
+				row.Name,
+				fmt.Sprint(row.Tasks),
+				clockDuration(row.Estimated),
+				clockDuration(row.Actual),
+				fmt.Sprintf("%.2fx", row.Ratio()),
+			})
+		}
+		return p.listTable([]string{header, "Tasks", "Estimated", "Actual", "Ratio"}, cells)
+	}
+	return fmt.Sprintf("%v\n%v\n", table("Effort/Impact", c.ByEffortImpact), table("Tag", c.ByTag))
+}
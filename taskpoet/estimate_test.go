@@ -0,0 +1,69 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateActualRemaining(t *testing.T) {
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(time.Hour)
+	task := MustNewTask("plan", WithEstimate(3*time.Hour))
+	p := &Poet{}
+	task.Intervals = []Interval{{Start: start, End: &end}}
+	require.Equal(t, time.Hour, task.Actual())
+	require.Equal(t, 2*time.Hour, task.Remaining())
+	require.Equal(t, "3:00", p.mustColumnValue("Estimate", *task))
+	require.Equal(t, "2:00", p.mustColumnValue("Remaining", *task))
+	require.Equal(t, "", p.mustColumnValue("Remaining", Task{}))
+
+	// Going over the estimate leaves nothing remaining
+	task.Estimate = 30 * time.Minute
+	require.Equal(t, time.Duration(0), task.Remaining())
+
+	// Without tracked time, a completed task took from added to completed
+	completed := start.Add(5 * time.Hour)
+	untracked := Task{Added: start, Completed: &completed}
+	require.Equal(t, 5*time.Hour, untracked.Actual())
+
+	_, err := NewTask("negative", WithEstimate(-time.Hour))
+	require.EqualError(t, err, "estimate cannot be negative")
+}
+
+func TestCalibrate(t *testing.T) {
+	p := newTestPoet(t)
+	added := time.Now().Add(-48 * time.Hour)
+	logged := func(desc string, ei EffortImpact, estimate, took time.Duration, tags ...string) {
+		done := added.Add(took)
+		_, err := p.Task.Log(MustNewTask(desc, WithEffortImpact(ei), WithEstimate(estimate), WithTags(tags),
+			func(t *Task) {
+				t.Added = added
+				t.Completed = &done
+			}), nil)
+		require.NoError(t, err)
+	}
+	logged("homework", EffortImpactMedium, 2*time.Hour, 6*time.Hour, "docs")
+	logged("more homework", EffortImpactMedium, 2*time.Hour, 2*time.Hour, "docs", "ops")
+	logged("sweet spot", EffortImpactHigh, time.Hour, time.Hour)
+	_, err := p.Task.Log(MustNewTask("no estimate"), nil)
+	require.NoError(t, err)
+
+	c, err := p.Calibrate(time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, CalibrationRow{Name: "Total", Tasks: 3, Estimated: 5 * time.Hour, Actual: 9 * time.Hour}, c.Total)
+	require.Equal(t, []CalibrationRow{
+		{Name: EffortImpactHigh.String(), Tasks: 1, Estimated: time.Hour, Actual: time.Hour},
+		{Name: EffortImpactMedium.String(), Tasks: 2, Estimated: 4 * time.Hour, Actual: 8 * time.Hour},
+	}, c.ByEffortImpact)
+	require.Equal(t, float64(2), c.ByEffortImpact[1].Ratio())
+	require.Equal(t, []string{"(none)", "docs", "ops"}, []string{c.ByTag[0].Name, c.ByTag[1].Name, c.ByTag[2].Name})
+	require.Equal(t, 2, c.ByTag[1].Tasks)
+	require.Contains(t, p.CalibrationTable(*c), "2.00x")
+
+	c, err = p.Calibrate(time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 0, c.Total.Tasks)
+	require.Equal(t, float64(0), c.Total.Ratio())
+}
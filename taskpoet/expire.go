@@ -0,0 +1,108 @@
+package taskpoet
+
+import (
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+/*
+A task with CancelAfter set expires once that time passes, like until in
+TaskWarrior. Expired tasks are moved to /deleted, with DeletedReason set to
+expired, so they show up in the trash and the expired report instead of just
+vanishing.
+*/
+
+// DeletedReasonExpired marks a task deleted because CancelAfter passed
+const DeletedReasonExpired = "expired"
+
+// WithCancelAfter sets when the task expires on create
+func WithCancelAfter(d *time.Time) TaskOption {
+	return func(t *Task) {
+		t.CancelAfter = d
+	}
+}
+
+// IsExpired is true when the task was deleted because it expired
+func (t Task) IsExpired() bool {
+	return t.Deleted != nil && t.DeletedReason == DeletedReasonExpired
+}
+
+// Expire moves every active task past its CancelAfter to the trash, as a
+// single operation, and returns the expired tasks
+func (p *Poet) Expire() (Tasks, error) {
+	active, err := p.ListOpen()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expired := Tasks{}
+	for _, t := range active {
+		if t.CancelAfter != nil && t.CancelAfter.Before(now) {
+			expired = append(expired, t)
+		}
+	}
+	if len(expired) == 0 {
+		return expired, nil
+	}
+	if err := p.update(OperationExpire, func(tx StoreTx) error {
+		for _, t := range expired {
+			before := *t
+			if err := p.removeTask(tx, t.DetectKeyPath()); err != nil {
+				return err
+			}
+			// Deleted is when it actually expired, CancelAfter keeps when it was
+			// due to
+			t.Deleted = &now
+			t.DeletedReason = DeletedReasonExpired
+			t.stopTimer(now)
+			if err := p.putTask(tx, *t); err != nil {
+				return err
+			}
+			if err := p.recordRevision(tx, OperationExpire, &before, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+// Expired returns the tasks that expired, most recent first
+func (p *Poet) Expired() (Tasks, error) {
+	trash, err := p.Trash()
+	if err != nil {
+		return nil, err
+	}
+	ret := Tasks{}
+	for _, t := range trash {
+		if t.IsExpired() {
+			ret = append(ret, t)
+		}
+	}
+	return ret, nil
+}
+
+func (p *Poet) checkExpired() {
+	var expired Tasks
+	err := p.unjournaled(func() error {
+		var err error
+		expired, err = p.Expire()
+		return err
+	})
+	if err != nil {
+		log.Warn("problem expiring tasks", "err", err)
+		return
+	}
+	p.SetShortIDs(expired...)
+	for _, t := range expired {
+		log.Info("Task expired", "task", t.Description, "id", t.ShortID(), "until", t.CancelAfter.Format("2006-01-02 15:04"))
+	}
+}
+
+// FilterExpired keeps only the tasks that expired
+func FilterExpired(p *FilterParams, task Task) bool {
+	return task.IsExpired()
+}
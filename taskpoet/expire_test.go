@@ -0,0 +1,65 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpire(t *testing.T) {
+	p := newTestPoet(t)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+	_, err := p.Task.Add(MustNewTask("gone", WithID("gone"), WithCancelAfter(&past)))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("later", WithID("later"), WithCancelAfter(&future)))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("forever", WithID("forever")))
+	require.NoError(t, err)
+
+	expired, err := p.Expire()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(expired))
+	require.Equal(t, "gone", expired[0].ID)
+	require.Equal(t, 2, len(p.MustList("/active")))
+
+	got, err := p.Expired()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+	require.True(t, got[0].IsExpired())
+	require.True(t, got[0].Deleted.After(past))
+	require.Equal(t, past.Unix(), got[0].CancelAfter.Unix())
+
+	revs, err := p.History(*got[0])
+	require.NoError(t, err)
+	require.Equal(t, OperationExpire, revs[len(revs)-1].Operation)
+
+	// Nothing left to expire
+	expired, err = p.Expire()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(expired))
+
+	// Restoring drops the until date, so it sticks around
+	require.NoError(t, p.RestoreDeleted(got[0]))
+	require.Nil(t, got[0].CancelAfter)
+	require.Equal(t, "", got[0].DeletedReason)
+	expired, err = p.Expire()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(expired))
+	require.Equal(t, 3, len(p.MustList("/active")))
+}
+
+func TestExpireUndo(t *testing.T) {
+	p := newTestPoet(t)
+	past := time.Now().Add(-time.Hour)
+	_, err := p.Task.Add(MustNewTask("gone", WithID("gone"), WithCancelAfter(&past)))
+	require.NoError(t, err)
+	_, err = p.Expire()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(p.MustList("/active")))
+
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(p.MustList("/active")))
+}
@@ -261,3 +261,23 @@ func nowPTR() *time.Time {
 	t := time.Now()
 	return &t
 }
+
+// without returns items, minus any that equal s
+func without(items []string, s string) []string {
+	ret := []string{}
+	for _, item := range items {
+		if item != s {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// dirPrefix ends prefix with a /, so scanning a state like /active doesn't
+// pick up another that starts with the same name, like /active-hold
+func dirPrefix(prefix string) string {
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+	return prefix + "/"
+}
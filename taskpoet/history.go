@@ -0,0 +1,272 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+)
+
+/*
+History lives in its own bucket at /${namespace}/history. It is append only,
+each key is ${plugin-id}/${id}/${nanoseconds}, so a prefix scan of a task
+returns its revisions oldest first. History is keyed without the state, so it
+follows a task as it is completed, deleted, or even purged.
+*/
+
+// Operation is the kind of mutation a Revision records
+type Operation string
+
+const (
+	// OperationAdd is a brand new task
+	OperationAdd Operation = "add"
+	// OperationEdit is a change to an existing task
+	OperationEdit Operation = "edit"
+	// OperationComplete is a task being marked as completed
+	OperationComplete Operation = "complete"
+	// OperationDelete is a task being marked as deleted
+	OperationDelete Operation = "delete"
+	// OperationPurge is a task being removed from the database entirely
+	OperationPurge Operation = "purge"
+	// OperationRestore is a deleted task being brought back
+	OperationRestore Operation = "restore"
+	// OperationImport is a batch of tasks coming in from somewhere else
+	OperationImport Operation = "import"
+	// OperationRepair is fsck fixing broken links
+	OperationRepair Operation = "repair"
+	// OperationUndo is an earlier operation being reversed
+	OperationUndo Operation = "undo"
+	// OperationStart is work starting on a task
+	OperationStart Operation = "start"
+	// OperationStop is work stopping on a task
+	OperationStop Operation = "stop"
+	// OperationReview is a task being looked over in a review
+	OperationReview Operation = "review"
+	// OperationRecur is instances of recurring tasks being added
+	OperationRecur Operation = "recur"
+	// OperationExpire is tasks being deleted because CancelAfter passed
+	OperationExpire Operation = "expire"
+	// OperationMove is a task moving to another workflow state
+	OperationMove Operation = "move"
+)
+
+// FieldChange is the before and after of a single field, using the json
+// field names and values
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// Revision is a single entry in the history of a task
+type Revision struct {
+	Time      time.Time     `json:"time"`
+	Operation Operation     `json:"operation"`
+	User      string        `json:"user,omitempty"`
+	Path      string        `json:"path"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+// Revisions is multiple Revision items
+type Revisions []Revision
+
+func historyPrefix(pluginID, id string) []byte {
+	if pluginID == "" {
+		pluginID = DefaultPluginID
+	}
+	return []byte(filepath.Join(pluginID, id) + "/")
+}
+
+// historyUser is whoever is running taskpoet, recorded on each revision
+func historyUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// taskFields flattens a task in to its json fields
+func taskFields(t *Task) (map[string]any, error) {
+	fields := map[string]any{}
+	if t == nil {
+		return fields, nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	// Urgency is recalculated all the time, it's noise in the history
+	delete(fields, "urgency")
+	return fields, nil
+}
+
+// diffTasks returns the fields that differ between before and after, sorted by
+// field name. Either of them may be nil
+func diffTasks(before, after *Task) ([]FieldChange, error) {
+	oldFields, err := taskFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := taskFields(after)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for k := range oldFields {
+		names[k] = true
+	}
+	for k := range newFields {
+		names[k] = true
+	}
+	changes := []FieldChange{}
+	for name := range names {
+		if reflect.DeepEqual(oldFields[name], newFields[name]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Old: oldFields[name], New: newFields[name]})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+// getTaskIn returns the task at path inside of an existing transaction, or nil
+// if there is nothing there
+func (p *Poet) getTaskIn(tx StoreTx, path []byte) (*Task, error) {
+	got, err := tx.Get(p.bucket, path)
+	if err != nil || got == nil {
+		return nil, err
+	}
+	var t Task
+	if err := json.Unmarshal(got, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// recordRevision appends a revision for the change from before to after. Edits
+// that don't actually change anything are not recorded
+func (p *Poet) recordRevision(tx StoreTx, op Operation, before, after *Task) error {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+	changes, err := diffTasks(before, after)
+	if err != nil {
+		return err
+	}
+	if op == OperationEdit && len(changes) == 0 {
+		return nil
+	}
+	rev := Revision{
+		Time:      time.Now(),
+		Operation: op,
+		User:      historyUser(),
+		Path:      string(subject.DetectKeyPath()),
+		Changes:   changes,
+	}
+	b, err := json.Marshal(rev)
+	if err != nil {
+		return err
+	}
+	if err := putSequenced(tx, p.historyBucket, historyPrefix(subject.PluginID, subject.ID), rev.Time, b); err != nil {
+		return err
+	}
+	return p.journalChange(tx, before, after)
+}
+
+// putSequenced stores value under prefix with a key that sorts by time
+func putSequenced(tx StoreTx, bucket, prefix []byte, t time.Time, value []byte) error {
+	// Several entries can land in the same nanosecond inside of a single
+	// transaction, so bump the key until it is free
+	for stamp := t.UnixNano(); ; stamp++ {
+		key := append(copyBytes(prefix), []byte(fmt.Sprintf("%020d", stamp))...)
+		existing, err := tx.Get(bucket, key)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return tx.Put(bucket, key, value)
+		}
+	}
+}
+
+// History returns the revisions of a task, oldest first
+func (p *Poet) History(t Task) (Revisions, error) {
+	revs := Revisions{}
+	err := p.Store.View(func(tx StoreTx) error {
+		return tx.ForEach(p.historyBucket, historyPrefix(t.PluginID, t.ID), func(k, v []byte) error {
+			var rev Revision
+			if err := json.Unmarshal(v, &rev); err != nil {
+				return fmt.Errorf("could not decode revision %s: %w", k, err)
+			}
+			revs = append(revs, rev)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+// historyValue makes a json value from a FieldChange readable
+func historyValue(v any) string {
+	switch item := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if ts, err := time.Parse(time.RFC3339Nano, item); err == nil {
+			return ts.Local().Format("2006-01-02 15:04")
+		}
+		return item
+	case []any, map[string]any:
+		b, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Sprint(item)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(item)
+	}
+}
+
+// DescribeHistory renders the revisions of a task as a table
+func (p *Poet) DescribeHistory(revs Revisions) string {
+	rows := [][]string{}
+	for _, rev := range revs {
+		when := rev.Time.Local().Format("2006-01-02 15:04")
+		if len(rev.Changes) == 0 {
+			rows = append(rows, []string{when, string(rev.Operation), rev.User, "", "", ""})
+			continue
+		}
+		for idx, c := range rev.Changes {
+			if idx == 0 {
+				rows = append(rows, []string{when, string(rev.Operation), rev.User, c.Field, historyValue(c.Old), historyValue(c.New)})
+			} else {
+				rows = append(rows, []string{"", "", "", c.Field, historyValue(c.Old), historyValue(c.New)})
+			}
+		}
+	}
+	return p.listTable([]string{"When", "Operation", "User", "Field", "Old", "New"}, rows)
+}
+
+// editTask overwrites a task in place, recording what changed
+func (p *Poet) editTask(tx StoreTx, t *Task) error {
+	before, err := p.getTaskIn(tx, t.DetectKeyPath())
+	if err != nil {
+		return err
+	}
+	if err := p.putTask(tx, *t); err != nil {
+		return err
+	}
+	return p.recordRevision(tx, OperationEdit, before, t)
+}
@@ -0,0 +1,66 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	p := newTestPoet(t)
+	task, err := p.Task.Add(MustNewTask("write history", WithID("hist")))
+	require.NoError(t, err)
+
+	due := time.Now().Add(24 * time.Hour)
+	task.Due = &due
+	task.Description = "write the history"
+	_, err = p.Task.Edit(task)
+	require.NoError(t, err)
+
+	// Editing without changing anything is not a revision
+	_, err = p.Task.Edit(task)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Task.Complete(task))
+	require.NoError(t, p.Delete(task))
+	require.NoError(t, p.Task.Purge(task))
+
+	revs, err := p.History(*task)
+	require.NoError(t, err)
+	ops := make([]Operation, len(revs))
+	for idx, rev := range revs {
+		ops[idx] = rev.Operation
+	}
+	require.Equal(t, []Operation{OperationAdd, OperationEdit, OperationComplete, OperationDelete, OperationPurge}, ops)
+
+	edit := revs[1]
+	require.Equal(t, "/active/builtin/hist", edit.Path)
+	fields := []string{}
+	for _, c := range edit.Changes {
+		fields = append(fields, c.Field)
+	}
+	require.Equal(t, []string{"description", "due"}, fields)
+	require.Equal(t, "write history", edit.Changes[0].Old)
+	require.Equal(t, "write the history", edit.Changes[0].New)
+	require.Nil(t, edit.Changes[1].Old)
+
+	require.Equal(t, "/completed/builtin/hist", revs[2].Path)
+	require.Equal(t, "/deleted/builtin/hist", revs[3].Path)
+
+	require.Contains(t, p.DescribeHistory(revs), "write the history")
+}
+
+func TestHistoryEditSet(t *testing.T) {
+	p := newTestPoet(t)
+	parent := MustNewTask("parent")
+	child := MustNewTask("child")
+	require.NoError(t, p.Task.AddSet(Tasks{parent, child}))
+	require.NoError(t, p.Task.AddParent(child, parent))
+
+	revs, err := p.History(*child)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(revs))
+	require.Equal(t, OperationEdit, revs[1].Operation)
+	require.Equal(t, "parents", revs[1].Changes[0].Field)
+}
@@ -1,10 +1,13 @@
 package taskpoet
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // TWTime is the format that TaskWarrior uses for timestamps
@@ -38,6 +41,7 @@ type TaskWarriorTask struct {
 	Description string         `json:"description,omitempty"`
 	UUID        string         `json:"uuid,omitempty"`
 	Status      string         `json:"status,omitempty"`
+	Project     string         `json:"project,omitempty"`
 	Entry       *TWTime        `json:"entry,omitempty"`
 	Modified    *TWTime        `json:"modified,omitempty"`
 	Due         *TWTime        `json:"due,omitempty"`
@@ -46,17 +50,65 @@ type TaskWarriorTask struct {
 	Reviewed    *TWTime        `json:"reviewed,omitempty"`
 	Until       *TWTime        `json:"until,omitempty"`
 	Mask        string         `json:"mask,omitempty"`
+	Recur       string         `json:"recur,omitempty"`
+	Parent      string         `json:"parent,omitempty"`
 	Urgency     float64        `json:"urgency,omitempty"`
 	Tags        []string       `json:"tags,omitempty"`
+	Depends     TWDepends      `json:"depends,omitempty"`
 	Annotations []TWAnnotation `json:"annotations,omitempty"`
 }
 
+// TWDepends are the UUIDs a TaskWarrior task depends on. Older versions of
+// TaskWarrior export these as a single comma separated string, newer ones as a
+// list
+type TWDepends []string
+
+// UnmarshalJSON accepts either form of depends
+func (d *TWDepends) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*d = TWDepends{}
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			*d = append(*d, id)
+		}
+	}
+	return nil
+}
+
 // TWAnnotation is a TaskWarrior Annotation
 type TWAnnotation struct {
 	Entry       *TWTime `json:"entry,omitempty"`
 	Description string  `json:"description,omitempty"`
 }
 
+// twComments turns TaskWarrior annotations in to comments. Annotations
+// without an entry time are dated when the task was added
+func twComments(annotations []TWAnnotation, added time.Time) []Comment {
+	if len(annotations) == 0 {
+		return nil
+	}
+	ret := make([]Comment, 0, len(annotations))
+	for _, a := range annotations {
+		if strings.TrimSpace(a.Description) == "" {
+			continue
+		}
+		c := Comment{ID: uuid.New().String(), Added: added, Text: a.Description}
+		if a.Entry != nil {
+			c.Added = time.Time(*a.Entry)
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
 // TaskWarriorTasks is multiple TaskWarriorTasks items
 type TaskWarriorTasks []TaskWarriorTask
 
@@ -67,6 +119,16 @@ func (p *Poet) ImportTaskWarrior(ts TaskWarriorTasks, c chan ProgressStatus) (in
 	var imported int
 	// Erase the defaults
 	p.Default = Task{}
+	// The whole import is undone as a single operation
+	err := p.inBatch(OperationImport, func() error {
+		imported = p.importTaskWarrior(ts, c)
+		return nil
+	})
+	return imported, err
+}
+
+func (p *Poet) importTaskWarrior(ts TaskWarriorTasks, c chan ProgressStatus) int {
+	var imported int
 	total := len(ts)
 	for idx, twItem := range ts {
 		s := ProgressStatus{
@@ -74,15 +136,19 @@ func (p *Poet) ImportTaskWarrior(ts TaskWarriorTasks, c chan ProgressStatus) (in
 			Total:   int64(total),
 			Info:    fmt.Sprintf("Importing: %v", twItem.Description),
 		}
+		// Items with a mask are the templates of recurring tasks
 		if twItem.Mask != "" {
-			s.Warning = fmt.Sprintf("Skipping item with recursion mask since we know how to handle it yet: %v", twItem.Description)
+			if err := p.importTaskWarriorRecur(twItem); err != nil {
+				s.Warning = fmt.Sprintf("Error importing recurring task: %v (%v)", twItem.Description, err.Error())
+			} else {
+				imported++
+			}
 			pushStatus(c, s)
 			continue
 		}
 		t := MustNewTask(twItem.Description, WithTaskWarriorTask(twItem))
 
 		if !p.exists(t) {
-			fmt.Fprintf(os.Stderr, "DIIING: %+v\n", t)
 			if _, err := p.Task.Add(t); err != nil {
 				s.Warning = fmt.Sprintf("Error importing task: %v (%v)", twItem.Description, err.Error())
 			} else {
@@ -92,7 +158,60 @@ func (p *Poet) ImportTaskWarrior(ts TaskWarriorTasks, c chan ProgressStatus) (in
 
 		pushStatus(c, s)
 	}
-	return imported, nil
+	return imported
+}
+
+// importTaskWarriorRecur adds a recurring task template for a TaskWarrior
+// recurring task. The instances TaskWarrior already made are imported as
+// regular tasks, linked back to the template by their parent
+func (p *Poet) importTaskWarriorRecur(twItem TaskWarriorTask) error {
+	rule, err := twRecurRule(twItem)
+	if err != nil {
+		return err
+	}
+	opts := []RecurOption{
+		WithRecurTags(twItem.Tags),
+		WithRecurProject(twItem.Project),
+		WithRecurLast((*time.Time)(twItem.Due)),
+	}
+	if twItem.UUID != "" {
+		opts = append(opts, WithRecurID(twItem.UUID))
+	}
+	if twItem.Due != nil {
+		// TaskWarrior instances are due when they recur, which is the end of
+		// the day they are scheduled for here
+		opts = append(opts, WithRecurDueOffset(24*time.Hour))
+	}
+	r, err := NewRecurTemplate(twItem.Description, rule, opts...)
+	if err != nil {
+		return err
+	}
+	return p.AddRecurTemplate(r)
+}
+
+// twRecurRule turns a TaskWarrior recur period in to a rule. Monthly lands on
+// the day of the month the task is first due
+func twRecurRule(twItem TaskWarriorTask) (RecurRule, error) {
+	period := strings.ToLower(strings.TrimSpace(twItem.Recur))
+	switch period {
+	case "":
+		return RecurRule{}, errors.New("recurring task has no recur period")
+	case "daily", "weekly", "weekdays":
+		return ParseRecurRule(period)
+	case "biweekly", "fortnight":
+		return ParseRecurRule("every 2w")
+	case "monthly":
+		if twItem.Due == nil {
+			return ParseRecurRule("monthly 1")
+		}
+		return ParseRecurRule(fmt.Sprintf("monthly %v", time.Time(*twItem.Due).Day()))
+	case "quarterly":
+		return ParseRecurRule("every 13w")
+	case "yearly", "annual":
+		return ParseRecurRule("every 52w")
+	default:
+		return ParseRecurRule("every " + period)
+	}
 }
 
 func pushStatus(c chan ProgressStatus, s ProgressStatus) {
@@ -46,6 +46,33 @@ func TestTWImportHasMask(t *testing.T) {
 	require.Equal(t, 0, got)
 }
 
+func TestTWImportRecurring(t *testing.T) {
+	p := newTestPoet(t)
+	due := TWTime(time.Date(2023, time.May, 15, 12, 0, 0, 0, time.UTC))
+	ts := []TaskWarriorTask{
+		{UUID: "template", Description: "pay rent", Status: "recurring", Mask: "--+", Recur: "monthly", Due: &due, Tags: []string{"home"}},
+		{UUID: "instance", Description: "pay rent", Status: "pending", Parent: "template", Due: &due},
+		{UUID: "broken", Description: "who knows", Status: "recurring", Mask: "-", Recur: "whenever"},
+	}
+	got, err := p.ImportTaskWarrior(ts, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, got)
+
+	r, err := p.GetRecurTemplate("template")
+	require.NoError(t, err)
+	require.Equal(t, "monthly 15", r.Rule.String())
+	require.Equal(t, []string{"home"}, r.Tags)
+
+	instance, err := p.Task.GetWithID("instance", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, "template", instance.RecurID)
+
+	// The imported instance is still outstanding
+	added, err := p.Recur()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(added))
+}
+
 func TestTWImportHiddenWeirdness(t *testing.T) {
 	p := newTestPoet(t)
 	futureT := TWTime(time.Now().Add(24 * time.Hour))
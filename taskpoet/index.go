@@ -0,0 +1,262 @@
+package taskpoet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Indexes live in their own bucket next to the tasks, at /${namespace}/index.
+Each key is ${index}/${escaped-value}/${task-key-path}, and the value is the
+task key path, so a lookup is a prefix scan of the index followed by a Get for
+each path.
+*/
+
+// Index is the name of a secondary index
+type Index string
+
+const (
+	// IndexTag indexes tasks by each of their tags
+	IndexTag Index = "tag"
+	// IndexProject indexes tasks by their project
+	IndexProject Index = "project"
+	// IndexDue indexes tasks by the day they are due
+	IndexDue Index = "due"
+	// IndexPlugin indexes tasks by their plugin id
+	IndexPlugin Index = "plugin"
+)
+
+const indexDayLayout = "2006-01-02"
+
+func indexPrefix(i Index, value string) []byte {
+	return []byte(fmt.Sprintf("%v/%v/", i, url.PathEscape(value)))
+}
+
+func indexKey(i Index, value string, path []byte) []byte {
+	return append(indexPrefix(i, value), path...)
+}
+
+// indexKeys returns every index key a task should have at the given path
+func indexKeys(t Task, path []byte) [][]byte {
+	keys := [][]byte{}
+	for _, tag := range filterUniqueStrings(t.Tags) {
+		keys = append(keys, indexKey(IndexTag, tag, path))
+	}
+	if t.Project != "" {
+		keys = append(keys, indexKey(IndexProject, t.Project, path))
+	}
+	if t.Due != nil {
+		keys = append(keys, indexKey(IndexDue, t.Due.Local().Format(indexDayLayout), path))
+	}
+	pluginID := t.PluginID
+	if pluginID == "" {
+		pluginID = DefaultPluginID
+	}
+	keys = append(keys, indexKey(IndexPlugin, pluginID, path))
+	return keys
+}
+
+func indexTask(tx StoreTx, indexBucket []byte, t Task, path []byte) error {
+	for _, k := range indexKeys(t, path) {
+		if err := tx.Put(indexBucket, k, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unindexPath removes the index entries for whatever task is currently stored
+// at path
+func (p *Poet) unindexPath(tx StoreTx, path []byte) error {
+	existing, err := tx.Get(p.bucket, path)
+	if err != nil || existing == nil {
+		return err
+	}
+	var t Task
+	if err := json.Unmarshal(existing, &t); err != nil {
+		return err
+	}
+	for _, k := range indexKeys(t, path) {
+		if err := tx.Delete(p.indexBucket, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putTask writes a task to its key path, keeping the indexes in sync
+func (p *Poet) putTask(tx StoreTx, t Task) error {
+	path := t.DetectKeyPath()
+	if err := p.unindexPath(tx, path); err != nil {
+		return err
+	}
+	taskSerial, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := tx.Put(p.bucket, path, taskSerial); err != nil {
+		return err
+	}
+	return indexTask(tx, p.indexBucket, t, path)
+}
+
+// removeTask deletes whatever is at the key path, keeping the indexes in sync
+func (p *Poet) removeTask(tx StoreTx, path []byte) error {
+	if err := p.unindexPath(tx, path); err != nil {
+		return err
+	}
+	return tx.Delete(p.bucket, path)
+}
+
+// RebuildIndexes throws away all of the indexes and builds them again from the
+// stored tasks. Returns the number of tasks that were indexed
+func (p *Poet) RebuildIndexes() (int, error) {
+	var count int
+	err := p.Store.Update(func(tx StoreTx) error {
+		var err error
+		count, err = rebuildIndexes(tx, p.bucket, p.indexBucket)
+		return err
+	})
+	return count, err
+}
+
+func rebuildIndexes(tx StoreTx, bucket, indexBucket []byte) (int, error) {
+	var count int
+	buckets, err := tx.Buckets()
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range buckets {
+		if bytes.Equal(b, indexBucket) {
+			if err := tx.DeleteBucket(indexBucket); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := tx.CreateBucket(indexBucket); err != nil {
+		return 0, err
+	}
+	err = tx.ForEach(bucket, nil, func(k, v []byte) error {
+		var t Task
+		if err := json.Unmarshal(v, &t); err != nil {
+			return fmt.Errorf("could not decode %s: %w", k, err)
+		}
+		count++
+		return indexTask(tx, indexBucket, t, copyBytes(k))
+	})
+	return count, err
+}
+
+// listWithIndex returns the tasks under prefix that have an index entry for
+// value. With keep set, every entry starting with value is given to keep
+// instead, unescaped, to decide
+func (p *Poet) listWithIndex(i Index, value, prefix string, keep func(string) bool) (Tasks, error) {
+	base := fmt.Sprintf("%v/", i)
+	scan := []byte(base + url.PathEscape(value))
+	if keep == nil {
+		scan = indexPrefix(i, value)
+	}
+	prefix = dirPrefix(prefix)
+	tasks := Tasks{}
+	err := p.Store.View(func(tx StoreTx) error {
+		return tx.ForEach(p.indexBucket, scan, func(k, v []byte) error {
+			if !strings.HasPrefix(string(v), prefix) {
+				return nil
+			}
+			if keep != nil {
+				raw := strings.SplitN(strings.TrimPrefix(string(k), base), "/", 2)[0]
+				got, err := url.PathUnescape(raw)
+				if err != nil {
+					return err
+				}
+				if !keep(got) {
+					return nil
+				}
+			}
+			taskB, err := tx.Get(p.bucket, v)
+			if err != nil {
+				return err
+			}
+			if taskB == nil {
+				return fmt.Errorf("index points at a missing task: %s, try rebuilding the indexes", v)
+			}
+			var t Task
+			if err := json.Unmarshal(taskB, &t); err != nil {
+				return err
+			}
+			tasks = append(tasks, &t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ListWithTag returns the tasks under prefix that have the given tag
+func (svc *TaskServiceOp) ListWithTag(prefix, tag string) (Tasks, error) {
+	return svc.localClient.listWithIndex(IndexTag, tag, prefix, nil)
+}
+
+// ListWithProject returns the tasks under prefix in the given project
+func (svc *TaskServiceOp) ListWithProject(prefix, project string) (Tasks, error) {
+	return svc.localClient.listWithIndex(IndexProject, project, prefix, nil)
+}
+
+// ListWithPluginID returns the tasks under prefix that came from the given plugin
+func (svc *TaskServiceOp) ListWithPluginID(prefix, pluginID string) (Tasks, error) {
+	return svc.localClient.listWithIndex(IndexPlugin, pluginID, prefix, nil)
+}
+
+// ListDueBetween returns the tasks under prefix that are due on any day from
+// start through end, inclusive
+func (svc *TaskServiceOp) ListDueBetween(prefix string, start, end time.Time) (Tasks, error) {
+	first := start.Local().Format(indexDayLayout)
+	last := end.Local().Format(indexDayLayout)
+	return svc.localClient.listWithIndex(IndexDue, "", prefix, func(day string) bool {
+		return day >= first && day <= last
+	})
+}
+
+// indexLookup is a way to find the only tasks a filter could keep using an
+// index, instead of reading every task
+type indexLookup struct {
+	index Index
+	value string
+	keep  func(string) bool
+}
+
+// projectLookup finds the tasks in a project, or its subprojects
+func projectLookup(project string) *indexLookup {
+	return &indexLookup{index: IndexProject, value: project, keep: func(got string) bool {
+		return InProject(got, project)
+	}}
+}
+
+// listIndexed returns the tasks under each of the prefixes, in order. With a
+// lookup, only the tasks it finds are read. Anything it finds still needs to
+// be filtered
+func (p *Poet) listIndexed(prefixes []string, l *indexLookup) (Tasks, error) {
+	if l == nil {
+		return p.listPrefixes(prefixes)
+	}
+	ret := Tasks{}
+	for _, prefix := range prefixes {
+		tasks, err := p.listWithIndex(l.index, l.value, prefix, l.keep)
+		if err != nil {
+			return nil, err
+		}
+		// Keep the order of a regular listing
+		sort.SliceStable(tasks, func(i, j int) bool {
+			return bytes.Compare(tasks[i].DetectKeyPath(), tasks[j].DetectKeyPath()) < 0
+		})
+		ret = append(ret, tasks...)
+	}
+	return ret, nil
+}
@@ -0,0 +1,172 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexTags(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("first", WithID("first"), WithTags([]string{"work", "urgent"})),
+		MustNewTask("second", WithID("second"), WithTags([]string{"work"})),
+		MustNewTask("third", WithID("third"), WithTags([]string{"home/garden"})),
+	}))
+
+	got, err := p.Task.ListWithTag("/active", "work")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(got))
+
+	got, err = p.Task.ListWithTag("/active", "home")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(got))
+
+	got, err = p.Task.ListWithTag("", "home/garden")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+
+	// Editing the tags should drop the old index entries
+	first, err := p.Task.GetWithID("first", "", "/active")
+	require.NoError(t, err)
+	first.Tags = []string{"urgent"}
+	require.NoError(t, p.Task.EditSet([]Task{*first}))
+	got, err = p.Task.ListWithTag("/active", "work")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+	require.Equal(t, "second", got[0].ID)
+
+	// Completing moves the index entries along with the task
+	second, err := p.Task.GetWithID("second", "", "/active")
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Complete(second))
+	got, err = p.Task.ListWithTag("/active", "work")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(got))
+	got, err = p.Task.ListWithTag("/completed", "work")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+
+	// Deleting and purging
+	require.NoError(t, p.Delete(second))
+	got, err = p.Task.ListWithTag("/deleted", "work")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+	require.NoError(t, p.Task.Purge(second))
+	got, err = p.Task.ListWithTag("", "work")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(got))
+}
+
+func TestIndexDueAndPlugin(t *testing.T) {
+	p := newTestPoet(t)
+	today := time.Now()
+	nextWeek := today.AddDate(0, 0, 7)
+	_, err := p.Task.Add(MustNewTask("due today", WithDue(&today)))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("due next week", WithDue(&nextWeek)))
+	require.NoError(t, err)
+	_, err = p.Task.Add(&Task{ID: "from-a-plugin", PluginID: "example", Description: "plugged"})
+	require.NoError(t, err)
+
+	got, err := p.Task.ListDueBetween("/active", today, today.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+	require.Equal(t, "due today", got[0].Description)
+
+	got, err = p.Task.ListDueBetween("/active", today, nextWeek)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(got))
+
+	got, err = p.Task.ListWithPluginID("/active", "example")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+	got, err = p.Task.ListWithPluginID("/active", DefaultPluginID)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(got))
+}
+
+func TestAddSetIsAtomic(t *testing.T) {
+	p := newTestPoet(t)
+	require.Error(t, p.Task.AddSet(Tasks{
+		MustNewTask("one", WithID("dup")),
+		MustNewTask("two", WithID("dup")),
+	}))
+	require.Equal(t, 0, len(p.MustList("/active")))
+}
+
+func TestRebuildIndexes(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("first", WithTags([]string{"work"})),
+		MustNewTask("second", WithTags([]string{"work"})),
+	}))
+	// Throw away the index, like a database from before indexes existed
+	require.NoError(t, p.Store.Update(func(tx StoreTx) error {
+		return tx.DeleteBucket(p.indexBucket)
+	}))
+	got, err := p.Task.ListWithTag("/active", "work")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(got))
+
+	count, err := p.RebuildIndexes()
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	got, err = p.Task.ListWithTag("/active", "work")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(got))
+}
+
+func TestQueryUsesIndexes(t *testing.T) {
+	present := time.Date(2023, 10, 10, 8, 0, 0, 0, time.Local)
+	tomorrow := present.AddDate(0, 0, 1)
+	nextMonth := present.AddDate(0, 1, 0)
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("dns", WithID("dns"), WithTags([]string{"work"}), WithProject("work.infra"), WithDue(&tomorrow)),
+		MustNewTask("payroll", WithID("payroll"), WithProject("work"), WithDue(&nextMonth)),
+		MustNewTask("workshop", WithID("workshop"), WithProject("workshop")),
+		MustNewTask("garden", WithID("garden"), WithTags([]string{"home"})),
+	}))
+
+	for query, expect := range map[string][]string{
+		"+work":                  {"dns"},
+		"project:work":           {"dns", "payroll"},
+		"project=work":           {"payroll"},
+		"due<2023-10-20":         {"dns"},
+		"due:2023-10-11":         {"dns"},
+		"due:any":                {"dns", "payroll"},
+		"due.after:2023-10-20":   {"payroll"},
+		"project:work and +work": {"dns"},
+	} {
+		q, err := ParseQuery(query, WithPresent(&present))
+		require.NoError(t, err, query)
+		require.NotNil(t, q.indexLookup(), query)
+		got, err := p.ListQuery(q)
+		require.NoError(t, err, query)
+		ids := []string{}
+		for _, task := range got {
+			ids = append(ids, task.ID)
+		}
+		require.ElementsMatch(t, expect, ids, query)
+	}
+	for _, query := range []string{"", "dns", "+work or +home", "not +work", "due:none", "project:", "added.after:1w"} {
+		require.Nil(t, MustParseQuery(query).indexLookup(), query)
+	}
+
+	// Only the tasks found with an index are read, so a missing index finds
+	// nothing
+	require.NoError(t, p.Store.Update(func(tx StoreTx) error {
+		return tx.DeleteBucket(p.indexBucket)
+	}))
+	got, err := p.ListQuery(MustParseQuery("+work"))
+	require.NoError(t, err)
+	require.Empty(t, got)
+	require.NotContains(t, p.TaskTable(TableOpts{
+		Prefix:       "/active",
+		Columns:      []string{"Description"},
+		Filters:      []Filter{FilterProject},
+		FilterParams: FilterParams{Project: "work"},
+	}), "payroll")
+}
@@ -0,0 +1,369 @@
+package taskpoet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+The journal lives at /${namespace}/journal, and holds one entry for each
+mutating operation, oldest first. An entry holds the before and after of every
+task the operation touched, which is everything needed to put them back. Undo
+pops entries off of the end of the journal.
+*/
+
+// journalLimit is how many operations are kept around to undo
+const journalLimit = 100
+
+// ErrUndoConflict is returned when a task has changed since the operation
+// being undone
+var ErrUndoConflict = errors.New("conflicts with a later change")
+
+// journalState is the operation being journaled right now. It is shared by
+// copies of a Poet
+type journalState struct {
+	mu sync.Mutex
+	// pending collects the changes of the running transaction
+	pending *JournalEntry
+	// batch collects changes across transactions, see inBatch
+	batch *JournalEntry
+	// off is set while automatic operations run, see unjournaled
+	off bool
+}
+
+// JournalChange is a single task before and after an operation. Before is
+// empty for a new task, and After is empty for a purged one. Recur changes are
+// to a recurring task template instead, with its ID as the paths
+type JournalChange struct {
+	BeforePath string          `json:"before_path,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	AfterPath  string          `json:"after_path,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Recur      bool            `json:"recur,omitempty"`
+}
+
+// bucket is where the changed item lives
+func (c JournalChange) bucket(p *Poet) []byte {
+	if c.Recur {
+		return p.recurBucket
+	}
+	return p.bucket
+}
+
+// JournalEntry is a single operation that can be undone
+type JournalEntry struct {
+	Time      time.Time       `json:"time"`
+	Operation Operation       `json:"operation"`
+	Changes   []JournalChange `json:"changes"`
+}
+
+// Description is a short summary of what the entry changed
+func (e JournalEntry) Description() string {
+	subject := ""
+	if len(e.Changes) > 0 {
+		c := e.Changes[0]
+		raw := c.After
+		if raw == nil {
+			raw = c.Before
+		}
+		var t Task
+		if err := json.Unmarshal(raw, &t); err == nil {
+			subject = t.Description
+		}
+	}
+	if len(e.Changes) == 1 {
+		return fmt.Sprintf("%v %q", e.Operation, subject)
+	}
+	return fmt.Sprintf("%v of %v tasks", e.Operation, len(e.Changes))
+}
+
+// update runs fn in a write transaction, journaling every task change it makes
+// as a single operation
+func (p *Poet) update(op Operation, fn func(tx StoreTx) error) error {
+	p.journal.mu.Lock()
+	defer p.journal.mu.Unlock()
+	entry := p.journal.batch
+	if entry == nil {
+		entry = &JournalEntry{Time: time.Now(), Operation: op}
+	}
+	start := len(entry.Changes)
+	if !p.journal.off {
+		p.journal.pending = entry
+	}
+	defer func() {
+		p.journal.pending = nil
+	}()
+	err := p.Store.Update(func(tx StoreTx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		// Batches are written all at once when they are done
+		if p.journal.batch != nil || len(entry.Changes) == 0 {
+			return nil
+		}
+		return p.putJournal(tx, *entry)
+	})
+	if err != nil {
+		// The transaction rolled back, so these never happened
+		entry.Changes = entry.Changes[:start]
+	}
+	return err
+}
+
+// inBatch journals everything fn changes as a single operation, even across
+// multiple transactions
+func (p *Poet) inBatch(op Operation, fn func() error) error {
+	p.journal.mu.Lock()
+	if p.journal.batch != nil || p.journal.off {
+		// Already part of a larger batch, or not journaled at all
+		p.journal.mu.Unlock()
+		return fn()
+	}
+	p.journal.batch = &JournalEntry{Time: time.Now(), Operation: op}
+	p.journal.mu.Unlock()
+
+	ferr := fn()
+
+	p.journal.mu.Lock()
+	defer p.journal.mu.Unlock()
+	entry := p.journal.batch
+	p.journal.batch = nil
+	if len(entry.Changes) > 0 {
+		if err := p.Store.Update(func(tx StoreTx) error {
+			return p.putJournal(tx, *entry)
+		}); err != nil {
+			return err
+		}
+	}
+	return ferr
+}
+
+// unjournaled runs fn without journaling anything it changes. It is for the
+// automatic operations, like expiring tasks, so undo reverses what was done
+// last on purpose instead
+func (p *Poet) unjournaled(fn func() error) error {
+	p.journal.mu.Lock()
+	if p.journal.batch != nil {
+		// Part of a larger operation, which is undone along with this
+		p.journal.mu.Unlock()
+		return fn()
+	}
+	p.journal.off = true
+	p.journal.mu.Unlock()
+	defer func() {
+		p.journal.mu.Lock()
+		p.journal.off = false
+		p.journal.mu.Unlock()
+	}()
+	return fn()
+}
+
+// journalChange adds a task change to the operation currently being journaled
+func (p *Poet) journalChange(tx StoreTx, before, after *Task) error {
+	if p.journal.pending == nil {
+		return nil
+	}
+	c := JournalChange{}
+	if before != nil {
+		b, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		c.BeforePath = string(before.DetectKeyPath())
+		c.Before = b
+	}
+	if after != nil {
+		c.AfterPath = string(after.DetectKeyPath())
+		// Keep exactly what was written, so undo can tell if it changed since
+		got, err := tx.Get(p.bucket, []byte(c.AfterPath))
+		if err != nil {
+			return err
+		}
+		c.After = got
+	}
+	p.journal.pending.Changes = append(p.journal.pending.Changes, c)
+	return nil
+}
+
+// journalRecur adds a template change to the operation currently being
+// journaled. before is the template as it was stored, if it was
+func (p *Poet) journalRecur(tx StoreTx, id string, before []byte) error {
+	if p.journal.pending == nil {
+		return nil
+	}
+	after, err := tx.Get(p.recurBucket, []byte(id))
+	if err != nil {
+		return err
+	}
+	c := JournalChange{AfterPath: id, After: copyBytes(after), Recur: true}
+	if before != nil {
+		c.BeforePath = id
+		c.Before = before
+	}
+	p.journal.pending.Changes = append(p.journal.pending.Changes, c)
+	return nil
+}
+
+func (p *Poet) putJournal(tx StoreTx, entry JournalEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := putSequenced(tx, p.journalBucket, nil, entry.Time, b); err != nil {
+		return err
+	}
+	keys, err := journalKeys(tx, p.journalBucket)
+	if err != nil {
+		return err
+	}
+	for len(keys) > journalLimit {
+		if err := tx.Delete(p.journalBucket, keys[0]); err != nil {
+			return err
+		}
+		keys = keys[1:]
+	}
+	return nil
+}
+
+func journalKeys(tx StoreTx, bucket []byte) ([][]byte, error) {
+	keys := [][]byte{}
+	err := tx.ForEach(bucket, nil, func(k, v []byte) error {
+		keys = append(keys, copyBytes(k))
+		return nil
+	})
+	return keys, err
+}
+
+// Journal returns the operations that can be undone, oldest first
+func (p *Poet) Journal() ([]JournalEntry, error) {
+	entries := []JournalEntry{}
+	err := p.Store.View(func(tx StoreTx) error {
+		return tx.ForEach(p.journalBucket, nil, func(k, v []byte) error {
+			var entry JournalEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("could not decode journal entry %s: %w", k, err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Undo reverses the last n operations, newest first, and returns what was
+// undone. Either all n are undone, or none are
+func (p *Poet) Undo(n int) ([]JournalEntry, error) {
+	if n < 1 {
+		return nil, errors.New("must undo at least 1 operation")
+	}
+	p.journal.mu.Lock()
+	defer p.journal.mu.Unlock()
+	undone := []JournalEntry{}
+	err := p.Store.Update(func(tx StoreTx) error {
+		keys, err := journalKeys(tx, p.journalBucket)
+		if err != nil {
+			return err
+		}
+		if len(keys) < n {
+			return fmt.Errorf("only %v operation(s) can be undone", len(keys))
+		}
+		for idx := len(keys) - 1; idx >= len(keys)-n; idx-- {
+			raw, err := tx.Get(p.journalBucket, keys[idx])
+			if err != nil {
+				return err
+			}
+			var entry JournalEntry
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				return err
+			}
+			if err := p.undoEntry(tx, entry); err != nil {
+				return err
+			}
+			if err := tx.Delete(p.journalBucket, keys[idx]); err != nil {
+				return err
+			}
+			undone = append(undone, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return undone, nil
+}
+
+func (p *Poet) undoEntry(tx StoreTx, entry JournalEntry) error {
+	for idx := len(entry.Changes) - 1; idx >= 0; idx-- {
+		c := entry.Changes[idx]
+		if err := p.checkUndo(tx, entry, c); err != nil {
+			return err
+		}
+		if c.Recur {
+			if err := undoRecur(tx, p.recurBucket, c); err != nil {
+				return err
+			}
+			continue
+		}
+		var before, after *Task
+		if c.After != nil {
+			after = &Task{}
+			if err := json.Unmarshal(c.After, after); err != nil {
+				return err
+			}
+			if err := p.removeTask(tx, []byte(c.AfterPath)); err != nil {
+				return err
+			}
+		}
+		if c.Before != nil {
+			before = &Task{}
+			if err := json.Unmarshal(c.Before, before); err != nil {
+				return err
+			}
+			if err := p.putTask(tx, *before); err != nil {
+				return err
+			}
+		}
+		if err := p.recordRevision(tx, OperationUndo, after, before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// undoRecur puts a template back how it was before the change
+func undoRecur(tx StoreTx, bucket []byte, c JournalChange) error {
+	if c.Before == nil {
+		return tx.Delete(bucket, []byte(c.AfterPath))
+	}
+	return tx.Put(bucket, []byte(c.BeforePath), c.Before)
+}
+
+// checkUndo makes sure the task is still exactly how the operation left it
+func (p *Poet) checkUndo(tx StoreTx, entry JournalEntry, c JournalChange) error {
+	if c.AfterPath != "" {
+		current, err := tx.Get(c.bucket(p), []byte(c.AfterPath))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, c.After) {
+			return fmt.Errorf("cannot undo %v, %v %w", entry.Description(), c.AfterPath, ErrUndoConflict)
+		}
+	}
+	if c.BeforePath != "" && c.BeforePath != c.AfterPath {
+		current, err := tx.Get(c.bucket(p), []byte(c.BeforePath))
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			return fmt.Errorf("cannot undo %v, %v %w", entry.Description(), c.BeforePath, ErrUndoConflict)
+		}
+	}
+	return nil
+}
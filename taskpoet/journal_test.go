@@ -0,0 +1,126 @@
+package taskpoet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoComplete(t *testing.T) {
+	p := newTestPoet(t)
+	task, err := p.Task.Add(MustNewTask("oops", WithID("oops"), WithTags([]string{"work"})))
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Complete(task))
+	require.Equal(t, 0, len(p.MustList("/active")))
+
+	undone, err := p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(undone))
+	require.Equal(t, OperationComplete, undone[0].Operation)
+	require.Equal(t, `complete "oops"`, undone[0].Description())
+
+	got, err := p.Task.GetWithID("oops", "", "/active")
+	require.NoError(t, err)
+	require.Nil(t, got.Completed)
+	require.Equal(t, 0, len(p.MustList("/completed")))
+
+	// Indexes follow the task back
+	tagged, err := p.Task.ListWithTag("/active", "work")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tagged))
+
+	// Then undo the add itself
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(p.MustList("")))
+
+	_, err = p.Undo(1)
+	require.EqualError(t, err, "only 0 operation(s) can be undone")
+}
+
+func TestUndoMultiple(t *testing.T) {
+	p := newTestPoet(t)
+	task, err := p.Task.Add(MustNewTask("first", WithID("first")))
+	require.NoError(t, err)
+	task.Description = "first, edited"
+	_, err = p.Task.Edit(task)
+	require.NoError(t, err)
+	require.NoError(t, p.Delete(task))
+	require.NoError(t, p.Task.Purge(task))
+	require.Equal(t, 0, len(p.MustList("")))
+
+	undone, err := p.Undo(3)
+	require.NoError(t, err)
+	require.Equal(t, []Operation{OperationPurge, OperationDelete, OperationEdit}, []Operation{
+		undone[0].Operation, undone[1].Operation, undone[2].Operation,
+	})
+	got, err := p.Task.GetWithID("first", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, "first", got.Description)
+
+	journal, err := p.Journal()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(journal))
+}
+
+func TestUndoConflict(t *testing.T) {
+	p := newTestPoet(t)
+	task, err := p.Task.Add(MustNewTask("first", WithID("first")))
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Complete(task))
+
+	// Sneak a change in underneath the journal
+	require.NoError(t, p.Store.Update(func(tx StoreTx) error {
+		task.Description = "changed behind our back"
+		return p.putTask(tx, *task)
+	}))
+	_, err = p.Undo(1)
+	require.ErrorIs(t, err, ErrUndoConflict)
+
+	// A later edit conflicts with undoing anything before it
+	other, err := p.Task.Add(MustNewTask("other", WithID("other")))
+	require.NoError(t, err)
+	other.Description = "other, edited"
+	_, err = p.Task.Edit(other)
+	require.NoError(t, err)
+	require.NoError(t, p.Store.Update(func(tx StoreTx) error {
+		other.Description = "edited again"
+		return p.putTask(tx, *other)
+	}))
+	_, err = p.Undo(2)
+	require.ErrorIs(t, err, ErrUndoConflict)
+	// Nothing was undone
+	journal, err := p.Journal()
+	require.NoError(t, err)
+	require.Equal(t, 4, len(journal))
+}
+
+func TestUndoImportBatch(t *testing.T) {
+	p := newTestPoet(t)
+	imported, err := p.ImportTaskWarrior(TaskWarriorTasks{
+		{Description: "one", UUID: "one"},
+		{Description: "two", UUID: "two"},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, imported)
+
+	journal, err := p.Journal()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(journal))
+	require.Equal(t, "import of 2 tasks", journal[0].Description())
+
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(p.MustList("")))
+}
+
+func TestUndoFailedAdd(t *testing.T) {
+	p := newTestPoet(t)
+	require.Error(t, p.Task.AddSet(Tasks{
+		MustNewTask("one", WithID("dup")),
+		MustNewTask("two", WithID("dup")),
+	}))
+	journal, err := p.Journal()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(journal))
+}
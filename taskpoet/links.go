@@ -0,0 +1,278 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+/*
+Parent and child links are stored on both sides, the child lists the parent in
+Parents and the parent lists the child in Children. Links are by ID only, and
+work across states, so a completed parent can still have active children.
+*/
+
+var (
+	// ErrLinkCycle is returned when a link would make a task its own ancestor
+	ErrLinkCycle = errors.New("link would create a cycle")
+	// ErrDuplicateLink is returned when two tasks are already linked
+	ErrDuplicateLink = errors.New("tasks are already linked")
+)
+
+// AddParent adds a parent to a child command
+func (svc *TaskServiceOp) AddParent(c, p *Task) error {
+	return svc.link(p, c)
+}
+
+// AddChild adds a child to a parent
+func (svc *TaskServiceOp) AddChild(p, c *Task) error {
+	return svc.link(p, c)
+}
+
+func (svc *TaskServiceOp) link(parent, child *Task) error {
+	all, err := svc.List("")
+	if err != nil {
+		return err
+	}
+	if err := addLink(linkGraph(all), parent, child); err != nil {
+		return err
+	}
+	return svc.EditSet([]Task{*child, *parent})
+}
+
+// addLink links child below parent, on both sides, as long as that doesn't
+// duplicate a link or close a cycle in graph
+func addLink(graph map[string]*Task, parent, child *Task) error {
+	if parent.ID == child.ID {
+		return fmt.Errorf("%v: %w", parent.ID, ErrLinkCycle)
+	}
+	if containsString(child.Parents, parent.ID) || containsString(parent.Children, child.ID) {
+		return fmt.Errorf("%v and %v: %w", parent.ID, child.ID, ErrDuplicateLink)
+	}
+	// The tasks we were handed may be newer than what is in the graph
+	graph[parent.ID] = parent
+	graph[child.ID] = child
+	if reachable(graph, child.ID, parent.ID) {
+		return fmt.Errorf("%v is already below %v: %w", parent.ID, child.ID, ErrLinkCycle)
+	}
+
+	child.Parents = append(child.Parents, parent.ID)
+	parent.Children = append(parent.Children, child.ID)
+	return nil
+}
+
+// linkGraph maps each task ID to the task
+func linkGraph(tasks Tasks) map[string]*Task {
+	graph := make(map[string]*Task, len(tasks))
+	for _, t := range tasks {
+		graph[t.ID] = t
+	}
+	return graph
+}
+
+// reachable is true if to is at or below from, following children
+func reachable(graph map[string]*Task, from, to string) bool {
+	seen := map[string]bool{}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == to {
+			return true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if t, ok := graph[id]; ok {
+			queue = append(queue, t.Children...)
+		}
+	}
+	return false
+}
+
+// unlinkIn removes every link and dependency to id from the other tasks in the namespace
+func (p *Poet) unlinkIn(tx StoreTx, id string) error {
+	linked := Tasks{}
+	if err := tx.ForEach(p.bucket, nil, func(k, v []byte) error {
+		var t Task
+		if err := json.Unmarshal(v, &t); err != nil {
+			return err
+		}
+		if containsString(t.Parents, id) || containsString(t.Children, id) || containsString(t.DependsOn, id) {
+			linked = append(linked, &t)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, t := range linked {
+		t.Parents = without(t.Parents, id)
+		t.Children = without(t.Children, id)
+		t.DependsOn = without(t.DependsOn, id)
+		if err := p.editTask(tx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeIn removes a task for good, along with any links to it
+func (p *Poet) purgeIn(tx StoreTx, t *Task) error {
+	if err := p.removeTask(tx, t.DetectKeyPath()); err != nil {
+		return err
+	}
+	if err := p.recordRevision(tx, OperationPurge, t, nil); err != nil {
+		return err
+	}
+	return p.unlinkIn(tx, t.ID)
+}
+
+// FsckKind is a kind of problem fsck can find
+type FsckKind string
+
+const (
+	// FsckOrphan is a link to a task that does not exist
+	FsckOrphan FsckKind = "orphan"
+	// FsckOneSided is a link that is only recorded on one of the two tasks
+	FsckOneSided FsckKind = "one-sided"
+	// FsckDuplicate is the same link recorded more than once
+	FsckDuplicate FsckKind = "duplicate"
+	// FsckCycle is a link that makes a task its own ancestor
+	FsckCycle FsckKind = "cycle"
+)
+
+// FsckProblem is a single broken link
+type FsckProblem struct {
+	Kind   FsckKind
+	TaskID string
+	Other  string
+	Detail string
+}
+
+// FsckReport is everything fsck found
+type FsckReport struct {
+	Checked  int
+	Problems []FsckProblem
+	Fixed    bool
+}
+
+// Fsck checks the parent and child links of every task in every state. With
+// fix set, the problems are repaired: orphaned and cyclic links are removed,
+// one-sided links are completed and duplicates are dropped. Repairs are a
+// single operation, so they can be undone
+func (p *Poet) Fsck(fix bool) (*FsckReport, error) {
+	all, err := p.Task.List("")
+	if err != nil {
+		return nil, err
+	}
+	report := &FsckReport{Checked: len(all), Problems: []FsckProblem{}}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	graph := linkGraph(all)
+	changed := map[string]bool{}
+	problem := func(kind FsckKind, t *Task, other, detail string) {
+		report.Problems = append(report.Problems, FsckProblem{Kind: kind, TaskID: t.ID, Other: other, Detail: detail})
+		changed[t.ID] = true
+	}
+
+	for _, t := range all {
+		if !CheckUniqueStringSlice(t.Parents) {
+			problem(FsckDuplicate, t, "", "duplicate parents")
+			t.Parents = filterUniqueStrings(t.Parents)
+		}
+		if !CheckUniqueStringSlice(t.Children) {
+			problem(FsckDuplicate, t, "", "duplicate children")
+			t.Children = filterUniqueStrings(t.Children)
+		}
+	}
+
+	for _, t := range all {
+		for _, id := range append([]string{}, t.Parents...) {
+			parent, ok := graph[id]
+			switch {
+			case !ok:
+				problem(FsckOrphan, t, id, "parent does not exist")
+				t.Parents = without(t.Parents, id)
+			case !containsString(parent.Children, t.ID):
+				problem(FsckOneSided, parent, t.ID, "child lists this as a parent, but it does not list the child")
+				parent.Children = append(parent.Children, t.ID)
+			}
+		}
+		for _, id := range append([]string{}, t.Children...) {
+			child, ok := graph[id]
+			switch {
+			case !ok:
+				problem(FsckOrphan, t, id, "child does not exist")
+				t.Children = without(t.Children, id)
+			case !containsString(child.Parents, t.ID):
+				problem(FsckOneSided, child, t.ID, "parent lists this as a child, but it does not list the parent")
+				child.Parents = append(child.Parents, t.ID)
+			}
+		}
+	}
+
+	for _, edge := range findCycleEdges(all, graph) {
+		parent, child := graph[edge[0]], graph[edge[1]]
+		problem(FsckCycle, parent, child.ID, "link to this child closes a cycle")
+		changed[child.ID] = true
+		parent.Children = without(parent.Children, child.ID)
+		child.Parents = without(child.Parents, parent.ID)
+	}
+
+	if !fix || len(changed) == 0 {
+		return report, nil
+	}
+	if err := p.update(OperationRepair, func(tx StoreTx) error {
+		for _, t := range all {
+			if !changed[t.ID] {
+				continue
+			}
+			if err := p.editTask(tx, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	report.Fixed = true
+	return report, nil
+}
+
+// findCycleEdges returns the parent to child links that close a cycle, so that
+// removing all of them leaves no cycles behind
+func findCycleEdges(all Tasks, graph map[string]*Task) [][2]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	edges := [][2]string{}
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		t := graph[id]
+		for _, child := range t.Children {
+			if _, ok := graph[child]; !ok {
+				continue
+			}
+			switch state[child] {
+			case visiting:
+				edges = append(edges, [2]string{id, child})
+			case unvisited:
+				visit(child)
+			}
+		}
+		state[id] = done
+	}
+	for _, t := range all {
+		if state[t.ID] == unvisited {
+			visit(t.ID)
+		}
+	}
+	return edges
+}
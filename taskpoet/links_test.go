@@ -0,0 +1,119 @@
+package taskpoet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkRejectsCyclesAndDuplicates(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		{ID: "grandparent", Description: "grandparent"},
+		{ID: "parent", Description: "parent"},
+		{ID: "child", Description: "child"},
+	}))
+	get := func(id string) *Task {
+		got, err := p.Task.GetWithID(id, "", "")
+		require.NoError(t, err)
+		return got
+	}
+	require.NoError(t, p.Task.AddParent(get("parent"), get("grandparent")))
+	require.NoError(t, p.Task.AddChild(get("parent"), get("child")))
+
+	require.ErrorIs(t, p.Task.AddParent(get("child"), get("parent")), ErrDuplicateLink)
+	require.ErrorIs(t, p.Task.AddChild(get("child"), get("grandparent")), ErrLinkCycle)
+	require.ErrorIs(t, p.Task.AddParent(get("child"), get("child")), ErrLinkCycle)
+
+	// Nothing changed from the rejected links
+	require.Equal(t, []string{"parent"}, get("child").Parents)
+	require.Empty(t, get("child").Children)
+	require.Empty(t, get("grandparent").Parents)
+}
+
+func TestAddLink(t *testing.T) {
+	parent := &Task{ID: "parent"}
+	child := &Task{ID: "child"}
+	graph := linkGraph(Tasks{parent})
+	require.NoError(t, addLink(graph, parent, child))
+	require.Equal(t, []string{"child"}, parent.Children)
+	require.Equal(t, []string{"parent"}, child.Parents)
+
+	require.ErrorIs(t, addLink(graph, parent, child), ErrDuplicateLink)
+	require.ErrorIs(t, addLink(graph, child, parent), ErrLinkCycle)
+	require.Equal(t, []string{"child"}, parent.Children)
+	require.Empty(t, parent.Parents)
+}
+
+func TestPurgeCleansLinks(t *testing.T) {
+	p := newTestPoet(t)
+	parent := &Task{ID: "parent", Description: "parent"}
+	child := &Task{ID: "child", Description: "child"}
+	require.NoError(t, p.Task.AddSet(Tasks{parent, child}))
+	require.NoError(t, p.Task.AddParent(child, parent))
+
+	require.NoError(t, p.Task.Purge(parent))
+	got, err := p.Task.GetWithID("child", "", "")
+	require.NoError(t, err)
+	require.Empty(t, got.Parents)
+
+	// Undoing the purge puts the link back too
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	got, err = p.Task.GetWithID("child", "", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"parent"}, got.Parents)
+}
+
+func TestFsck(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		// Links to a task that was never there
+		{ID: "a", Description: "a", Parents: []string{"ghost"}},
+		// Only b knows about the link
+		{ID: "b", Description: "b", Children: []string{"c", "c"}},
+		{ID: "c", Description: "c", Parents: []string{"b"}},
+		// d and e are each others parent
+		{ID: "d", Description: "d", Children: []string{"e"}, Parents: []string{"e"}},
+		{ID: "e", Description: "e", Children: []string{"d"}, Parents: []string{"d"}},
+		{ID: "f", Description: "f", Children: []string{"g"}},
+		{ID: "g", Description: "g"},
+	}))
+	done, err := p.Task.GetWithID("g", "", "")
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Complete(done))
+
+	report, err := p.Fsck(false)
+	require.NoError(t, err)
+	require.False(t, report.Fixed)
+	require.Equal(t, 7, report.Checked)
+	require.Equal(t, []FsckProblem{
+		{Kind: FsckDuplicate, TaskID: "b", Detail: "duplicate children"},
+		{Kind: FsckOrphan, TaskID: "a", Other: "ghost", Detail: "parent does not exist"},
+		{Kind: FsckOneSided, TaskID: "g", Other: "f", Detail: "parent lists this as a child, but it does not list the parent"},
+		{Kind: FsckCycle, TaskID: "e", Other: "d", Detail: "link to this child closes a cycle"},
+	}, report.Problems)
+
+	// Checking doesn't change anything
+	report, err = p.Fsck(false)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(report.Problems))
+
+	report, err = p.Fsck(true)
+	require.NoError(t, err)
+	require.True(t, report.Fixed)
+	report, err = p.Fsck(false)
+	require.NoError(t, err)
+	require.Empty(t, report.Problems)
+
+	g, err := p.Task.GetWithID("g", "", "/completed")
+	require.NoError(t, err)
+	require.Equal(t, []string{"f"}, g.Parents)
+
+	// The repair is a single operation that can be undone
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	report, err = p.Fsck(false)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(report.Problems))
+}
@@ -0,0 +1,250 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+The schema version is stored in the /meta bucket, which is shared by every
+namespace. A database without a version is version 0. When a Poet opens an
+older database, each Migration with a newer version is applied, in order, to
+every stored task in every namespace, all inside of a single transaction.
+*/
+
+var metaBucket = []byte("/meta")
+
+const schemaVersionKey = "schema_version"
+
+// Migration upgrades the stored tasks to a new schema version
+type Migration struct {
+	Version     int
+	Description string
+	// Migrate is given the raw json of a single stored task, and returns true
+	// if it changed anything. Working on the raw json instead of a Task means
+	// old records can be read even after the Task struct changes
+	Migrate func(record map[string]any) (bool, error)
+	// Reindex rebuilds the indexes of every namespace, even when Migrate didn't
+	// change any tasks
+	Reindex bool
+}
+
+// migrations must stay in version order. Never change or remove a migration
+// once it has been released, only add new ones
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "De-duplicate tags",
+		Migrate: func(record map[string]any) (bool, error) {
+			raw, ok := record["tags"].([]any)
+			if !ok || len(raw) == 0 {
+				return false, nil
+			}
+			tags := make([]string, len(raw))
+			for idx, item := range raw {
+				tags[idx] = fmt.Sprint(item)
+			}
+			// The order of tags is up to the person adding them, so it is kept
+			clean := filterUniqueStrings(tags)
+			if strings.Join(clean, "\x00") == strings.Join(tags, "\x00") {
+				return false, nil
+			}
+			record["tags"] = clean
+			return true, nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "Reset effort_impact values that are out of range to unset",
+		Migrate: func(record map[string]any) (bool, error) {
+			ei, ok := record["effort_impact"].(float64)
+			if !ok || (ei >= float64(EffortImpactUnset) && ei <= float64(EffortImpactAvoid)) {
+				return false, nil
+			}
+			record["effort_impact"] = EffortImpactUnset
+			return true, nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "Give every comment an ID",
+		Migrate:     commentIDMigrate,
+	},
+	{
+		Version:     4,
+		Description: "Build the tag, project, due and plugin indexes",
+		Migrate:     func(map[string]any) (bool, error) { return false, nil },
+		Reindex:     true,
+	},
+}
+
+// CurrentSchemaVersion is the schema version this version of taskpoet writes
+func CurrentSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// MigrationResult is what a single Migration changed, or would change
+type MigrationResult struct {
+	Version     int
+	Description string
+	Changed     []string
+}
+
+// MigrationReport describes a migration run
+type MigrationReport struct {
+	From    int
+	To      int
+	DryRun  bool
+	Results []MigrationResult
+}
+
+var errDryRun = errors.New("dry run, rolling back")
+
+// WithAutoMigrate sets whether New migrates an older database when it is
+// opened. Defaults to true
+func WithAutoMigrate(b bool) Option {
+	return success(func(p *Poet) {
+		p.autoMigrate = b
+	})
+}
+
+// SchemaVersion returns the schema version recorded in the database
+func (p *Poet) SchemaVersion() (int, error) {
+	var v int
+	err := p.Store.View(func(tx StoreTx) error {
+		var err error
+		v, err = schemaVersion(tx)
+		return err
+	})
+	return v, err
+}
+
+func schemaVersion(tx StoreTx) (int, error) {
+	got, err := tx.Get(metaBucket, []byte(schemaVersionKey))
+	if err != nil || got == nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(got))
+}
+
+// Migrate brings the database up to CurrentSchemaVersion. With dryRun set,
+// everything is done inside of a transaction that is then thrown away, so the
+// report shows what would have changed
+func (p *Poet) Migrate(dryRun bool) (*MigrationReport, error) {
+	report := &MigrationReport{DryRun: dryRun, To: CurrentSchemaVersion()}
+	err := p.Store.Update(func(tx StoreTx) error {
+		var err error
+		if report.From, err = schemaVersion(tx); err != nil {
+			return err
+		}
+		if report.From > report.To {
+			return fmt.Errorf("database schema version %v is newer than this taskpoet supports (%v)", report.From, report.To)
+		}
+		if report.Results, err = applyMigrations(tx, report.From); err != nil {
+			return err
+		}
+		if report.From != report.To {
+			if err := tx.Put(metaBucket, []byte(schemaVersionKey), []byte(strconv.Itoa(report.To))); err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// taskBuckets returns the task bucket of every namespace
+func taskBuckets(tx StoreTx) ([][]byte, error) {
+	buckets, err := tx.Buckets()
+	if err != nil {
+		return nil, err
+	}
+	ret := [][]byte{}
+	for _, b := range buckets {
+		if strings.HasSuffix(string(b), "/tasks") {
+			ret = append(ret, b)
+		}
+	}
+	return ret, nil
+}
+
+func applyMigrations(tx StoreTx, from int) ([]MigrationResult, error) {
+	results := []MigrationResult{}
+	buckets, err := taskBuckets(tx)
+	if err != nil {
+		return nil, err
+	}
+	reindex := map[string]bool{}
+	for _, m := range migrations {
+		if m.Version <= from {
+			continue
+		}
+		result := MigrationResult{Version: m.Version, Description: m.Description, Changed: []string{}}
+		for _, bucket := range buckets {
+			changed, err := migrateBucket(tx, bucket, m)
+			if err != nil {
+				return nil, err
+			}
+			if len(changed) > 0 || m.Reindex {
+				reindex[string(bucket)] = true
+			}
+			result.Changed = append(result.Changed, changed...)
+		}
+		results = append(results, result)
+	}
+	for bucket := range reindex {
+		indexBucket := []byte(strings.TrimSuffix(bucket, "/tasks") + "/index")
+		if _, err := rebuildIndexes(tx, []byte(bucket), indexBucket); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// migrateBucket applies a single migration to every task in a bucket, returning
+// the paths that changed
+func migrateBucket(tx StoreTx, bucket []byte, m Migration) ([]string, error) {
+	// Collect the changes first, bolt doesn't like a bucket changing while we
+	// are walking it
+	pending := map[string][]byte{}
+	if err := tx.ForEach(bucket, nil, func(k, v []byte) error {
+		var record map[string]any
+		if err := json.Unmarshal(v, &record); err != nil {
+			return fmt.Errorf("could not decode %s%s: %w", bucket, k, err)
+		}
+		changed, err := m.Migrate(record)
+		if err != nil || !changed {
+			return err
+		}
+		updated, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		pending[string(k)] = updated
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	changed := make([]string, 0, len(pending))
+	for k, v := range pending {
+		if err := tx.Put(bucket, []byte(k), v); err != nil {
+			return nil, err
+		}
+		changed = append(changed, fmt.Sprintf("%s%s", bucket, k))
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
@@ -0,0 +1,146 @@
+package taskpoet
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// putRaw stores a raw json record, the way an older taskpoet would have
+func putRaw(t *testing.T, s Store, bucket, key, value string) {
+	require.NoError(t, s.Update(func(tx StoreTx) error {
+		return tx.Put([]byte(bucket), []byte(key), []byte(value))
+	}))
+}
+
+func TestNewStampsSchemaVersion(t *testing.T) {
+	p := newTestPoet(t)
+	got, err := p.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, CurrentSchemaVersion(), got)
+}
+
+// metaWriteStore counts the writes to the meta bucket
+type metaWriteStore struct {
+	Store
+	writes int
+}
+
+type metaWriteTx struct {
+	StoreTx
+	s *metaWriteStore
+}
+
+func (s *metaWriteStore) Update(fn func(StoreTx) error) error {
+	return s.Store.Update(func(tx StoreTx) error {
+		return fn(metaWriteTx{StoreTx: tx, s: s})
+	})
+}
+
+func (tx metaWriteTx) Put(bucket, key, value []byte) error {
+	if bytes.Equal(bucket, metaBucket) {
+		tx.s.writes++
+	}
+	return tx.StoreTx.Put(bucket, key, value)
+}
+
+func TestMigrateOnlyWritesNewVersions(t *testing.T) {
+	s := &metaWriteStore{Store: NewMemoryStore()}
+	_, err := New(WithStore(s))
+	require.NoError(t, err)
+	require.Equal(t, 1, s.writes)
+
+	_, err = New(WithStore(s))
+	require.NoError(t, err)
+	require.Equal(t, 1, s.writes)
+}
+
+func TestMigrateOnOpen(t *testing.T) {
+	s := NewMemoryStore()
+	putRaw(t, s, "/default/tasks", "/active/builtin/messy", `{"id":"messy","description":"messy","tags":["b","a","b"],"effort_impact":9}`)
+	putRaw(t, s, "/other/tasks", "/active/builtin/clean", `{"id":"clean","description":"clean","tags":["a"]}`)
+
+	p, err := New(WithStore(s))
+	require.NoError(t, err)
+	got, err := p.Task.GetWithID("messy", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a"}, got.Tags)
+	require.Equal(t, EffortImpactUnset, got.EffortImpact)
+
+	// Migrated tasks get indexed too
+	tagged, err := p.Task.ListWithTag("/active", "b")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tagged))
+
+	v, err := p.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, CurrentSchemaVersion(), v)
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	s := NewMemoryStore()
+	raw := `{"id":"messy","description":"messy","tags":["b","a","b"]}`
+	putRaw(t, s, "/default/tasks", "/active/builtin/messy", raw)
+
+	p, err := New(WithStore(s), WithAutoMigrate(false))
+	require.NoError(t, err)
+
+	report, err := p.Migrate(true)
+	require.NoError(t, err)
+	require.Equal(t, 0, report.From)
+	require.Equal(t, CurrentSchemaVersion(), report.To)
+	require.Equal(t, []string{"/default/tasks/active/builtin/messy"}, report.Results[0].Changed)
+	require.Empty(t, report.Results[1].Changed)
+
+	// Nothing should have actually changed
+	v, err := p.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, 0, v)
+	require.NoError(t, s.View(func(tx StoreTx) error {
+		got, gerr := tx.Get([]byte("/default/tasks"), []byte("/active/builtin/messy"))
+		require.NoError(t, gerr)
+		require.Equal(t, raw, string(got))
+		return nil
+	}))
+
+	report, err = p.Migrate(false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(report.Results[0].Changed))
+	report, err = p.Migrate(false)
+	require.NoError(t, err)
+	require.Equal(t, report.From, report.To)
+	require.Empty(t, report.Results)
+}
+
+func TestMigrateBuildsIndexes(t *testing.T) {
+	s := NewMemoryStore()
+	// A database from before indexes, that no other migration changes
+	putRaw(t, s, string(metaBucket), schemaVersionKey, "3")
+	putRaw(t, s, "/default/tasks", "/active/builtin/tidy", `{"id":"tidy","description":"tidy","tags":["a"]}`)
+
+	p, err := New(WithStore(s))
+	require.NoError(t, err)
+	tagged, err := p.Task.ListWithTag("/active", "a")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tagged))
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	s := NewMemoryStore()
+	putRaw(t, s, string(metaBucket), schemaVersionKey, "9999")
+	_, err := New(WithStore(s))
+	require.EqualError(t, err, "database schema version 9999 is newer than this taskpoet supports (4)")
+}
+
+func TestMigrationsInOrder(t *testing.T) {
+	for idx, m := range migrations {
+		require.Equal(t, idx+1, m.Version, m.Description)
+		require.NotPanics(t, func() {
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(`{}`), &record))
+			_, _ = m.Migrate(record)
+		})
+	}
+}
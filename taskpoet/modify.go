@@ -0,0 +1,276 @@
+package taskpoet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Modification is a set of changes made to many tasks at once. Anything left
+// empty is not changed
+type Modification struct {
+	Description  string
+	Project      string
+	Due          *time.Time
+	HideUntil    *time.Time
+	EffortImpact *EffortImpact
+	AddTags      []string
+	RemoveTags   []string
+	// AddDepends and RemoveDepends are the full IDs of tasks to start or stop
+	// waiting on
+	AddDepends    []string
+	RemoveDepends []string
+}
+
+// IsEmpty is true when the modification doesn't change anything
+func (m Modification) IsEmpty() bool {
+	return m.Description == "" && m.Project == "" && m.Due == nil && m.HideUntil == nil && m.EffortImpact == nil &&
+		len(m.AddTags) == 0 && len(m.RemoveTags) == 0 && len(m.AddDepends) == 0 && len(m.RemoveDepends) == 0
+}
+
+// Apply returns a copy of the task with the modification made. New
+// dependencies are left to Modify, which checks them against every task
+func (m Modification) Apply(t Task) Task {
+	if m.Description != "" {
+		t.Description = m.Description
+	}
+	if m.Project != "" {
+		t.Project = m.Project
+	}
+	if m.Due != nil {
+		t.Due = m.Due
+	}
+	if m.HideUntil != nil {
+		t.HideUntil = m.HideUntil
+	}
+	if m.EffortImpact != nil {
+		t.EffortImpact = *m.EffortImpact
+	}
+	if len(m.AddTags) > 0 || len(m.RemoveTags) > 0 {
+		tags := map[string]bool{}
+		for _, tag := range t.Tags {
+			tags[tag] = true
+		}
+		for _, tag := range m.AddTags {
+			tags[tag] = true
+		}
+		for _, tag := range m.RemoveTags {
+			delete(tags, tag)
+		}
+		t.Tags = make([]string, 0, len(tags))
+		for tag := range tags {
+			t.Tags = append(t.Tags, tag)
+		}
+		sort.Strings(t.Tags)
+	}
+	for _, id := range m.RemoveDepends {
+		t.DependsOn = without(t.DependsOn, id)
+	}
+	return t
+}
+
+// ParseTagArgs splits +tag and -tag arguments out from the rest
+func ParseTagArgs(args []string) (rest, add, remove []string) {
+	rest, add, remove = []string{}, []string{}, []string{}
+	for _, arg := range args {
+		switch {
+		case len(arg) > 1 && strings.HasPrefix(arg, "+"):
+			add = append(add, arg[1:])
+		case len(arg) > 1 && strings.HasPrefix(arg, "-"):
+			remove = append(remove, arg[1:])
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, add, remove
+}
+
+// IDRange is an inclusive range of task IDs, like 3a-3f. IDs are compared to
+// each end by its length, so 3a-3f covers every ID starting with 3a through 3f
+type IDRange struct {
+	From string
+	To   string
+}
+
+// ParseIDRange parses a range like 3a-3f. ok is false when s isn't a range
+func ParseIDRange(s string) (r IDRange, ok bool) {
+	from, to, found := strings.Cut(s, "-")
+	if !found || from == "" || to == "" || strings.Contains(to, "-") {
+		return IDRange{}, false
+	}
+	return IDRange{From: from, To: to}, true
+}
+
+// String returns the range the way it is given
+func (r IDRange) String() string {
+	return r.From + "-" + r.To
+}
+
+// Contains is true when the id is in the range
+func (r IDRange) Contains(id string) bool {
+	return id[:min(len(id), len(r.From))] >= r.From && id[:min(len(id), len(r.To))] <= r.To
+}
+
+// OpenWithIDs returns the open tasks given as partial IDs, comma separated
+// lists of them, or ranges like 3a-3f. Something that looks like a range but is
+// the start of an ID, like a UUID, is used as an ID. Each task is returned
+// once, in the order given, with the tasks in a range sorted by ID
+func (p *Poet) OpenWithIDs(args []string) (Tasks, error) {
+	open, err := p.ListOpen()
+	if err != nil {
+		return nil, err
+	}
+	ret := Tasks{}
+	seen := map[string]bool{}
+	add := func(t *Task) {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			ret = append(ret, t)
+		}
+	}
+	for _, arg := range args {
+		for _, item := range strings.Split(arg, ",") {
+			if item == "" {
+				continue
+			}
+			if r, ok := ParseIDRange(item); ok && !hasIDPrefix(open, item) {
+				got, err := openInRange(open, r)
+				if err != nil {
+					return nil, err
+				}
+				for _, t := range got {
+					add(t)
+				}
+				continue
+			}
+			t, err := p.Task.GetOpenWithPartialID(item, "")
+			if err != nil {
+				return nil, err
+			}
+			add(t)
+		}
+	}
+	return ret, nil
+}
+
+// hasIDPrefix is true when any builtin task has an ID starting with prefix
+func hasIDPrefix(tasks Tasks, prefix string) bool {
+	for _, t := range tasks {
+		if isBuiltin(t) && strings.HasPrefix(t.ID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// openInRange returns the builtin tasks in the range, sorted by ID
+func openInRange(open Tasks, r IDRange) (Tasks, error) {
+	if r.From > r.To {
+		return nil, fmt.Errorf("range %v ends before it starts", r)
+	}
+	ret := Tasks{}
+	for _, t := range open {
+		if isBuiltin(t) && r.Contains(t.ID) {
+			ret = append(ret, t)
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no open tasks in the range %v", r)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret, nil
+}
+
+// isBuiltin is true for tasks that didn't come from a plugin, which are the
+// only ones partial IDs find
+func isBuiltin(t *Task) bool {
+	return t.PluginID == "" || t.PluginID == DefaultPluginID
+}
+
+// Modify makes the modification to every task in a single transaction. If any
+// of them end up invalid, none are changed. The tasks are updated in place
+func (p *Poet) Modify(tasks Tasks, m Modification) error {
+	if m.IsEmpty() {
+		return errors.New("nothing to modify")
+	}
+	if len(tasks) == 0 {
+		return errors.New("no tasks to modify")
+	}
+	if err := ValidateProject(m.Project); err != nil {
+		return err
+	}
+	// Edits treat an unset effort/impact as leaving it alone
+	if m.EffortImpact != nil && *m.EffortImpact == EffortImpactUnset {
+		return errors.New("effort/impact cannot be modified back to unset")
+	}
+	modified, err := p.applyModification(tasks, m)
+	if err != nil {
+		return err
+	}
+	for _, t := range modified {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := p.Task.EditSet(modified); err != nil {
+		return err
+	}
+	for idx := range tasks {
+		*tasks[idx] = modified[idx]
+	}
+	return nil
+}
+
+// applyModification returns copies of the tasks with the modification made.
+// New dependencies go through the same checks as DependOn, across every task
+// and the rest of the modified ones
+func (p *Poet) applyModification(tasks Tasks, m Modification) ([]Task, error) {
+	modified := make([]Task, len(tasks))
+	for idx, t := range tasks {
+		modified[idx] = m.Apply(*t)
+	}
+	if len(m.AddDepends) == 0 {
+		return modified, nil
+	}
+	all, err := p.Task.List("")
+	if err != nil {
+		return nil, err
+	}
+	graph := linkGraph(all)
+	for idx := range modified {
+		// Copied, so a failed modification leaves the given tasks alone
+		modified[idx].DependsOn = append([]string{}, modified[idx].DependsOn...)
+		for _, id := range m.AddDepends {
+			if err := addDependency(graph, &modified[idx], id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return modified, nil
+}
+
+// ModifyPreview renders what a modification would change on each task
+func (p *Poet) ModifyPreview(tasks Tasks, m Modification) (string, error) {
+	modified, err := p.applyModification(tasks, m)
+	if err != nil {
+		return "", err
+	}
+	p.SetShortIDs(tasks...)
+	rows := [][]string{}
+	for tidx, t := range tasks {
+		changes, err := diffTasks(t, &modified[tidx])
+		if err != nil {
+			return "", err
+		}
+		for idx, c := range changes {
+			id, desc := "", ""
+			if idx == 0 {
+				id, desc = t.ShortID(), t.Description
+			}
+			rows = append(rows, []string{id, desc, c.Field, historyValue(c.Old), historyValue(c.New)})
+		}
+	}
+	return p.listTable([]string{"ID", "Description", "Field", "Old", "New"}, rows), nil
+}
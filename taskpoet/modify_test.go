@@ -0,0 +1,156 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTagArgs(t *testing.T) {
+	rest, add, remove := ParseTagArgs([]string{"3fa8", "+urgent", "-someday", "+", "9c1d"})
+	require.Equal(t, []string{"3fa8", "+", "9c1d"}, rest)
+	require.Equal(t, []string{"urgent"}, add)
+	require.Equal(t, []string{"someday"}, remove)
+}
+
+func TestModify(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("one", WithID("one"), WithTags([]string{"someday", "home"})),
+		MustNewTask("two", WithID("two"), WithTags([]string{"someday"})),
+	}))
+	tasks := p.MustList("/active")
+
+	require.EqualError(t, p.Modify(tasks, Modification{}), "nothing to modify")
+	unset := EffortImpactUnset
+	require.Error(t, p.Modify(tasks, Modification{EffortImpact: &unset}))
+
+	due := time.Now().Add(24 * time.Hour)
+	ei := EffortImpactHigh
+	m := Modification{Due: &due, EffortImpact: &ei, AddTags: []string{"urgent"}, RemoveTags: []string{"someday"}}
+	preview, err := p.ModifyPreview(tasks, m)
+	require.NoError(t, err)
+	require.Contains(t, preview, "effort_impact")
+
+	require.NoError(t, p.Modify(tasks, m))
+	one, err := p.Task.GetWithID("one", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, []string{"home", "urgent"}, one.Tags)
+	require.Equal(t, EffortImpactHigh, one.EffortImpact)
+	require.Equal(t, due.Unix(), one.Due.Unix())
+	two, err := p.Task.GetWithID("two", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, []string{"urgent"}, two.Tags)
+
+	// Invalid changes leave everything alone
+	later := due.Add(time.Hour)
+	require.Error(t, p.Modify(p.MustList("/active"), Modification{HideUntil: &later}))
+	one, err = p.Task.GetWithID("one", "", "/active")
+	require.NoError(t, err)
+	require.Nil(t, one.HideUntil)
+
+	// Projects are checked like they are on add
+	require.EqualError(t, p.Modify(p.MustList("/active"), Modification{Project: "work..infra"}),
+		`invalid project "work..infra", it cannot have an empty part`)
+	require.NoError(t, p.Modify(Tasks{one}, Modification{Project: "work.infra"}))
+	one, err = p.Task.GetWithID("one", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, "work.infra", one.Project)
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+
+	// The whole modification is a single operation
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	one, err = p.Task.GetWithID("one", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, []string{"home", "someday"}, one.Tags)
+	require.Equal(t, EffortImpactUnset, one.EffortImpact)
+}
+
+func TestModifyDepends(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("get certs", WithID("certs")),
+		MustNewTask("deploy certs", WithID("deploy"), WithDependsOn("certs")),
+		MustNewTask("announce", WithID("announce")),
+	}))
+	get := func(id string) *Task {
+		got, err := p.Task.GetWithID(id, "", "/active")
+		require.NoError(t, err)
+		return got
+	}
+
+	require.NoError(t, p.Modify(Tasks{get("announce")}, Modification{AddDepends: []string{"deploy"}}))
+	require.Equal(t, []string{"deploy"}, get("announce").DependsOn)
+
+	// The same checks as DependOn, and nothing changes when they fail
+	require.ErrorIs(t, p.Modify(Tasks{get("announce")}, Modification{AddDepends: []string{"deploy"}}), ErrDuplicateLink)
+	require.ErrorIs(t, p.Modify(Tasks{get("certs")}, Modification{AddDepends: []string{"certs"}}), ErrLinkCycle)
+	require.ErrorIs(t, p.Modify(Tasks{get("announce"), get("certs")}, Modification{AddDepends: []string{"announce"}}), ErrLinkCycle)
+	require.Empty(t, get("certs").DependsOn)
+
+	preview, err := p.ModifyPreview(Tasks{get("certs")}, Modification{AddDepends: []string{"announce"}})
+	require.ErrorIs(t, err, ErrLinkCycle)
+	require.Empty(t, preview)
+
+	require.NoError(t, p.Modify(Tasks{get("deploy")}, Modification{RemoveDepends: []string{"certs"}}))
+	require.Empty(t, get("deploy").DependsOn)
+}
+
+func TestParseIDRange(t *testing.T) {
+	r, ok := ParseIDRange("3a-3f")
+	require.True(t, ok)
+	require.Equal(t, IDRange{From: "3a", To: "3f"}, r)
+	require.Equal(t, "3a-3f", r.String())
+	for _, s := range []string{"3a", "-3f", "3a-", "3c2c3988-2c17-407a"} {
+		_, ok := ParseIDRange(s)
+		require.False(t, ok, s)
+	}
+
+	require.True(t, r.Contains("3a00"))
+	require.True(t, r.Contains("3c9"))
+	require.True(t, r.Contains("3fff"))
+	require.False(t, r.Contains("39ff"))
+	require.False(t, r.Contains("40"))
+	require.False(t, r.Contains("3"))
+}
+
+func TestOpenWithIDs(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("a", WithID("3a01")),
+		MustNewTask("b", WithID("3c02")),
+		MustNewTask("c", WithID("3f03")),
+		MustNewTask("d", WithID("4004")),
+		MustNewTask("dashed", WithID("ab-cd")),
+	}))
+	ids := func(tasks Tasks) []string {
+		ret := []string{}
+		for _, t := range tasks {
+			ret = append(ret, t.ID)
+		}
+		return ret
+	}
+
+	got, err := p.OpenWithIDs([]string{"40", "3a-3c"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"4004", "3a01", "3c02"}, ids(got))
+
+	got, err = p.OpenWithIDs([]string{"3c,3a-3f", "3f"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"3c02", "3a01", "3f03"}, ids(got))
+
+	// An ID with a dash in it is not a range
+	got, err = p.OpenWithIDs([]string{"ab-c"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ab-cd"}, ids(got))
+
+	_, err = p.OpenWithIDs([]string{"3f-3a"})
+	require.EqualError(t, err, "range 3f-3a ends before it starts")
+	_, err = p.OpenWithIDs([]string{"50-5f"})
+	require.EqualError(t, err, "no open tasks in the range 50-5f")
+	_, err = p.OpenWithIDs([]string{"nope"})
+	require.Error(t, err)
+}
@@ -0,0 +1,391 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+/*
+Each namespace is a set of buckets that all start with /${namespace}/. A
+namespace exists if its tasks bucket does.
+*/
+
+// namespaceBuckets returns the tasks, index, history, journal, recur and
+// templates buckets of a namespace
+func namespaceBuckets(ns string) (tasks, index, history, journal, recur, templates []byte) {
+	return []byte(fmt.Sprintf("/%v/tasks", ns)),
+		[]byte(fmt.Sprintf("/%v/index", ns)),
+		[]byte(fmt.Sprintf("/%v/history", ns)),
+		[]byte(fmt.Sprintf("/%v/journal", ns)),
+		[]byte(fmt.Sprintf("/%v/recur", ns)),
+		[]byte(fmt.Sprintf("/%v/templates", ns))
+}
+
+// namespaceBucketList is every bucket of a namespace
+func namespaceBucketList(ns string) [][]byte {
+	tasks, index, history, journal, recur, templates := namespaceBuckets(ns)
+	return [][]byte{tasks, index, history, journal, recur, templates}
+}
+
+// NamespaceSummary is a namespace, and how many tasks it has in each state
+type NamespaceSummary struct {
+	Name   string
+	Counts map[string]int
+}
+
+// Total is the number of tasks in every state
+func (n NamespaceSummary) Total() int {
+	var total int
+	for _, c := range n.Counts {
+		total += c
+	}
+	return total
+}
+
+// CollisionPolicy says what a merge does when a task ID is in both namespaces
+type CollisionPolicy string
+
+const (
+	// CollisionFail stops the merge without changing anything
+	CollisionFail CollisionPolicy = "fail"
+	// CollisionSkip keeps the task that is already in the destination
+	CollisionSkip CollisionPolicy = "skip"
+	// CollisionOverwrite replaces the destination task with the source task
+	CollisionOverwrite CollisionPolicy = "overwrite"
+	// CollisionNewID gives the source task a new ID
+	CollisionNewID CollisionPolicy = "new-id"
+)
+
+// CollisionPolicies is every valid CollisionPolicy
+func CollisionPolicies() []CollisionPolicy {
+	return []CollisionPolicy{CollisionFail, CollisionSkip, CollisionOverwrite, CollisionNewID}
+}
+
+// MergeReport is what a merge did
+type MergeReport struct {
+	Merged      int
+	Skipped     []string
+	Overwritten []string
+	Renamed     map[string]string
+}
+
+// ErrNamespaceCollision is returned by a merge when a task is in both
+// namespaces and the policy is CollisionFail
+var ErrNamespaceCollision = errors.New("task exists in both namespaces")
+
+func validNamespace(ns string) error {
+	switch {
+	case ns == "":
+		return errors.New("namespace cannot be empty")
+	case strings.Contains(ns, "/"):
+		return errors.New("namespace cannot contain a slash (/)")
+	default:
+		return nil
+	}
+}
+
+func namespaceExists(tx StoreTx, ns string) (bool, error) {
+	buckets, err := taskBuckets(tx)
+	if err != nil {
+		return false, err
+	}
+	tasks, _, _, _, _, _ := namespaceBuckets(ns)
+	for _, b := range buckets {
+		if string(b) == string(tasks) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Namespaces returns every namespace in the database, with the number of tasks
+// in each state
+func (p *Poet) Namespaces() ([]NamespaceSummary, error) {
+	ret := []NamespaceSummary{}
+	err := p.Store.View(func(tx StoreTx) error {
+		buckets, err := taskBuckets(tx)
+		if err != nil {
+			return err
+		}
+		for _, b := range buckets {
+			summary := NamespaceSummary{
+				Name:   strings.TrimSuffix(strings.TrimPrefix(string(b), "/"), "/tasks"),
+				Counts: map[string]int{},
+			}
+			for _, state := range p.Task.GetStates() {
+				summary.Counts[state] = 0
+			}
+			if err := tx.ForEach(b, nil, func(k, v []byte) error {
+				state := strings.SplitN(strings.TrimPrefix(string(k), "/"), "/", 2)[0]
+				summary.Counts[state]++
+				return nil
+			}); err != nil {
+				return err
+			}
+			ret = append(ret, summary)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// checkNamespaces makes sure src and dst are usable, and that src exists
+func (p *Poet) checkNamespaces(tx StoreTx, src, dst string) error {
+	for _, ns := range []string{src, dst} {
+		if err := validNamespace(ns); err != nil {
+			return err
+		}
+	}
+	if src == dst {
+		return errors.New("source and destination namespaces must be different")
+	}
+	exists, err := namespaceExists(tx, src)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("namespace does not exist: %v", src)
+	}
+	return nil
+}
+
+// CopyNamespace copies everything in the src namespace in to a new dst
+// namespace
+func (p *Poet) CopyNamespace(src, dst string) error {
+	return p.Store.Update(func(tx StoreTx) error {
+		return p.copyNamespace(tx, src, dst)
+	})
+}
+
+func (p *Poet) copyNamespace(tx StoreTx, src, dst string) error {
+	if err := p.checkNamespaces(tx, src, dst); err != nil {
+		return err
+	}
+	exists, err := namespaceExists(tx, dst)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("namespace already exists: %v, try merging instead", dst)
+	}
+	srcBuckets := namespaceBucketList(src)
+	for idx, dstBucket := range namespaceBucketList(dst) {
+		if err := tx.CreateBucket(dstBucket); err != nil {
+			return err
+		}
+		if err := tx.ForEach(srcBuckets[idx], nil, func(k, v []byte) error {
+			return tx.Put(dstBucket, copyBytes(k), copyBytes(v))
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameNamespace moves everything in the src namespace to a new dst namespace
+func (p *Poet) RenameNamespace(src, dst string) error {
+	if src == p.Namespace {
+		return fmt.Errorf("cannot rename the namespace in use: %v", src)
+	}
+	return p.Store.Update(func(tx StoreTx) error {
+		if err := p.copyNamespace(tx, src, dst); err != nil {
+			return err
+		}
+		return deleteNamespace(tx, src)
+	})
+}
+
+// DeleteNamespace removes a namespace and everything in it
+func (p *Poet) DeleteNamespace(ns string) error {
+	if ns == p.Namespace {
+		return fmt.Errorf("cannot delete the namespace in use: %v", ns)
+	}
+	return p.Store.Update(func(tx StoreTx) error {
+		exists, err := namespaceExists(tx, ns)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("namespace does not exist: %v", ns)
+		}
+		return deleteNamespace(tx, ns)
+	})
+}
+
+func deleteNamespace(tx StoreTx, ns string) error {
+	buckets, err := tx.Buckets()
+	if err != nil {
+		return err
+	}
+	remove := map[string]bool{}
+	for _, b := range namespaceBucketList(ns) {
+		remove[string(b)] = true
+	}
+	for _, b := range buckets {
+		if remove[string(b)] {
+			if err := tx.DeleteBucket(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// withNamespace returns a Poet that works on another namespace of the same
+// store. The workflow states come along, so tasks in any of them are found, and
+// so does the curator, which has the urgency of each state
+func (p *Poet) withNamespace(ns string) *Poet {
+	np := &Poet{
+		Store:          p.Store,
+		Namespace:      ns,
+		WorkflowStates: p.WorkflowStates,
+		UDAs:           p.UDAs,
+		curator:        p.curator,
+		styling:        p.styling,
+		journal:        &journalState{},
+	}
+	np.setBuckets()
+	np.Task = &TaskServiceOp{localClient: np}
+	return np
+}
+
+// MergeNamespace adds every task in the src namespace to the dst namespace,
+// along with its history, recurring tasks and templates. The src namespace is
+// left alone. policy decides what happens to a task that is in both
+func (p *Poet) MergeNamespace(src, dst string, policy CollisionPolicy) (*MergeReport, error) {
+	report := &MergeReport{Skipped: []string{}, Overwritten: []string{}, Renamed: map[string]string{}}
+	from := p.withNamespace(src)
+	to := p.withNamespace(dst)
+	err := p.Store.Update(func(tx StoreTx) error {
+		if err := p.checkNamespaces(tx, src, dst); err != nil {
+			return err
+		}
+		for _, b := range namespaceBucketList(dst) {
+			if err := tx.CreateBucket(b); err != nil {
+				return err
+			}
+		}
+		incoming := Tasks{}
+		if err := tx.ForEach(from.bucket, nil, func(k, v []byte) error {
+			var t Task
+			if err := json.Unmarshal(v, &t); err != nil {
+				return fmt.Errorf("could not decode %s: %w", k, err)
+			}
+			incoming = append(incoming, &t)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		// Work out what happens to each collision before writing anything
+		merging := Tasks{}
+		historyFrom := map[*Task]string{}
+		for _, t := range incoming {
+			historyFrom[t] = t.ID
+			existing, err := to.findIn(tx, t.PluginID, t.ID)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				switch policy {
+				case CollisionSkip:
+					report.Skipped = append(report.Skipped, t.ID)
+					continue
+				case CollisionOverwrite:
+					if err := to.removeTask(tx, existing.DetectKeyPath()); err != nil {
+						return err
+					}
+					report.Overwritten = append(report.Overwritten, t.ID)
+				case CollisionNewID:
+					newID := uuid.New().String()
+					report.Renamed[t.ID] = newID
+					t.ID = newID
+				case CollisionFail, "":
+					return fmt.Errorf("%v: %w", t.ID, ErrNamespaceCollision)
+				default:
+					return fmt.Errorf("unknown collision policy: %v", policy)
+				}
+			}
+			merging = append(merging, t)
+		}
+
+		for _, t := range merging {
+			// Keep links between the merged tasks pointing at the right place
+			t.Parents = renameIDs(t.Parents, report.Renamed)
+			t.Children = renameIDs(t.Children, report.Renamed)
+			t.DependsOn = renameIDs(t.DependsOn, report.Renamed)
+			if err := tx.ForEach(from.historyBucket, historyPrefix(t.PluginID, historyFrom[t]), func(k, v []byte) error {
+				key := append(historyPrefix(t.PluginID, t.ID), k[len(historyPrefix(t.PluginID, historyFrom[t])):]...)
+				return tx.Put(to.historyBucket, key, copyBytes(v))
+			}); err != nil {
+				return err
+			}
+			if err := to.putTask(tx, *t); err != nil {
+				return err
+			}
+			if err := to.recordRevision(tx, OperationImport, nil, t); err != nil {
+				return err
+			}
+			report.Merged++
+		}
+		// Recurring tasks and templates already in dst are left alone
+		for _, b := range [][2][]byte{{from.recurBucket, to.recurBucket}, {from.templateBucket, to.templateBucket}} {
+			if err := tx.ForEach(b[0], nil, func(k, v []byte) error {
+				got, err := tx.Get(b[1], k)
+				if err != nil || got != nil {
+					return err
+				}
+				return tx.Put(b[1], copyBytes(k), copyBytes(v))
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// findIn returns the task with the given plugin and id in any state, or nil
+func (p *Poet) findIn(tx StoreTx, pluginID, id string) (*Task, error) {
+	if pluginID == "" {
+		pluginID = DefaultPluginID
+	}
+	for _, state := range p.Task.GetStatePaths() {
+		got, err := p.getTaskIn(tx, []byte(filepath.Join(state, pluginID, id)))
+		if err != nil || got != nil {
+			return got, err
+		}
+	}
+	return nil, nil
+}
+
+// renameIDs swaps out any ids that were renamed
+func renameIDs(ids []string, renamed map[string]string) []string {
+	if len(ids) == 0 {
+		return ids
+	}
+	ret := make([]string, len(ids))
+	for idx, id := range ids {
+		if newID, ok := renamed[id]; ok {
+			ret[idx] = newID
+		} else {
+			ret[idx] = id
+		}
+	}
+	return ret
+}
@@ -0,0 +1,166 @@
+package taskpoet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaces(t *testing.T) {
+	s := NewMemoryStore()
+	work, err := New(WithStore(s), WithNamespace("work"))
+	require.NoError(t, err)
+	home, err := New(WithStore(s), WithNamespace("home"))
+	require.NoError(t, err)
+
+	task, err := work.Task.Add(MustNewTask("work thing"))
+	require.NoError(t, err)
+	require.NoError(t, work.Task.Complete(task))
+	_, err = work.Task.Add(MustNewTask("another work thing"))
+	require.NoError(t, err)
+	_, err = home.Task.Add(MustNewTask("home thing"))
+	require.NoError(t, err)
+
+	got, err := work.Namespaces()
+	require.NoError(t, err)
+	require.Equal(t, []NamespaceSummary{
+		{Name: "home", Counts: map[string]int{"active": 1, "completed": 0, "deleted": 0}},
+		{Name: "work", Counts: map[string]int{"active": 1, "completed": 1, "deleted": 0}},
+	}, got)
+	require.Equal(t, 2, got[1].Total())
+}
+
+func TestCopyRenameDeleteNamespace(t *testing.T) {
+	s := NewMemoryStore()
+	p, err := New(WithStore(s), WithNamespace("work"))
+	require.NoError(t, err)
+	task, err := p.Task.Add(MustNewTask("work thing", WithTags([]string{"office"})))
+	require.NoError(t, err)
+
+	require.EqualError(t, p.CopyNamespace("nope", "other"), "namespace does not exist: nope")
+	require.EqualError(t, p.CopyNamespace("work", "work"), "source and destination namespaces must be different")
+	require.EqualError(t, p.CopyNamespace("work", "a/b"), "namespace cannot contain a slash (/)")
+	require.NoError(t, p.CopyNamespace("work", "backup"))
+	require.EqualError(t, p.CopyNamespace("work", "backup"), "namespace already exists: backup, try merging instead")
+
+	backup, err := New(WithStore(s), WithNamespace("backup"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(backup.MustList("/active")))
+	tagged, err := backup.Task.ListWithTag("/active", "office")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tagged))
+	revs, err := backup.History(*task)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(revs))
+
+	require.EqualError(t, p.RenameNamespace("work", "office"), "cannot rename the namespace in use: work")
+	require.NoError(t, p.RenameNamespace("backup", "archive"))
+	require.EqualError(t, p.DeleteNamespace("backup"), "namespace does not exist: backup")
+	require.EqualError(t, p.DeleteNamespace("work"), "cannot delete the namespace in use: work")
+	require.NoError(t, p.DeleteNamespace("archive"))
+
+	got, err := p.Namespaces()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+	require.Equal(t, "work", got[0].Name)
+}
+
+func TestMergeNamespace(t *testing.T) {
+	setup := func(t *testing.T) (*Poet, *Poet) {
+		s := NewMemoryStore()
+		work, err := New(WithStore(s), WithNamespace("work"))
+		require.NoError(t, err)
+		home, err := New(WithStore(s), WithNamespace("home"))
+		require.NoError(t, err)
+		require.NoError(t, work.Task.AddSet(Tasks{
+			MustNewTask("work version", WithID("shared")),
+			MustNewTask("only at work", WithID("work-only")),
+		}))
+		require.NoError(t, home.Task.AddSet(Tasks{
+			MustNewTask("home version", WithID("shared")),
+			MustNewTask("child of shared", WithID("home-child"), WithParents([]string{"shared"})),
+		}))
+		return work, home
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		work, _ := setup(t)
+		_, err := work.MergeNamespace("home", "work", CollisionFail)
+		require.ErrorIs(t, err, ErrNamespaceCollision)
+		require.Equal(t, 2, len(work.MustList("")))
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		work, _ := setup(t)
+		report, err := work.MergeNamespace("home", "work", CollisionSkip)
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Merged)
+		require.Equal(t, []string{"shared"}, report.Skipped)
+		got, err := work.Task.GetWithID("shared", "", "")
+		require.NoError(t, err)
+		require.Equal(t, "work version", got.Description)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		work, home := setup(t)
+		report, err := work.MergeNamespace("home", "work", CollisionOverwrite)
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Merged)
+		got, err := work.Task.GetWithID("shared", "", "")
+		require.NoError(t, err)
+		require.Equal(t, "home version", got.Description)
+		// Source is left alone
+		require.Equal(t, 2, len(home.MustList("")))
+	})
+
+	t.Run("new-id", func(t *testing.T) {
+		work, _ := setup(t)
+		report, err := work.MergeNamespace("home", "work", CollisionNewID)
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Merged)
+		newID := report.Renamed["shared"]
+		require.NotEmpty(t, newID)
+		require.Equal(t, 4, len(work.MustList("")))
+		got, err := work.Task.GetWithID(newID, "", "")
+		require.NoError(t, err)
+		require.Equal(t, "home version", got.Description)
+		// Links follow the new ID
+		child, err := work.Task.GetWithID("home-child", "", "")
+		require.NoError(t, err)
+		require.Equal(t, []string{newID}, child.Parents)
+		// And so does the history
+		revs, err := work.History(*got)
+		require.NoError(t, err)
+		require.Equal(t, []Operation{OperationAdd, OperationImport}, []Operation{revs[0].Operation, revs[1].Operation})
+	})
+
+	t.Run("workflow-states", func(t *testing.T) {
+		s := NewMemoryStore()
+		work, err := New(WithStore(s), WithNamespace("work"), WithWorkflowStates(testWorkflowStates()))
+		require.NoError(t, err)
+		home, err := New(WithStore(s), WithNamespace("home"), WithWorkflowStates(testWorkflowStates()))
+		require.NoError(t, err)
+		waiting, err := work.Task.Add(MustNewTask("waiting at work", WithID("shared")))
+		require.NoError(t, err)
+		require.NoError(t, work.Move(waiting, "waiting"))
+		_, err = home.Task.Add(MustNewTask("home version", WithID("shared")))
+		require.NoError(t, err)
+		_, err = home.Task.Add(MustNewTask("in progress at home", WithID("busy"), WithWorkflowState("in-progress")))
+		require.NoError(t, err)
+
+		// The copy in /waiting is a collision
+		_, err = work.MergeNamespace("home", "work", CollisionFail)
+		require.ErrorIs(t, err, ErrNamespaceCollision)
+
+		report, err := work.MergeNamespace("home", "work", CollisionSkip)
+		require.NoError(t, err)
+		require.Equal(t, []string{"shared"}, report.Skipped)
+		got, err := work.Task.GetWithID("shared", "", "")
+		require.NoError(t, err)
+		require.Equal(t, "waiting", got.State())
+		busy, err := work.Task.GetWithID("busy", "", "")
+		require.NoError(t, err)
+		require.Equal(t, "in-progress", busy.State())
+		require.Equal(t, work.curator.Weigh(*busy), busy.Urgency)
+	})
+}
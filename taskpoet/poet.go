@@ -4,12 +4,12 @@ Package taskpoet is the main worker library
 package taskpoet
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"reflect"
 	"regexp"
 	"strings"
 	"time"
@@ -19,7 +19,6 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/drewstinnett/taskpoet/themes"
 	"github.com/mitchellh/go-homedir"
-	bolt "go.etcd.io/bbolt"
 	"golang.org/x/term"
 )
 
@@ -50,9 +49,11 @@ func MustNew(options ...Option) *Poet {
 // New returns a new poet object and optional error
 func New(options ...Option) (*Poet, error) {
 	p := &Poet{
-		Namespace: "default",
-		dbPath:    path.Join(mustHomeDir(), ".taskpoet.db"),
-		curator:   NewCurator(),
+		Namespace:   "default",
+		dbPath:      path.Join(mustHomeDir(), ".taskpoet.db"),
+		curator:     NewCurator(),
+		autoMigrate: true,
+		journal:     &journalState{},
 	}
 	// Default to homedir database
 	for _, option := range options {
@@ -62,14 +63,23 @@ func New(options ...Option) (*Poet, error) {
 		}
 		opt(p)
 	}
+	WithStateUrgency(p.WorkflowStates.urgency())(p.curator)
+	// Filters and columns may use UDAs, so they are checked once every option
+	// is set
+	if err := p.validateContexts(); err != nil {
+		return nil, err
+	}
+	if err := p.validateReports(); err != nil {
+		return nil, err
+	}
 
-	// We may want to make this more flexible later
-	p.bucket = []byte(fmt.Sprintf("/%v/tasks", p.Namespace))
+	p.setBuckets()
 
-	var err error
-	p.DB, err = bolt.Open(p.dbPath, 0o600, nil)
-	if err != nil {
-		return nil, err
+	if p.Store == nil {
+		var err error
+		if p.Store, err = NewBoltStore(p.dbPath); err != nil {
+			return nil, err
+		}
 	}
 
 	p.Task = &TaskServiceOp{
@@ -81,6 +91,16 @@ func New(options ...Option) (*Poet, error) {
 		return nil, err
 	}
 
+	if p.autoMigrate {
+		report, err := p.Migrate(false)
+		if err != nil {
+			return nil, err
+		}
+		if report.From != report.To {
+			log.Debug("Migrated database schema", "from", report.From, "to", report.To)
+		}
+	}
+
 	// Open the db
 	return p, nil
 }
@@ -129,18 +149,32 @@ func WithRecurringTasks(r RecurringTasks) Option {
 
 // Poet is the main operator for this whole thing
 type Poet struct {
-	DB             *bolt.DB
+	Store          Store
 	Namespace      string
 	Default        Task
 	Task           TaskService
 	dbPath         string
 	RecurringTasks RecurringTasks
+	TaskTemplates  TaskTemplates
+	WorkflowStates WorkflowStates
+	UDAs           UDAs
+	Contexts       Contexts
+	Reports        Reports
 	bucket         []byte
+	indexBucket    []byte
+	historyBucket  []byte
+	journalBucket  []byte
+	recurBucket    []byte
+	templateBucket []byte
+	journal        *journalState
 	styling        themes.Styling
 	curator        *Curator
+	autoMigrate    bool
+	commentLimit   int
 }
 
 func (p Poet) refresh(ts Tasks) {
+	p.setDependencies(ts)
 	for _, task := range ts {
 		newW := p.curator.Weigh(*task)
 		if task.Urgency != newW {
@@ -148,22 +182,31 @@ func (p Poet) refresh(ts Tasks) {
 			task.Urgency = newW
 		}
 	}
+	p.SetShortIDs(ts...)
+}
+
+// setBuckets names the buckets for the namespace
+func (p *Poet) setBuckets() {
+	p.bucket, p.indexBucket, p.historyBucket, p.journalBucket, p.recurBucket, p.templateBucket = namespaceBuckets(p.Namespace)
 }
 
 // initDB initializes the database
 func (p *Poet) initDB() error {
-	// store some data
-	return p.DB.Update(func(tx *bolt.Tx) error {
-		bucket := p.getBucket(tx)
-		if bucket == nil {
-			if _, berr := tx.CreateBucket(p.bucket); berr != nil {
-				return berr
+	return p.Store.Update(func(tx StoreTx) error {
+		for _, b := range namespaceBucketList(p.Namespace) {
+			if err := tx.CreateBucket(b); err != nil {
+				return err
 			}
 		}
 		return nil
 	})
 }
 
+// Close closes the underlying store
+func (p *Poet) Close() error {
+	return p.Store.Close()
+}
+
 /*
 func dclose(c io.Closer) {
 	err := c.Close()
@@ -196,7 +239,10 @@ var docStyle = lipgloss.NewStyle().Padding(0).Margin(0)
 
 // TableOpts defines the data displayed in a table
 type TableOpts struct {
-	Prefix       string
+	Prefix string
+	// Prefixes lists several prefixes in one table, Prefix is used when it is
+	// empty
+	Prefixes     []string
 	FilterParams FilterParams
 	Filters      []Filter
 	Columns      []string
@@ -220,6 +266,7 @@ var columnMap map[string]func(Task) string = map[string]func(Task) string{
 	"Age": func(t Task) string {
 		return shortDuration(time.Since(t.Added))
 	},
+	// Shown with the comment limit of the Poet by columnValue
 	descriptionColumnName: func(t Task) string { return t.DescriptionDetails() },
 	"Due": func(t Task) string {
 		// return t.DueString()
@@ -228,25 +275,88 @@ var columnMap map[string]func(Task) string = map[string]func(Task) string{
 		}
 		return ""
 	},
-	"Tags": func(t Task) string { return strings.Join(t.Tags, ",") },
+	"Tags":    func(t Task) string { return strings.Join(t.Tags, ",") },
+	"Project": func(t Task) string { return t.Project },
+	"Blocked": func(t Task) string { return strings.Join(t.blockedBy, ",") },
+	"Active": func(t Task) string {
+		if !t.IsRunning() {
+			return ""
+		}
+		return clockDuration(t.Intervals[len(t.Intervals)-1].Duration())
+	},
+	"Estimate": func(t Task) string {
+		if t.Estimate == 0 {
+			return ""
+		}
+		return clockDuration(t.Estimate)
+	},
+	"Remaining": func(t Task) string {
+		if t.Estimate == 0 {
+			return ""
+		}
+		return clockDuration(t.Remaining())
+	},
+	"Spent": func(t Task) string {
+		if len(t.Intervals) == 0 {
+			return ""
+		}
+		return clockDuration(t.Spent())
+	},
 	"Completed": func(t Task) string {
 		if t.Completed == nil {
 			return ""
 		}
 		return t.Completed.Format("2006-01-02")
 	},
+	"Deleted": func(t Task) string {
+		if t.Deleted == nil {
+			return ""
+		}
+		return t.Deleted.Format("2006-01-02")
+	},
+	"Reviewed": func(t Task) string {
+		if t.Reviewed != nil {
+			return shortDuration(time.Since(*t.Reviewed))
+		}
+		return ""
+	},
+	"Reason": func(t Task) string { return t.DeletedReason },
+	"State":  func(t Task) string { return t.State() },
+	"Until": func(t Task) string {
+		if t.CancelAfter != nil {
+			return shortDuration(time.Since(*t.CancelAfter) * -1)
+		}
+		return ""
+	},
 }
 
-func columnValue(s string, t Task) (string, error) {
+func (p *Poet) columnValue(s string, t Task) (string, error) {
 	valF, ok := columnMap[s]
-	if !ok {
-		return "", fmt.Errorf("column not defined: %v", s)
+	switch {
+	case s == descriptionColumnName:
+		return p.DescriptionDetails(t), nil
+	case ok:
+		return valF(t), nil
+	}
+	if got, ok := p.UDAs.columnValue(s, t); ok {
+		return got, nil
 	}
-	return valF(t), nil
+	return "", fmt.Errorf("column not defined: %v", s)
 }
 
-func mustColumnValue(s string, t Task) string {
-	got, err := columnValue(s, t)
+// ValidateColumn makes sure a column can be shown in a table
+func (p *Poet) ValidateColumn(s string) error {
+	if _, ok := columnMap[s]; ok {
+		return nil
+	}
+	if _, ok := p.UDAs[s]; ok {
+		return nil
+	}
+	return fmt.Errorf("column not defined: %v", s)
+}
+
+func (p *Poet) mustColumnValue(s string, t Task) string {
+	got, err := p.columnValue(s, t)
 	if err != nil {
 		panic(err)
 	}
@@ -262,6 +372,7 @@ type taskTable struct {
 	columns []string
 	styling themes.Styling
 	tasks   Tasks
+	poet    *Poet
 }
 
 // Generate returns a real table from the struct
@@ -270,7 +381,7 @@ func (t taskTable) Generate() *table.Table {
 	for idx, task := range t.tasks {
 		row := make([]string, len(t.columns))
 		for idx, c := range t.columns {
-			row[idx] = mustColumnValue(c, *task)
+			row[idx] = t.poet.mustColumnValue(c, *task)
 		}
 		rows[idx] = row
 	}
@@ -324,18 +435,59 @@ func descDate(d time.Time) string {
 	return fmt.Sprintf("%v (%v)", d.Format("2006-01-02 15:4"), shortDuration(time.Since(d)*-1))
 }
 
-func descRows(t Task) [][]string {
+func descRows(t Task, udas UDAs) [][]string {
 	rows := [][]string{
 		{"ID", fmt.Sprintf("%v (%v)", t.ID, t.ShortID())},
-		{"Description", t.DescriptionDetails()},
+		{"Description", t.Description},
 		{"Added", descDate(t.Added)},
 	}
 	if t.Due != nil {
 		rows = append(rows, []string{"Due", descDate(*t.Due)})
 	}
+	if t.Reviewed != nil {
+		rows = append(rows, []string{"Reviewed", descDate(*t.Reviewed)})
+	}
+	if t.CancelAfter != nil {
+		rows = append(rows, []string{"Until", descDate(*t.CancelAfter)})
+	}
+	if t.WorkflowState != "" && t.IsOpen() {
+		rows = append(rows, []string{"State", t.WorkflowState})
+	}
+	if t.Deleted != nil {
+		deleted := descDate(*t.Deleted)
+		if t.DeletedReason != "" {
+			deleted += ", " + t.DeletedReason
+		}
+		rows = append(rows, []string{"Deleted", deleted})
+	}
+	if t.Project != "" {
+		rows = append(rows, []string{"Project", t.Project})
+	}
+	if t.RecurID != "" {
+		rows = append(rows, []string{"Recurs", t.RecurID})
+	}
+	if t.Estimate > 0 {
+		rows = append(rows, []string{"Estimate", fmt.Sprintf("%v (%v remaining)", clockDuration(t.Estimate), clockDuration(t.Remaining()))})
+	}
+	if len(t.Intervals) > 0 {
+		spent := clockDuration(t.Spent())
+		if t.IsRunning() {
+			spent += " (running)"
+		}
+		rows = append(rows, []string{"Spent", spent})
+	}
 	if len(t.Tags) > 0 {
 		rows = append(rows, []string{"Tags", strings.Join(t.Tags, ",")})
 	}
+	for _, name := range udas.Names() {
+		if value, ok := t.UDAs[name]; ok {
+			label := udas[name].Label
+			if label == "" {
+				label = name
+			}
+			rows = append(rows, []string{label, value})
+		}
+	}
 	rows = append(rows, []string{
 		"Urgency", fmt.Sprint(t.Urgency),
 	})
@@ -346,6 +498,12 @@ func descRows(t Task) [][]string {
 	if len(t.Children) > 0 {
 		rows = append(rows, []string{"Children", strings.Join(t.Children, ",")})
 	}
+	if len(t.DependsOn) > 0 {
+		rows = append(rows, []string{"Depends On", strings.Join(t.DependsOn, ",")})
+	}
+	if t.IsBlocked() {
+		rows = append(rows, []string{"Blocked By", strings.Join(t.blockedBy, ",")})
+	}
 	return rows
 }
 
@@ -353,7 +511,7 @@ func descRows(t Task) [][]string {
 func (p *Poet) DescribeTask(t Task) string {
 	p.refresh(Tasks{&t})
 	doc := strings.Builder{}
-	rows := descRows(t)
+	rows := descRows(t, p.UDAs)
 	doc.WriteString(table.New().
 		Border(lipgloss.HiddenBorder()).
 		BorderStyle(lipgloss.NewStyle()).
@@ -403,16 +561,60 @@ func (p *Poet) DescribeTask(t Task) string {
 
 	w, _, _ := term.GetSize(int(os.Stdout.Fd()))
 	maxW := min(w, 180)
+	if len(t.Comments) > 0 {
+		doc.WriteString("\n  Comments\n")
+		comments, err := renderComments(t.Comments, commentWidth(maxW))
+		if err != nil {
+			// Fall back to the plain text rather than losing the comments
+			comments = plainComments(t.Comments)
+		}
+		doc.WriteString(comments)
+	}
 	docStyle = docStyle.MaxWidth(maxW)
 	return docStyle.Render(doc.String())
 }
 
+// listTable renders simple rows with the current styling
+func (p *Poet) listTable(headers []string, rows [][]string) string {
+	doc := strings.Builder{}
+	doc.WriteString(table.New().
+		Border(lipgloss.HiddenBorder()).
+		BorderStyle(lipgloss.NewStyle()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return p.styling.RowHeader
+			}
+			even := row%2 == 0
+			rowStyle := p.styling.Row
+			if even {
+				rowStyle = p.styling.RowAlt
+			}
+			return rowStyle
+		}).
+		Headers(headers...).
+		Rows(rows...).Render())
+	return docStyle.Render(doc.String())
+}
+
 // TaskTable returns a table of the given tasks
 func (p *Poet) TaskTable(opts TableOpts) string {
 	p.checkRecurring()
-	tasks := ApplyFilters(p.MustList(opts.Prefix), &opts.FilterParams, opts.Filters...)
-
+	p.checkExpired()
+	prefixes := opts.Prefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{opts.Prefix}
+	}
+	context, contextQuery := p.contextQuery()
+	tasks, err := p.listIndexed(prefixes, tableLookup(opts, contextQuery))
+	if err != nil {
+		panic(err)
+	}
+	// Filters may need to know what is blocked, and the current urgency
 	p.refresh(tasks)
+	tasks = ApplyFilters(tasks, &opts.FilterParams, opts.Filters...)
+	if contextQuery != nil {
+		tasks = ApplyFilters(tasks, &FilterParams{Query: contextQuery}, FilterQuery)
+	}
 
 	allTasksLen := len(tasks)
 
@@ -428,7 +630,7 @@ func (p *Poet) TaskTable(opts TableOpts) string {
 	for iidx, task := range tasks {
 		row := make([]string, len(opts.Columns))
 		for idx, c := range opts.Columns {
-			row[idx] = mustColumnValue(c, *task)
+			row[idx] = p.mustColumnValue(c, *task)
 		}
 		rows[iidx] = row
 	}
@@ -437,11 +639,13 @@ func (p *Poet) TaskTable(opts TableOpts) string {
 		tasks:   tasks,
 		columns: opts.Columns,
 		styling: p.styling,
+		poet:    p,
 	}.Generate().Render()
 
 	doc.WriteString(tr)
 	width := lipgloss.Width(tr)
 	addLimitWarning(&doc, width-4, opts.FilterParams.Limit, allTasksLen)
+	addContextFooter(&doc, width-4, context)
 
 	w, _, _ := term.GetSize(int(os.Stdout.Fd()))
 	maxW := min(w, width)
@@ -479,8 +683,40 @@ func FilterRegex(p *FilterParams, task Task) bool {
 
 // FilterParams are options for filtering tasks
 type FilterParams struct {
-	Regex *regexp.Regexp
-	Limit int
+	Regex   *regexp.Regexp
+	Limit   int
+	Project string
+	UDAs    map[string]string
+	Blocked *bool
+	// StaleAfter keeps tasks not reviewed in this long, when set
+	StaleAfter time.Duration
+	// States keeps tasks in any of these states, when set
+	States []string
+	// Query keeps tasks matching the query, when set
+	Query *TaskQuery
+}
+
+// hasFilter is true when f is one of the filters
+func hasFilter(filters []Filter, f Filter) bool {
+	want := reflect.ValueOf(f).Pointer()
+	for _, got := range filters {
+		if reflect.ValueOf(got).Pointer() == want {
+			return true
+		}
+	}
+	return false
+}
+
+// tableLookup picks an index to find the tasks a table could show, using only
+// the filters that are sure to be applied
+func tableLookup(opts TableOpts, context *TaskQuery) *indexLookup {
+	if opts.FilterParams.Project != "" && hasFilter(opts.Filters, FilterProject) {
+		return projectLookup(opts.FilterParams.Project)
+	}
+	if l := opts.FilterParams.Query.indexLookup(); l != nil && hasFilter(opts.Filters, FilterQuery) {
+		return l
+	}
+	return context.indexLookup()
 }
 
 // ApplyFilters applies a set of filters to a task list.
@@ -553,6 +789,21 @@ func (a ByCompleted) Less(i, j int) bool {
 }
 func (a ByCompleted) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 
+// ByDeleted is the by deleted date sorter
+type ByDeleted Tasks
+
+func (a ByDeleted) Len() int { return len(a) }
+func (a ByDeleted) Less(i, j int) bool {
+	if a[i].Deleted == nil {
+		return false
+	}
+	if a[j].Deleted == nil {
+		return true
+	}
+	return a[j].Deleted.Before(*a[i].Deleted)
+}
+func (a ByDeleted) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
 func (p *Poet) exists(t *Task) bool {
 	_, err := p.Task.GetWithID(t.ID, t.PluginID, "")
 	return err == nil
@@ -562,23 +813,21 @@ func (p *Poet) exists(t *Task) bool {
 func (p *Poet) Delete(t *Task) error {
 	curPath := t.DetectKeyPath()
 	t.Deleted = nowPTR()
-	newPath := t.DetectKeyPath()
-	if err := p.DB.Update(func(tx *bolt.Tx) error {
-		taskSerial, err := json.Marshal(t)
-		if err != nil {
-			return err
+	t.stopTimer(*t.Deleted)
+	if err := p.update(OperationDelete, func(tx StoreTx) error {
+		before, gerr := p.getTaskIn(tx, curPath)
+		if gerr != nil {
+			return gerr
 		}
-		b := tx.Bucket(p.bucket)
-		if perr := b.Put(newPath, taskSerial); perr != nil {
+		if rerr := p.removeTask(tx, curPath); rerr != nil {
+			return rerr
+		}
+		if perr := p.putTask(tx, *t); perr != nil {
 			return perr
 		}
-		return b.Delete(curPath)
+		return p.recordRevision(tx, OperationDelete, before, t)
 	}); err != nil {
 		return err
 	}
 	return nil
 }
-
-func (p Poet) getBucket(tx *bolt.Tx) *bolt.Bucket {
-	return tx.Bucket(p.bucket)
-}
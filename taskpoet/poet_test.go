@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
-	bolt "go.etcd.io/bbolt"
 )
 
 func mustTempDB(t *testing.T) string {
@@ -20,8 +19,10 @@ func TestInitDB(t *testing.T) {
 
 	lc, err := New(WithDatabasePath(tmpfile.Name()))
 	require.NoError(t, err)
-	err = lc.DB.View(func(tx *bolt.Tx) error {
-		require.NotNil(t, lc.getBucket(tx))
+	err = lc.Store.View(func(tx StoreTx) error {
+		buckets, berr := tx.Buckets()
+		require.NoError(t, berr)
+		require.Contains(t, buckets, lc.bucket)
 		return nil
 	})
 	require.NoError(t, err)
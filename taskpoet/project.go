@@ -0,0 +1,129 @@
+package taskpoet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+Projects are dotted paths, like work.infra.dns. A task in work.infra.dns is
+also counted as part of work.infra and work.
+*/
+
+// projectSeparator splits a project in to its parents
+const projectSeparator = "."
+
+// WithProject sets the project on create
+func WithProject(p string) TaskOption {
+	return func(t *Task) {
+		t.Project = p
+	}
+}
+
+// ValidateProject makes sure a project name has no empty pieces
+func ValidateProject(project string) error {
+	if project == "" {
+		return nil
+	}
+	for _, piece := range strings.Split(project, projectSeparator) {
+		if strings.TrimSpace(piece) == "" {
+			return fmt.Errorf("invalid project %q, it cannot have an empty part", project)
+		}
+	}
+	return nil
+}
+
+// InProject is true when project is parent, or one of its subprojects
+func InProject(project, parent string) bool {
+	return project == parent || strings.HasPrefix(project, parent+projectSeparator)
+}
+
+// projectAncestors returns the project and each of its parents, shortest first
+func projectAncestors(project string) []string {
+	pieces := strings.Split(project, projectSeparator)
+	ret := make([]string, len(pieces))
+	for idx := range pieces {
+		ret[idx] = strings.Join(pieces[0:idx+1], projectSeparator)
+	}
+	return ret
+}
+
+// FilterProject keeps the tasks in the FilterParams project, and its
+// subprojects
+func FilterProject(p *FilterParams, task Task) bool {
+	if p.Project == "" {
+		return true
+	}
+	return InProject(task.Project, p.Project)
+}
+
+// ProjectSummary is how far along a project is. Counts include subprojects
+type ProjectSummary struct {
+	Name      string
+	Active    int
+	Completed int
+}
+
+// PercentComplete is the percentage of tasks that are completed
+func (s ProjectSummary) PercentComplete() float64 {
+	total := s.Active + s.Completed
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Completed) / float64(total) * 100
+}
+
+// Projects summarizes every project with active or completed tasks, sorted by
+// name so subprojects follow their parents
+func (p *Poet) Projects() ([]ProjectSummary, error) {
+	summaries := map[string]*ProjectSummary{}
+	for _, state := range append(p.OpenStatePaths(), "/completed") {
+		tasks, err := p.Task.List(state)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			if t.Project == "" {
+				continue
+			}
+			for _, project := range projectAncestors(t.Project) {
+				s, ok := summaries[project]
+				if !ok {
+					s = &ProjectSummary{Name: project}
+					summaries[project] = s
+				}
+				if t.Completed == nil {
+					s.Active++
+				} else {
+					s.Completed++
+				}
+			}
+		}
+	}
+	ret := make([]ProjectSummary, 0, len(summaries))
+	for _, s := range summaries {
+		ret = append(ret, *s)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// ProjectsTable renders project summaries, with subprojects indented under
+// their parents
+func (p *Poet) ProjectsTable(summaries []ProjectSummary) string {
+	rows := make([][]string, len(summaries))
+	for idx, s := range summaries {
+		depth := strings.Count(s.Name, projectSeparator)
+		name := s.Name[strings.LastIndex(s.Name, projectSeparator)+1:]
+		rows[idx] = []string{
+			strings.Repeat("  ", depth) + name,
+			fmt.Sprint(s.Active),
+			fmt.Sprint(s.Completed),
+			fmt.Sprintf("%.0f%%", s.PercentComplete()),
+		}
+	}
+	return p.listTable([]string{"Project", "Active", "Completed", "Complete"}, rows)
+}
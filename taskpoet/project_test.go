@@ -0,0 +1,90 @@
+package taskpoet
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInProject(t *testing.T) {
+	require.True(t, InProject("work", "work"))
+	require.True(t, InProject("work.infra.dns", "work"))
+	require.True(t, InProject("work.infra.dns", "work.infra"))
+	require.False(t, InProject("workshop", "work"))
+	require.False(t, InProject("work", "work.infra"))
+	require.False(t, InProject("", "work"))
+}
+
+func TestValidateProject(t *testing.T) {
+	require.NoError(t, ValidateProject(""))
+	require.NoError(t, ValidateProject("work.infra"))
+	require.EqualError(t, ValidateProject("work..infra"), `invalid project "work..infra", it cannot have an empty part`)
+	require.Error(t, ValidateProject(".work"))
+	require.Error(t, ValidateProject("work."))
+
+	_, err := NewTask("bad", WithProject("work."))
+	require.Error(t, err)
+}
+
+func TestProjects(t *testing.T) {
+	p := newTestPoet(t)
+	for _, item := range []struct {
+		id, project string
+		complete    bool
+	}{
+		{"dns", "work.infra.dns", true},
+		{"dns2", "work.infra.dns", false},
+		{"mail", "work.infra.mail", false},
+		{"lunch", "home", true},
+		{"none", "", false},
+	} {
+		task, err := p.Task.Add(MustNewTask(item.id, WithID(item.id), WithProject(item.project)))
+		require.NoError(t, err)
+		if item.complete {
+			require.NoError(t, p.Task.Complete(task))
+		}
+	}
+
+	got, err := p.Projects()
+	require.NoError(t, err)
+	require.Equal(t, []ProjectSummary{
+		{Name: "home", Completed: 1},
+		{Name: "work", Active: 2, Completed: 1},
+		{Name: "work.infra", Active: 2, Completed: 1},
+		{Name: "work.infra.dns", Active: 1, Completed: 1},
+		{Name: "work.infra.mail", Active: 1},
+	}, got)
+	require.Equal(t, float64(100), got[0].PercentComplete())
+	require.Equal(t, float64(50), got[3].PercentComplete())
+	require.Equal(t, float64(0), ProjectSummary{}.PercentComplete())
+	require.Contains(t, p.ProjectsTable(got), "    dns")
+}
+
+func TestFilterProject(t *testing.T) {
+	p := newTestPoet(t)
+	_, err := p.Task.Add(MustNewTask("dns", WithProject("work.infra.dns")))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("lunch", WithProject("home")))
+	require.NoError(t, err)
+
+	got := p.TaskTable(TableOpts{
+		Prefix:       "/active",
+		Columns:      []string{"Description", "Project"},
+		FilterParams: FilterParams{Project: "work", Regex: regexp.MustCompile(".*")},
+		Filters:      []Filter{FilterRegex, FilterProject},
+	})
+	require.Contains(t, got, "work.infra.dns")
+	require.NotContains(t, got, "lunch")
+}
+
+func TestImportTaskWarriorProject(t *testing.T) {
+	p := newTestPoet(t)
+	_, err := p.ImportTaskWarrior(TaskWarriorTasks{
+		{Description: "dns", UUID: "dns", Status: "pending", Project: "work.infra"},
+	}, nil)
+	require.NoError(t, err)
+	got, err := p.Task.GetWithID("dns", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, "work.infra", got.Project)
+}
@@ -0,0 +1,678 @@
+package taskpoet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Queries pick out tasks with an expression, like:
+
+	+urgent -someday project:work.infra due.before:eow ei:1 urgency>5 desc~"dns"
+
+Terms next to each other must all match, and can be combined with and, or,
+not and parentheses:
+
+	(project:work or +oncall) and not state:waiting
+
+A term is one of:
+
+	+tag, -tag            has, or doesn't have, a tag
+	word                  the description matches word, as a case-insensitive regex
+	"some words"          same as above, with spaces
+	field:value           see below
+	field<value           also <=, >, >=, = and != for numbers and dates
+	desc~regex            the description matches a case-insensitive regex
+
+Fields are description (desc), project, tag, state, id, ei (effort-impact),
+urgency, blocked, any registered UDA, and the dates due, wait, until, added,
+completed, reviewed and deleted. Dates take a synonym like eow, a duration like
+3d, or a date like 2024-01-31, and can be compared with due.before:eow,
+due.after:today, due<1w, or checked with due:none and due:any. Durations count
+forward for due, wait and until, and back for the rest, so added.after:1w is
+everything added in the last week.
+*/
+
+// TaskQuery is a parsed filter expression. The zero value, and a nil TaskQuery, match
+// everything
+type TaskQuery struct {
+	raw  string
+	root queryNode
+}
+
+// QueryError is a query that could not be parsed, with where it went wrong
+type QueryError struct {
+	Query string
+	// Pos is the byte offset in Query the problem starts at
+	Pos int
+	Msg string
+}
+
+// Error shows the problem along with the part of the query it is in
+func (e *QueryError) Error() string {
+	near := e.Query[min(e.Pos, len(e.Query)):]
+	if near == "" {
+		return fmt.Sprintf("invalid query at column %v: %v", e.Pos+1, e.Msg)
+	}
+	return fmt.Sprintf("invalid query at column %v: %v, near %q", e.Pos+1, e.Msg, near)
+}
+
+type queryNode interface {
+	match(t Task) bool
+}
+
+type (
+	queryAnd   struct{ left, right queryNode }
+	queryOr    struct{ left, right queryNode }
+	queryNot   struct{ node queryNode }
+	queryMatch func(t Task) bool
+	// queryIndexed is a term whose matches can all be found with an index
+	queryIndexed struct {
+		queryMatch
+		lookup *indexLookup
+	}
+)
+
+func (n queryAnd) match(t Task) bool   { return n.left.match(t) && n.right.match(t) }
+func (n queryOr) match(t Task) bool    { return n.left.match(t) || n.right.match(t) }
+func (n queryNot) match(t Task) bool   { return !n.node.match(t) }
+func (f queryMatch) match(t Task) bool { return f(t) }
+
+// nodeLookup returns a lookup that finds every task the node could match, or
+// nil when the node needs every task
+func nodeLookup(n queryNode) *indexLookup {
+	switch n := n.(type) {
+	case queryIndexed:
+		return n.lookup
+	case queryAnd:
+		if l := nodeLookup(n.left); l != nil {
+			return l
+		}
+		return nodeLookup(n.right)
+	}
+	return nil
+}
+
+// ParseQuery parses a query without any UDAs. Dates in it are worked out from
+// the present of a calendar with the given options
+func ParseQuery(s string, options ...func(*Calendar)) (*TaskQuery, error) {
+	return parseQuery(s, nil, options...)
+}
+
+// ParseQuery parses a query that may use the UDAs of the Poet
+func (p *Poet) ParseQuery(s string, options ...func(*Calendar)) (*TaskQuery, error) {
+	return parseQuery(s, p.UDAs, options...)
+}
+
+func parseQuery(s string, udas UDAs, options ...func(*Calendar)) (*TaskQuery, error) {
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{raw: s, tokens: tokens, cal: NewCalendar(options...), udas: udas}
+	q := &TaskQuery{raw: s}
+	if len(tokens) == 0 {
+		return q, nil
+	}
+	if q.root, err = p.parseOr(); err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok.pos, "unexpected %q", tok.text)
+	}
+	return q, nil
+}
+
+// MustParseQuery parses a query or panics
+func MustParseQuery(s string, options ...func(*Calendar)) *TaskQuery {
+	q, err := ParseQuery(s, options...)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the query as it was given
+func (q *TaskQuery) String() string {
+	if q == nil {
+		return ""
+	}
+	return q.raw
+}
+
+// Match is true when the task matches the query
+func (q *TaskQuery) Match(t Task) bool {
+	if q == nil || q.root == nil {
+		return true
+	}
+	return q.root.match(t)
+}
+
+// indexLookup returns a lookup for the tasks the query could match, when an
+// index can find them
+func (q *TaskQuery) indexLookup() *indexLookup {
+	if q == nil || q.root == nil {
+		return nil
+	}
+	return nodeLookup(q.root)
+}
+
+// Filter returns the query as a Filter
+func (q *TaskQuery) Filter() Filter {
+	return func(_ *FilterParams, t Task) bool {
+		return q.Match(t)
+	}
+}
+
+// ListQuery returns the open tasks matching the query, with their urgency
+// and dependencies worked out
+func (p *Poet) ListQuery(q *TaskQuery) (Tasks, error) {
+	return p.listQuery(q, p.OpenStatePaths())
+}
+
+func (p *Poet) listQuery(q *TaskQuery, prefixes []string) (Tasks, error) {
+	tasks, err := p.listIndexed(prefixes, q.indexLookup())
+	if err != nil {
+		return nil, err
+	}
+	p.refresh(tasks)
+	return ApplyFilters(tasks, &FilterParams{Query: q}, FilterQuery), nil
+}
+
+// FilterQuery keeps the tasks matching FilterParams.Query, when it is set
+func FilterQuery(p *FilterParams, task Task) bool {
+	return p.Query.Match(task)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenTerm
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	kind tokenKind
+	// text is the token as written, value has the quotes taken off
+	text   string
+	value  string
+	quoted bool
+	pos    int
+}
+
+// lexQuery splits a query in to tokens. Terms run until whitespace or a
+// parenthesis, unless they are inside of double quotes
+func lexQuery(s string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose, text: ")", pos: i})
+			i++
+		default:
+			tok, next, err := lexTerm(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+func lexTerm(s string, start int) (queryToken, int, error) {
+	value := strings.Builder{}
+	tok := queryToken{kind: tokenTerm, pos: start}
+	i := start
+	for i < len(s) {
+		c := s[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '(' || c == ')' {
+			break
+		}
+		if c != '"' {
+			value.WriteByte(c)
+			i++
+			continue
+		}
+		// A quoted section, where \" is a literal quote
+		tok.quoted = true
+		quote := i
+		i++
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) && s[i+1] == '"' {
+				i++
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return tok, 0, &QueryError{Query: s, Pos: quote, Msg: "missing closing quote"}
+		}
+		i++
+	}
+	tok.text, tok.value = s[start:i], value.String()
+	if !tok.quoted {
+		switch strings.ToLower(tok.value) {
+		case "and":
+			tok.kind = tokenAnd
+		case "or":
+			tok.kind = tokenOr
+		case "not":
+			tok.kind = tokenNot
+		}
+	}
+	return tok, i, nil
+}
+
+type queryParser struct {
+	raw    string
+	tokens []queryToken
+	idx    int
+	cal    *Calendar
+	udas   UDAs
+}
+
+func (p *queryParser) peek() queryToken {
+	if p.idx >= len(p.tokens) {
+		return queryToken{kind: tokenEOF, pos: len(p.raw)}
+	}
+	return p.tokens[p.idx]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.peek()
+	p.idx++
+	return tok
+}
+
+func (p *queryParser) errorf(pos int, format string, a ...any) error {
+	return &QueryError{Query: p.raw, Pos: pos, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryOr{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd handles an explicit and, as well as terms that are just next to
+// each other
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenTerm, tokenNot, tokenOpen:
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = queryAnd{left, right}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenClose {
+			return nil, p.errorf(tok.pos, "missing closing parenthesis")
+		}
+		p.next()
+		return node, nil
+	case tokenTerm:
+		return p.term(tok)
+	case tokenEOF:
+		return nil, p.errorf(tok.pos, "expected a term at the end of the query")
+	default:
+		return nil, p.errorf(tok.pos, "expected a term, not %q", tok.text)
+	}
+}
+
+var queryFieldRe = regexp.MustCompile(`^([a-zA-Z][\w.-]*)(>=|<=|!=|:|~|>|<|=)(.*)$`)
+
+// term compiles a single term
+func (p *queryParser) term(tok queryToken) (queryNode, error) {
+	v := tok.value
+	if !strings.HasPrefix(tok.text, `"`) {
+		switch {
+		case len(v) > 1 && v[0] == '+':
+			return hasTag(v[1:]), nil
+		case len(v) > 1 && v[0] == '-':
+			return queryNot{hasTag(v[1:])}, nil
+		}
+		if m := queryFieldRe.FindStringSubmatch(v); m != nil {
+			return p.field(tok, strings.ToLower(m[1]), m[2], m[3])
+		}
+	}
+	return p.descRegex(tok, v)
+}
+
+func hasTag(tag string) queryIndexed {
+	return queryIndexed{
+		queryMatch: func(t Task) bool { return containsString(t.Tags, tag) },
+		lookup:     &indexLookup{index: IndexTag, value: tag},
+	}
+}
+
+func (p *queryParser) descRegex(tok queryToken, expr string) (queryNode, error) {
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return nil, p.errorf(tok.pos, "invalid regex: %v", err)
+	}
+	return queryMatch(func(t Task) bool {
+		return re.MatchString(t.Description)
+	}), nil
+}
+
+// queryDateFields gets the date of each date field, and whether durations
+// count back in to the past for it
+var queryDateFields = map[string]struct {
+	get  func(Task) *time.Time
+	past bool
+}{
+	"due":       {func(t Task) *time.Time { return t.Due }, false},
+	"wait":      {func(t Task) *time.Time { return t.HideUntil }, false},
+	"until":     {func(t Task) *time.Time { return t.CancelAfter }, false},
+	"added":     {func(t Task) *time.Time { return &t.Added }, true},
+	"completed": {func(t Task) *time.Time { return t.Completed }, true},
+	"reviewed":  {func(t Task) *time.Time { return t.Reviewed }, true},
+	"deleted":   {func(t Task) *time.Time { return t.Deleted }, true},
+}
+
+var queryFieldAliases = map[string]string{
+	"desc":          "description",
+	"proj":          "project",
+	"tags":          "tag",
+	"status":        "state",
+	"effort-impact": "ei",
+	"effort_impact": "ei",
+	"hide_until":    "wait",
+	"entry":         "added",
+	"end":           "completed",
+}
+
+// queryFields are the built in fields that aren't dates
+var queryFields = []string{"description", "project", "tag", "state", "id", "blocked", "ei", "urgency"}
+
+// isQueryField is true when the parser reads name as a built in field
+func isQueryField(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := queryFieldAliases[name]; ok {
+		return true
+	}
+	if _, ok := queryDateFields[name]; ok {
+		return true
+	}
+	return containsString(queryFields, name)
+}
+
+// field compiles a field:value style term
+func (p *queryParser) field(tok queryToken, name, op, value string) (queryNode, error) {
+	name, modifier, _ := strings.Cut(name, ".")
+	if alias, ok := queryFieldAliases[name]; ok {
+		name = alias
+	}
+	if modifier != "" {
+		if _, ok := queryDateFields[name]; !ok {
+			return nil, p.errorf(tok.pos, "only dates take a modifier like .before, not %v", name)
+		}
+	}
+	badOp := func() error {
+		return p.errorf(tok.pos, "%v can't be used with %v", op, name)
+	}
+	switch name {
+	case "description":
+		lower := strings.ToLower(value)
+		switch op {
+		case ":":
+			return queryMatch(func(t Task) bool { return strings.Contains(strings.ToLower(t.Description), lower) }), nil
+		case "=":
+			return queryMatch(func(t Task) bool { return strings.EqualFold(t.Description, value) }), nil
+		case "!=":
+			return queryMatch(func(t Task) bool { return !strings.EqualFold(t.Description, value) }), nil
+		case "~":
+			return p.descRegex(tok, value)
+		}
+		return nil, badOp()
+	case "project":
+		switch op {
+		case ":":
+			if value == "" {
+				return queryMatch(func(t Task) bool { return t.Project == "" }), nil
+			}
+			return queryIndexed{
+				queryMatch: func(t Task) bool { return InProject(t.Project, value) },
+				lookup:     projectLookup(value),
+			}, nil
+		case "=":
+			match := queryMatch(func(t Task) bool { return t.Project == value })
+			if value == "" {
+				return match, nil
+			}
+			return queryIndexed{queryMatch: match, lookup: &indexLookup{index: IndexProject, value: value}}, nil
+		case "!=":
+			return queryMatch(func(t Task) bool { return !InProject(t.Project, value) }), nil
+		}
+		return nil, badOp()
+	case "tag":
+		switch op {
+		case ":", "=":
+			return hasTag(value), nil
+		case "!=":
+			return queryNot{hasTag(value)}, nil
+		}
+		return nil, badOp()
+	case "state":
+		switch op {
+		case ":", "=":
+			return queryMatch(func(t Task) bool { return t.State() == value }), nil
+		case "!=":
+			return queryMatch(func(t Task) bool { return t.State() != value }), nil
+		}
+		return nil, badOp()
+	case "id":
+		if op != ":" && op != "=" {
+			return nil, badOp()
+		}
+		return queryMatch(func(t Task) bool { return strings.HasPrefix(t.ID, value) }), nil
+	case "blocked":
+		want, err := strconv.ParseBool(value)
+		if err != nil || (op != ":" && op != "=") {
+			return nil, p.errorf(tok.pos, "blocked is either blocked:true or blocked:false")
+		}
+		return queryMatch(func(t Task) bool { return t.IsBlocked() == want }), nil
+	case "ei":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < int(EffortImpactUnset) || n > int(EffortImpactAvoid) {
+			return nil, p.errorf(tok.pos, "effort/impact is a number from %v to %v", int(EffortImpactUnset), int(EffortImpactAvoid))
+		}
+		return p.number(tok, op, float64(n), func(t Task) float64 { return float64(t.EffortImpact) })
+	case "urgency":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, p.errorf(tok.pos, "urgency must be a number, not %q", value)
+		}
+		return p.number(tok, op, n, func(t Task) float64 { return t.Urgency })
+	}
+	if _, ok := queryDateFields[name]; ok {
+		return p.date(tok, name, modifier, op, value)
+	}
+	if _, ok := p.udas[name]; ok {
+		return p.uda(tok, name, op, value)
+	}
+	return nil, p.errorf(tok.pos, "unknown field %q, put quotes around it to search descriptions", name)
+}
+
+func (p *queryParser) number(tok queryToken, op string, want float64, get func(Task) float64) (queryNode, error) {
+	var cmp func(a, b float64) bool
+	switch op {
+	case ":", "=":
+		cmp = func(a, b float64) bool { return a == b }
+	case "!=":
+		cmp = func(a, b float64) bool { return a != b }
+	case "<":
+		cmp = func(a, b float64) bool { return a < b }
+	case "<=":
+		cmp = func(a, b float64) bool { return a <= b }
+	case ">":
+		cmp = func(a, b float64) bool { return a > b }
+	case ">=":
+		cmp = func(a, b float64) bool { return a >= b }
+	default:
+		return nil, p.errorf(tok.pos, "%v can't be used with numbers", op)
+	}
+	return queryMatch(func(t Task) bool { return cmp(get(t), want) }), nil
+}
+
+func (p *queryParser) date(tok queryToken, name, modifier, op, value string) (queryNode, error) {
+	field := queryDateFields[name]
+	if modifier == "" && op == ":" {
+		switch strings.ToLower(value) {
+		case "none", "":
+			return queryMatch(func(t Task) bool { return field.get(t) == nil }), nil
+		case "any":
+			match := queryMatch(func(t Task) bool { return field.get(t) != nil })
+			if name != "due" {
+				return match, nil
+			}
+			return queryIndexed{queryMatch: match, lookup: &indexLookup{index: IndexDue, keep: func(string) bool { return true }}}, nil
+		}
+	}
+	switch {
+	case modifier == "before" && op == ":":
+		op = "<"
+	case modifier == "after" && op == ":":
+		op = ">"
+	case modifier != "":
+		return nil, p.errorf(tok.pos, "dates take .before: or .after:, not .%v%v", modifier, op)
+	}
+	when, err := p.parseDate(value, field.past)
+	if err != nil {
+		return nil, p.errorf(tok.pos, "invalid date %q for %v", value, name)
+	}
+	var cmp func(d time.Time) bool
+	switch op {
+	case ":", "=":
+		day := floorDay(when)
+		cmp = func(d time.Time) bool { return floorDay(d).Equal(day) }
+	case "!=":
+		day := floorDay(when)
+		cmp = func(d time.Time) bool { return !floorDay(d).Equal(day) }
+	case "<":
+		cmp = func(d time.Time) bool { return d.Before(when) }
+	case "<=":
+		cmp = func(d time.Time) bool { return !d.After(when) }
+	case ">":
+		cmp = func(d time.Time) bool { return d.After(when) }
+	case ">=":
+		cmp = func(d time.Time) bool { return !d.Before(when) }
+	default:
+		return nil, p.errorf(tok.pos, "%v can't be used with dates", op)
+	}
+	match := queryMatch(func(t Task) bool {
+		d := field.get(t)
+		return d != nil && cmp(*d)
+	})
+	if l := dueLookup(op, when); name == "due" && l != nil {
+		return queryIndexed{queryMatch: match, lookup: l}, nil
+	}
+	return match, nil
+}
+
+// dueLookup finds the tasks due on the days that could match a comparison
+// with when. The index is by local day, so a day either side is included in
+// case when or the due dates are in another timezone
+func dueLookup(op string, when time.Time) *indexLookup {
+	before := when.AddDate(0, 0, -1).Local().Format(indexDayLayout)
+	after := when.AddDate(0, 0, 1).Local().Format(indexDayLayout)
+	var keep func(day string) bool
+	switch op {
+	case ":", "=":
+		keep = func(day string) bool { return day >= before && day <= after }
+	case "<", "<=":
+		keep = func(day string) bool { return day <= after }
+	case ">", ">=":
+		keep = func(day string) bool { return day >= before }
+	default:
+		return nil
+	}
+	return &indexLookup{index: IndexDue, keep: keep}
+}
+
+// parseDate takes a date like 2024-01-31, or anything Calendar understands
+func (p *queryParser) parseDate(s string, past bool) (time.Time, error) {
+	if d, err := time.ParseInLocation("2006-01-02", s, p.cal.present.Location()); err == nil {
+		return d, nil
+	}
+	var got *time.Time
+	var err error
+	if past {
+		got, err = p.cal.Past(s)
+	} else {
+		got, err = p.cal.Date(s)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return *got, nil
+}
+
+func (p *queryParser) uda(tok queryToken, name, op, value string) (queryNode, error) {
+	if normalized, err := p.udas.Normalize(name, value); err == nil {
+		value = normalized
+	}
+	switch op {
+	case ":", "=":
+		return queryMatch(func(t Task) bool { return t.UDAs[name] == value }), nil
+	case "!=":
+		return queryMatch(func(t Task) bool { return t.UDAs[name] != value }), nil
+	}
+	return nil, p.errorf(tok.pos, "%v can't be used with %v", op, name)
+}
@@ -0,0 +1,151 @@
+package taskpoet
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryMatch(t *testing.T) {
+	present := time.Date(2023, 10, 10, 8, 0, 0, 0, time.Local) // This is a Tuesday
+	thursday := time.Date(2023, 10, 12, 17, 0, 0, 0, time.Local)
+	nextMonth := time.Date(2023, 11, 20, 0, 0, 0, 0, time.Local)
+	lastMonth := time.Date(2023, 9, 1, 0, 0, 0, 0, time.Local)
+	dns := Task{
+		Description:  "Move the DNS server",
+		Project:      "work.infra",
+		Tags:         []string{"urgent"},
+		Due:          &thursday,
+		EffortImpact: EffortImpactHigh,
+		Urgency:      7.5,
+		Added:        present.Add(-48 * time.Hour),
+	}
+	garden := Task{
+		Description:  "Weed the garden",
+		Project:      "home",
+		Tags:         []string{"someday"},
+		Due:          &nextMonth,
+		EffortImpact: EffortImpactLow,
+		Urgency:      1,
+		Added:        lastMonth,
+	}
+
+	tests := map[string]struct {
+		query  string
+		expect []bool
+	}{
+		"empty":            {"", []bool{true, true}},
+		"word":             {"dns", []bool{true, false}},
+		"quoted-phrase":    {`"the garden"`, []bool{false, true}},
+		"word-regex":       {"^weed", []bool{false, true}},
+		"tag":              {"+urgent", []bool{true, false}},
+		"not-tag":          {"-someday", []bool{true, false}},
+		"tag-field":        {"tag:someday", []bool{false, true}},
+		"project-parent":   {"project:work", []bool{true, false}},
+		"project-exact":    {"project=work", []bool{false, false}},
+		"desc-regex":       {`desc~"dns serv"`, []bool{true, false}},
+		"desc-contains":    {"desc:GARDEN", []bool{false, true}},
+		"ei":               {"ei:1", []bool{true, false}},
+		"ei-compare":       {"ei>=2", []bool{false, true}},
+		"urgency":          {"urgency>5", []bool{true, false}},
+		"due-before":       {"due.before:eow", []bool{true, false}},
+		"due-after":        {"due.after:1w", []bool{false, true}},
+		"due-compare":      {"due<2023-11-01", []bool{true, false}},
+		"due-same-day":     {"due:2023-10-12", []bool{true, false}},
+		"due-none":         {"due:none", []bool{false, false}},
+		"added-past":       {"added.after:1w", []bool{true, false}},
+		"and":              {"+urgent and project:home", []bool{false, false}},
+		"or":               {"+urgent or project:home", []bool{true, true}},
+		"not":              {"not +urgent", []bool{false, true}},
+		"not-binds-tight":  {"not +urgent or project:work", []bool{true, true}},
+		"parens":           {"(dns or garden) and urgency<5", []bool{false, true}},
+		"nested":           {"not (project:work or (+someday ei:3))", []bool{false, false}},
+		"keywords-any-cap": {"+urgent OR +someday", []bool{true, true}},
+		"full-example":     {`+urgent -someday project:work.infra due.before:eow ei:1 urgency>5 desc~"dns"`, []bool{true, false}},
+	}
+	for desc, tt := range tests {
+		q, err := ParseQuery(tt.query, WithPresent(&present))
+		require.NoError(t, err, desc)
+		require.Equal(t, tt.expect, []bool{q.Match(dns), q.Match(garden)}, desc)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := map[string]struct {
+		query  string
+		pos    int
+		expect string
+	}{
+		"unknown-field":  {"+urgent color:red", 8, `invalid query at column 9: unknown field "color", put quotes around it to search descriptions, near "color:red"`},
+		"open-paren":     {"(dns or foo", 0, "invalid query at column 1: missing closing parenthesis, near \"(dns or foo\""},
+		"close-paren":    {"dns)", 3, `invalid query at column 4: unexpected ")", near ")"`},
+		"dangling-or":    {"dns or", 6, "invalid query at column 7: expected a term at the end of the query"},
+		"open-quote":     {`desc~"dns`, 5, `invalid query at column 6: missing closing quote, near "\"dns"`},
+		"trailing-paren": {"dns(", 4, "invalid query at column 5: expected a term at the end of the query"},
+		"bad-regex-desc": {"desc~[", 0, "invalid query at column 1: invalid regex: error parsing regexp: missing closing ]: `[`, near \"desc~[\""},
+		"bad-date":       {"due.before:whenever", 0, `invalid query at column 1: invalid date "whenever" for due, near "due.before:whenever"`},
+		"bad-ei":         {"ei:9", 0, `invalid query at column 1: effort/impact is a number from 0 to 4, near "ei:9"`},
+		"bad-op":         {"project>work", 0, `invalid query at column 1: > can't be used with project, near "project>work"`},
+		"bad-modifier":   {"project.before:work", 0, `invalid query at column 1: only dates take a modifier like .before, not project, near "project.before:work"`},
+	}
+	for desc, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		require.EqualError(t, err, tt.expect, desc)
+		var qerr *QueryError
+		require.True(t, errors.As(err, &qerr), desc)
+		require.Equal(t, tt.pos, qerr.Pos, desc)
+	}
+}
+
+func TestQueryUDA(t *testing.T) {
+	p := MustNew(WithDatabasePath(mustTempDB(t)), WithUDAs(UDAs{"customer": {Type: UDAString}}))
+	task := Task{Description: "invoice", UDAs: map[string]string{"customer": "acme"}}
+	q, err := p.ParseQuery("customer:acme")
+	require.NoError(t, err)
+	require.True(t, q.Match(task))
+	q, err = p.ParseQuery("customer!=acme")
+	require.NoError(t, err)
+	require.False(t, q.Match(task))
+	_, err = ParseQuery("customer:acme")
+	require.ErrorContains(t, err, `unknown field "customer"`)
+}
+
+func TestTaskTableQuery(t *testing.T) {
+	p := newTestPoet(t)
+	_, err := p.Task.Add(MustNewTask("tagged task", WithTags([]string{"urgent"})))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("plain task"))
+	require.NoError(t, err)
+
+	got := p.TaskTable(TableOpts{
+		Prefix:       "/active",
+		Columns:      []string{"ID", "Description"},
+		Filters:      []Filter{FilterQuery},
+		FilterParams: FilterParams{Query: MustParseQuery("not +urgent")},
+	})
+	require.Contains(t, got, "plain task")
+	require.NotContains(t, got, "tagged task")
+}
+
+func TestActiveRouteQuery(t *testing.T) {
+	require.NoError(t, lc.Task.AddSet(Tasks{
+		{ID: "test-query-1", Description: "query route match", Tags: []string{"route-query"}},
+		{ID: "test-query-2", Description: "query route other"},
+	}))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/tasks?limit=100&q=%2Broute-query", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code)
+	require.Contains(t, w.Body.String(), "query route match")
+	require.NotContains(t, w.Body.String(), "query route other")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/tasks?q=nope:nope", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, 400, w.Code)
+	require.Contains(t, w.Body.String(), "unknown field")
+}
@@ -1,14 +1,38 @@
 package taskpoet
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/google/uuid"
 )
 
-// RecurringTask is a task that recurs
+/*
+Recurrence templates live at /${namespace}/recur, keyed by template ID. A
+template has a rule saying when it comes around again, and checkRecurring adds
+an instance of it once that time arrives. Only one instance of a template is
+outstanding at a time, so a template that is ignored for a while doesn't pile
+up copies of itself. Missed occurrences are skipped, and the instance is
+scheduled for the most recent one.
+
+Rules look like:
+
+	daily, weekly, every 3d     a fixed interval
+	weekdays                    every Monday through Friday
+	every friday                weekly on a given day
+	monthly 15, monthly last    a day of the month
+	last friday                 the last given day of the month
+	after 3d                    this long after the last instance was done
+*/
+
+// RecurringTask is a task that recurs, defined in the config. These are run
+// like templates with an after rule, and can't be paused
 type RecurringTask struct {
 	Description string        `yaml:"description"`
 	Frequency   time.Duration `yaml:"frequency"`
@@ -17,23 +41,574 @@ type RecurringTask struct {
 // RecurringTasks represents multiple RecurringTask items
 type RecurringTasks []RecurringTask
 
-func (p *Poet) checkRecurring() {
-	now := time.Now()
-	for _, recur := range p.RecurringTasks {
-		needsCreate := true
-		for _, task := range p.MustList("/completed") {
-			earliest := now.Add(recur.Frequency)
-			fmt.Fprintf(os.Stderr, "NOW THEN: %v %v\n", now, earliest)
-			if (task.Description == recur.Description) && task.Completed.After(now.Add(-recur.Frequency)) {
-				needsCreate = false
-				break
+// configRecurPrefix marks templates that come from RecurringTasks
+const configRecurPrefix = "config:"
+
+// template returns the RecurringTask as a template
+func (r RecurringTask) template() RecurTemplate {
+	return RecurTemplate{
+		ID:          configRecurPrefix + r.Description,
+		Description: r.Description,
+		Rule:        RecurRule{Kind: RecurAfter, Interval: r.Frequency},
+	}
+}
+
+// RecurKind is the type of a recurrence rule
+type RecurKind string
+
+const (
+	// RecurEvery is a fixed interval after the last instance was scheduled
+	RecurEvery RecurKind = "every"
+	// RecurWeekdays is every Monday through Friday
+	RecurWeekdays RecurKind = "weekdays"
+	// RecurWeekly is once a week on a given day
+	RecurWeekly RecurKind = "weekly"
+	// RecurMonthly is a given day of every month
+	RecurMonthly RecurKind = "monthly"
+	// RecurLastWeekday is the last given day of every month
+	RecurLastWeekday RecurKind = "last"
+	// RecurAfter is an interval after the last instance was completed
+	RecurAfter RecurKind = "after"
+)
+
+// lastDayOfMonth is the Day of a monthly rule on the last day of the month
+const lastDayOfMonth = -1
+
+// RecurRule says when a template comes around again
+type RecurRule struct {
+	Kind     RecurKind
+	Interval time.Duration
+	Weekday  time.Weekday
+	// Day is the day of the month, or lastDayOfMonth
+	Day int
+}
+
+// ParseRecurRule parses a rule like "weekdays", "monthly 15" or "after 3d"
+func ParseRecurRule(s string) (RecurRule, error) {
+	fields := strings.Fields(strings.ToLower(strings.TrimSpace(s)))
+	if len(fields) == 0 {
+		return RecurRule{}, errors.New("recurrence rule must not be empty")
+	}
+	arg := strings.Join(fields[1:], " ")
+	switch fields[0] {
+	case "daily":
+		return RecurRule{Kind: RecurEvery, Interval: 24 * time.Hour}, noArg(fields)
+	case "weekly":
+		return RecurRule{Kind: RecurEvery, Interval: 7 * 24 * time.Hour}, noArg(fields)
+	case "weekdays":
+		return RecurRule{Kind: RecurWeekdays}, noArg(fields)
+	case "every", "after":
+		if day, err := parseWeekday(arg); err == nil && fields[0] == "every" {
+			return RecurRule{Kind: RecurWeekly, Weekday: day}, nil
+		}
+		d, err := ParseDuration(arg)
+		if err != nil {
+			return RecurRule{}, fmt.Errorf("invalid interval in recurrence rule: %v", s)
+		}
+		if d <= 0 {
+			return RecurRule{}, fmt.Errorf("interval must be positive: %v", s)
+		}
+		return RecurRule{Kind: RecurKind(fields[0]), Interval: d}, nil
+	case "monthly":
+		if arg == "last" {
+			return RecurRule{Kind: RecurMonthly, Day: lastDayOfMonth}, nil
+		}
+		day, err := strconv.Atoi(arg)
+		if err != nil || day < 1 || day > 31 {
+			return RecurRule{}, fmt.Errorf("monthly needs a day of the month from 1 to 31, or last: %v", s)
+		}
+		return RecurRule{Kind: RecurMonthly, Day: day}, nil
+	case "last":
+		day, err := parseWeekday(arg)
+		if err != nil {
+			return RecurRule{}, err
+		}
+		return RecurRule{Kind: RecurLastWeekday, Weekday: day}, nil
+	default:
+		return RecurRule{}, fmt.Errorf("unknown recurrence rule: %v", s)
+	}
+}
+
+// MustParseRecurRule parses a rule or panics
+func MustParseRecurRule(s string) RecurRule {
+	r, err := ParseRecurRule(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func noArg(fields []string) error {
+	if len(fields) > 1 {
+		return fmt.Errorf("%v does not take anything after it", fields[0])
+	}
+	return nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if s == name || s == name[:3] {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day of the week: %v", s)
+}
+
+// String returns the rule the way ParseRecurRule takes it
+func (r RecurRule) String() string {
+	switch r.Kind {
+	case RecurEvery, RecurAfter:
+		return fmt.Sprintf("%v %v", r.Kind, shortDuration(r.Interval))
+	case RecurWeekly:
+		return "every " + strings.ToLower(r.Weekday.String())
+	case RecurMonthly:
+		if r.Day == lastDayOfMonth {
+			return "monthly last"
+		}
+		return fmt.Sprintf("monthly %v", r.Day)
+	case RecurLastWeekday:
+		return "last " + strings.ToLower(r.Weekday.String())
+	default:
+		return string(r.Kind)
+	}
+}
+
+// MarshalText stores the rule as its string
+func (r RecurRule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText parses a stored rule
+func (r *RecurRule) UnmarshalText(b []byte) error {
+	got, err := ParseRecurRule(string(b))
+	if err != nil {
+		return err
+	}
+	*r = got
+	return nil
+}
+
+// IsCalendar is true when the rule falls on given days, instead of counting
+// from the last instance
+func (r RecurRule) IsCalendar() bool {
+	return r.Kind != RecurEvery && r.Kind != RecurAfter
+}
+
+// Next returns the first time the rule comes around after the given time.
+// Calendar rules land on the start of a day
+func (r RecurRule) Next(after time.Time) time.Time {
+	if !r.IsCalendar() {
+		return after.Add(r.Interval)
+	}
+	// Every calendar rule comes around within a month or so
+	for day := floorDay(after).AddDate(0, 0, 1); ; day = day.AddDate(0, 0, 1) {
+		if r.matches(day) {
+			return day
+		}
+	}
+}
+
+// matches is true when a calendar rule falls on the given day
+func (r RecurRule) matches(day time.Time) bool {
+	switch r.Kind { //nolint:exhaustive
+	case RecurWeekdays:
+		return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+	case RecurWeekly:
+		return day.Weekday() == r.Weekday
+	case RecurMonthly:
+		last := floorMonth(day).AddDate(0, 1, -1).Day()
+		if r.Day == lastDayOfMonth || r.Day > last {
+			return day.Day() == last
+		}
+		return day.Day() == r.Day
+	case RecurLastWeekday:
+		return day.Weekday() == r.Weekday && day.AddDate(0, 0, 7).Month() != day.Month()
+	default:
+		return false
+	}
+}
+
+// RecurTemplate is a stored task that recurs
+type RecurTemplate struct {
+	ID           string       `json:"id"`
+	Description  string       `json:"description"`
+	Rule         RecurRule    `json:"rule"`
+	Tags         []string     `json:"tags,omitempty"`
+	Project      string       `json:"project,omitempty"`
+	EffortImpact EffortImpact `json:"effort_impact,omitempty"`
+	// DueOffset is when an instance is due, relative to when it was
+	// scheduled. Instances have no due date when this is 0
+	DueOffset time.Duration `json:"due_offset,omitempty"`
+	Paused    bool          `json:"paused,omitempty"`
+	Added     time.Time     `json:"added"`
+	// Last is when the most recent instance was scheduled for
+	Last *time.Time `json:"last,omitempty"`
+}
+
+// RecurTemplates represents multiple RecurTemplate items
+type RecurTemplates []RecurTemplate
+
+// RecurOption is a functional option for a new RecurTemplate
+type RecurOption func(*RecurTemplate)
+
+// WithRecurTags sets the tags given to each instance
+func WithRecurTags(tags []string) RecurOption {
+	return func(r *RecurTemplate) {
+		r.Tags = tags
+	}
+}
+
+// WithRecurProject sets the project given to each instance
+func WithRecurProject(s string) RecurOption {
+	return func(r *RecurTemplate) {
+		r.Project = s
+	}
+}
+
+// WithRecurEffortImpact sets the effort/impact given to each instance
+func WithRecurEffortImpact(e EffortImpact) RecurOption {
+	return func(r *RecurTemplate) {
+		r.EffortImpact = e
+	}
+}
+
+// WithRecurDueOffset sets how long after being scheduled each instance is due
+func WithRecurDueOffset(d time.Duration) RecurOption {
+	return func(r *RecurTemplate) {
+		r.DueOffset = d
+	}
+}
+
+// WithRecurID sets the ID of the template, instead of a random one
+func WithRecurID(s string) RecurOption {
+	return func(r *RecurTemplate) {
+		r.ID = s
+	}
+}
+
+// WithRecurLast sets when the most recent instance was scheduled for
+func WithRecurLast(d *time.Time) RecurOption {
+	return func(r *RecurTemplate) {
+		r.Last = d
+	}
+}
+
+// NewRecurTemplate returns a new template using functional options
+func NewRecurTemplate(desc string, rule RecurRule, options ...RecurOption) (*RecurTemplate, error) {
+	r := &RecurTemplate{
+		ID:          uuid.New().String(),
+		Description: desc,
+		Rule:        rule,
+		Added:       time.Now(),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r, r.Validate()
+}
+
+// MustNewRecurTemplate returns a new template or panics
+func MustNewRecurTemplate(desc string, rule RecurRule, options ...RecurOption) *RecurTemplate {
+	r, err := NewRecurTemplate(desc, rule, options...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Validate makes sure the template isn't malformed
+func (r RecurTemplate) Validate() error {
+	switch {
+	case r.Description == "":
+		return errors.New("missing description for recurring task")
+	case r.ID == "" || strings.Contains(r.ID, "/"):
+		return errors.New("recurring task ID must be set and cannot contain a slash (/)")
+	case strings.HasPrefix(r.ID, configRecurPrefix):
+		return fmt.Errorf("recurring task ID cannot start with %v", configRecurPrefix)
+	case r.DueOffset < 0:
+		return errors.New("due offset cannot be negative")
+	default:
+		return ValidateProject(r.Project)
+	}
+}
+
+// IsConfig is true when the template comes from RecurringTasks in the config
+func (r RecurTemplate) IsConfig() bool {
+	return strings.HasPrefix(r.ID, configRecurPrefix)
+}
+
+// instance returns a new task for the template, scheduled at the given time
+func (r RecurTemplate) instance(scheduled time.Time) *Task {
+	opts := []TaskOption{
+		WithTags(r.Tags),
+		WithProject(r.Project),
+		WithEffortImpact(r.EffortImpact),
+		WithRecurTemplate(r.ID),
+	}
+	if r.DueOffset > 0 {
+		opts = append(opts, WithDue(datePTR(scheduled.Add(r.DueOffset))))
+	}
+	return MustNewTask(r.Description, opts...)
+}
+
+// WithRecurTemplate links a task to the template it is an instance of
+func WithRecurTemplate(id string) TaskOption {
+	return func(t *Task) {
+		t.RecurID = id
+	}
+}
+
+// AddRecurTemplate stores a new template
+func (p *Poet) AddRecurTemplate(r *RecurTemplate) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	return p.update(OperationAdd, func(tx StoreTx) error {
+		got, err := tx.Get(p.recurBucket, []byte(r.ID))
+		if err != nil {
+			return err
+		}
+		if got != nil {
+			return fmt.Errorf("recurring task already exists: %v", r.ID)
+		}
+		return p.editRecur(tx, *r)
+	})
+}
+
+func putRecur(tx StoreTx, bucket []byte, r RecurTemplate) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return tx.Put(bucket, []byte(r.ID), b)
+}
+
+// editRecur stores a new or changed template, journaling it so it can be
+// undone
+func (p *Poet) editRecur(tx StoreTx, r RecurTemplate) error {
+	before, err := tx.Get(p.recurBucket, []byte(r.ID))
+	if err != nil {
+		return err
+	}
+	before = copyBytes(before)
+	if err := putRecur(tx, p.recurBucket, r); err != nil {
+		return err
+	}
+	return p.journalRecur(tx, r.ID, before)
+}
+
+// RecurTemplates returns every stored template, along with the ones from the
+// config, ordered by description
+func (p *Poet) RecurTemplates() (RecurTemplates, error) {
+	ret := RecurTemplates{}
+	for _, r := range p.RecurringTasks {
+		ret = append(ret, r.template())
+	}
+	if err := p.Store.View(func(tx StoreTx) error {
+		return tx.ForEach(p.recurBucket, nil, func(k, v []byte) error {
+			var r RecurTemplate
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("could not decode recurring task %s: %w", k, err)
 			}
+			ret = append(ret, r)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Description < ret[j].Description
+	})
+	return ret, nil
+}
+
+// GetRecurTemplate returns the template with the given ID, or the only one
+// starting with it
+func (p *Poet) GetRecurTemplate(partial string) (*RecurTemplate, error) {
+	all, err := p.RecurTemplates()
+	if err != nil {
+		return nil, err
+	}
+	matches := RecurTemplates{}
+	for _, r := range all {
+		if r.ID == partial {
+			return &r, nil
+		}
+		if strings.HasPrefix(r.ID, partial) {
+			matches = append(matches, r)
 		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no recurring task matches: %v", partial)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("%v recurring tasks match %v, use more of the ID", len(matches), partial)
+	}
+}
+
+// PauseRecurTemplate stops or starts new instances of a template
+func (p *Poet) PauseRecurTemplate(r *RecurTemplate, paused bool) error {
+	if r.IsConfig() {
+		return fmt.Errorf("recurring task is defined in the config, remove it there instead: %v", r.Description)
+	}
+	r.Paused = paused
+	return p.update(OperationEdit, func(tx StoreTx) error {
+		return p.editRecur(tx, *r)
+	})
+}
+
+// recurNext returns when the template will next add an instance, given its
+// instances. It is false while an instance is still outstanding
+func (p *Poet) recurNext(r RecurTemplate, instances Tasks) (time.Time, bool) {
+	var lastDone *time.Time
+	for _, t := range instances {
+		if t.Completed == nil && t.Deleted == nil {
+			// Still waiting on this one
+			return time.Time{}, false
+		}
+		done := t.Completed
+		if done == nil {
+			done = t.Deleted
+		}
+		if lastDone == nil || done.After(*lastDone) {
+			lastDone = done
+		}
+	}
+	switch {
+	case r.Rule.Kind == RecurAfter:
+		if lastDone == nil {
+			return time.Time{}, true
+		}
+		return lastDone.Add(r.Rule.Interval), true
+	case r.Last != nil:
+		return r.Rule.Next(*r.Last), true
+	case r.Rule.IsCalendar():
+		// Today counts, if the template was added on a matching day
+		return r.Rule.Next(floorDay(r.Added).Add(-time.Nanosecond)), true
+	default:
+		return r.Added, true
+	}
+}
+
+// recurInstances groups every task by the template it is an instance of.
+// Config templates also claim tasks with the same description, from before
+// instances were linked
+func (p *Poet) recurInstances(templates RecurTemplates) (map[string]Tasks, error) {
+	all, err := p.Task.List("")
+	if err != nil {
+		return nil, err
+	}
+	byDesc := map[string]string{}
+	for _, r := range templates {
+		if r.IsConfig() {
+			byDesc[r.Description] = r.ID
+		}
+	}
+	ret := map[string]Tasks{}
+	for _, t := range all {
+		switch {
+		case t.RecurID != "":
+			ret[t.RecurID] = append(ret[t.RecurID], t)
+		case byDesc[t.Description] != "" && t.Completed != nil:
+			ret[byDesc[t.Description]] = append(ret[byDesc[t.Description]], t)
+		}
+	}
+	return ret, nil
+}
 
-		if needsCreate {
-			if _, err := p.Task.Add(MustNewTask(recur.Description)); err != nil {
-				log.Warn("problem looking up recurring tasks", "err", err)
+// Recur adds an instance of every template that has come around again, as a
+// single operation, and returns the new tasks
+func (p *Poet) Recur() (Tasks, error) {
+	templates, err := p.RecurTemplates()
+	if err != nil {
+		return nil, err
+	}
+	instances, err := p.recurInstances(templates)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	added := Tasks{}
+	err = p.inBatch(OperationRecur, func() error {
+		for _, r := range templates {
+			if r.Paused {
+				continue
+			}
+			next, ok := p.recurNext(r, instances[r.ID])
+			if !ok || next.After(now) {
+				continue
+			}
+			// Skip ahead to the most recent occurrence that was missed
+			if r.Rule.Kind != RecurAfter {
+				for after := r.Rule.Next(next); !after.After(now); after = r.Rule.Next(next) {
+					next = after
+				}
 			}
+			t := r.instance(next)
+			if _, err := p.Task.Add(t); err != nil {
+				return err
+			}
+			added = append(added, t)
+			if r.IsConfig() {
+				continue
+			}
+			r.Last = &next
+			if err := p.update(OperationRecur, func(tx StoreTx) error {
+				return p.editRecur(tx, r)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return added, err
+}
+
+func (p *Poet) checkRecurring() {
+	var added Tasks
+	err := p.unjournaled(func() error {
+		var err error
+		added, err = p.Recur()
+		return err
+	})
+	if err != nil {
+		log.Warn("problem adding recurring tasks", "err", err)
+		return
+	}
+	for _, t := range added {
+		log.Debug("Added recurring task", "task", t.Description, "id", t.ID)
+	}
+}
+
+// RecurTable returns a table of the templates
+func (p *Poet) RecurTable(templates RecurTemplates) string {
+	instances, err := p.recurInstances(templates)
+	if err != nil {
+		log.Warn("problem finding recurring task instances", "err", err)
+	}
+	rows := [][]string{}
+	for _, r := range templates {
+		next := ""
+		switch got, ok := p.recurNext(r, instances[r.ID]); {
+		case r.Paused:
+			next = "paused"
+		case !ok:
+			next = "waiting"
+		default:
+			next = shortDuration(time.Until(got))
+		}
+		due := ""
+		if r.DueOffset > 0 {
+			due = shortDuration(r.DueOffset)
+		}
+		id := r.ID
+		if !r.IsConfig() && len(id) > 8 {
+			id = id[:8]
 		}
+		rows = append(rows, []string{id, r.Description, r.Rule.String(), next, due, r.Project, strings.Join(r.Tags, ",")})
 	}
+	return p.listTable([]string{"ID", "Description", "Rule", "Next", "Due", "Project", "Tags"}, rows)
 }
@@ -43,3 +43,169 @@ func TestSimpleRecursionHit(t *testing.T) {
 	require.Equal(t, 1, len(items))
 	require.Equal(t, "do something frequently", items[0].Description)
 }
+
+func TestParseRecurRule(t *testing.T) {
+	for _, given := range []string{"weekdays", "every friday", "monthly 15", "monthly last", "last friday", "after 3d", "every 2w"} {
+		got, err := ParseRecurRule(given)
+		require.NoError(t, err, given)
+		require.Equal(t, given, got.String())
+	}
+	require.Equal(t, "every 1d", MustParseRecurRule("daily").String())
+	require.Equal(t, "every 1w", MustParseRecurRule("Weekly").String())
+	for _, given := range []string{"", "sometimes", "monthly 32", "monthly", "last week", "after never", "daily please"} {
+		_, err := ParseRecurRule(given)
+		require.Error(t, err, given)
+	}
+}
+
+func TestRecurRuleNext(t *testing.T) {
+	// A Wednesday
+	wed := time.Date(2023, time.May, 17, 10, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2023, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := map[string]time.Time{
+		"weekdays":     day(time.May, 18),
+		"every monday": day(time.May, 22),
+		"monthly 15":   day(time.June, 15),
+		"monthly 31":   day(time.May, 31),
+		"monthly last": day(time.May, 31),
+		"last friday":  day(time.May, 26),
+		"every 3d":     wed.Add(72 * time.Hour),
+	}
+	for rule, expect := range tests {
+		require.Equal(t, expect, MustParseRecurRule(rule).Next(wed), rule)
+	}
+	// Fridays roll over the weekend
+	require.Equal(t, day(time.May, 22), MustParseRecurRule("weekdays").Next(day(time.May, 19)))
+	// Short months land on their last day
+	require.Equal(t, day(time.June, 30), MustParseRecurRule("monthly 31").Next(day(time.June, 1)))
+}
+
+func TestRecurTemplates(t *testing.T) {
+	p := newTestPoet(t)
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
+	r := MustNewRecurTemplate("take out the trash", MustParseRecurRule("every 2d"),
+		WithRecurID("trash"),
+		WithRecurTags([]string{"home"}),
+		WithRecurEffortImpact(EffortImpactLow),
+		WithRecurDueOffset(24*time.Hour),
+		WithRecurLast(&weekAgo),
+	)
+	require.NoError(t, p.AddRecurTemplate(r))
+	require.Error(t, p.AddRecurTemplate(r))
+	require.Error(t, p.AddRecurTemplate(&RecurTemplate{ID: "config:nope", Description: "nope"}))
+
+	added, err := p.Recur()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(added))
+	got := added[0]
+	require.Equal(t, "trash", got.RecurID)
+	require.Equal(t, []string{"home"}, got.Tags)
+	require.Equal(t, EffortImpactLow, got.EffortImpact)
+	require.NotNil(t, got.Due)
+	// Missed occurrences are skipped, so this is scheduled for the latest of
+	// them, 6 days after the last
+	require.True(t, got.Due.Equal(weekAgo.Add(7*24*time.Hour)), got.Due)
+
+	// Nothing new while the last one is outstanding
+	added, err = p.Recur()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(added))
+
+	// Or when paused
+	require.NoError(t, p.Task.Complete(got))
+	stored, err := p.GetRecurTemplate("tr")
+	require.NoError(t, err)
+	require.NotNil(t, stored.Last)
+	require.NoError(t, p.PauseRecurTemplate(stored, true))
+	stored.Last = &weekAgo
+	require.NoError(t, p.Store.Update(func(tx StoreTx) error {
+		return putRecur(tx, p.recurBucket, *stored)
+	}))
+	added, err = p.Recur()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(added))
+
+	require.NoError(t, p.PauseRecurTemplate(stored, false))
+	added, err = p.Recur()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(added))
+
+	// Adding instances can be undone in one go, along with when the template
+	// last came around
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(p.MustList("/active")))
+	stored, err = p.GetRecurTemplate("trash")
+	require.NoError(t, err)
+	require.True(t, stored.Last.Equal(weekAgo), stored.Last)
+}
+
+func TestRecurTemplateUndo(t *testing.T) {
+	p := newTestPoet(t)
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
+	r := MustNewRecurTemplate("take out the trash", MustParseRecurRule("every 2d"), WithRecurID("trash"), WithRecurLast(&weekAgo))
+	require.NoError(t, p.AddRecurTemplate(MustNewRecurTemplate("water plants", MustParseRecurRule("daily"), WithRecurID("water"))))
+	_, err := p.Undo(1)
+	require.NoError(t, err)
+	_, err = p.GetRecurTemplate("water")
+	require.Error(t, err)
+
+	require.NoError(t, p.AddRecurTemplate(r))
+	require.NoError(t, p.PauseRecurTemplate(r, true))
+	undone, err := p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, `edit "take out the trash"`, undone[0].Description())
+	got, err := p.GetRecurTemplate("trash")
+	require.NoError(t, err)
+	require.False(t, got.Paused)
+
+	// Tables add instances on their own, which undo skips over
+	_, err = p.Task.Add(MustNewTask("feed the cat", WithID("cat")))
+	require.NoError(t, err)
+	p.TaskTable(TableOpts{Prefix: "/active", Columns: []string{"ID", "Description"}})
+	require.Equal(t, 2, len(p.MustList("/active")))
+	undone, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, `add "feed the cat"`, undone[0].Description())
+	active := p.MustList("/active")
+	require.Equal(t, 1, len(active))
+	require.Equal(t, "trash", active[0].RecurID)
+
+	// Adding the template can't be undone now that it has come around
+	_, err = p.Undo(1)
+	require.ErrorIs(t, err, ErrUndoConflict)
+}
+
+func TestRecurAfterCompletion(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.AddRecurTemplate(MustNewRecurTemplate("water plants", MustParseRecurRule("after 3d"), WithRecurID("water"))))
+
+	// The first one shows up right away
+	added, err := p.Recur()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(added))
+	require.NoError(t, p.Task.Complete(added[0]))
+
+	// The next waits until 3 days after that was done
+	added, err = p.Recur()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(added))
+
+	templates, err := p.RecurTemplates()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(templates))
+	require.Contains(t, p.RecurTable(templates), "after 3d")
+}
+
+func TestRecurConfigCannotPause(t *testing.T) {
+	p, err := New(
+		WithDatabasePath(mustTempDB(t)),
+		WithRecurringTasks(RecurringTasks{{Description: "stretch", Frequency: time.Hour}}),
+	)
+	require.NoError(t, err)
+	r, err := p.GetRecurTemplate("config:stretch")
+	require.NoError(t, err)
+	require.Error(t, p.PauseRecurTemplate(r, true))
+}
@@ -0,0 +1,254 @@
+package taskpoet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Reports are task tables declared in the config, each of which becomes a
+command of its own, like:
+
+	reports:
+	  overdue:
+	    description: Open tasks that are past due
+	    columns: [ID, Due, Description, Urgency, Project]
+	    sort: [due+, urgency-]
+	    filter: due.before:now
+	  waiting:
+	    states: [waiting]
+	    columns: [ID, Age, Description, State]
+	    sort: [added-]
+	    limit: 20
+
+States default to the open ones, which are active and every workflow state.
+Columns default to those of the active command. Each sort key is a field with
+an optional + for ascending or - for descending, and the filter is a query.
+*/
+
+// DefaultReportColumns are the columns of a report that doesn't list any
+var DefaultReportColumns = []string{"ID", "Age", "Due", "Description", "Urgency", "Project", "Tags"}
+
+// Report is a task table from the config
+type Report struct {
+	Description string   `mapstructure:"description" yaml:"description,omitempty"`
+	States      []string `mapstructure:"states" yaml:"states,omitempty"`
+	Columns     []string `mapstructure:"columns" yaml:"columns,omitempty"`
+	Sort        []string `mapstructure:"sort" yaml:"sort,omitempty"`
+	Filter      string   `mapstructure:"filter" yaml:"filter,omitempty"`
+	Limit       int      `mapstructure:"limit" yaml:"limit,omitempty"`
+}
+
+// Reports maps the name of each report to its definition
+type Reports map[string]Report
+
+// Names returns the report names, sorted
+func (r Reports) Names() []string {
+	ret := make([]string, 0, len(r))
+	for name := range r {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Validate makes sure the limit and sort are usable. Columns and the filter
+// may use UDAs, and states depend on the workflow states, so those are checked
+// by the Poet
+func (r Report) Validate() error {
+	if r.Limit < 0 {
+		return errors.New("limit cannot be negative")
+	}
+	_, err := ParseSortKeys(r.Sort)
+	return err
+}
+
+// validateReports makes sure the columns and filter of every report are usable
+func (p *Poet) validateReports() error {
+	for _, name := range p.Reports.Names() {
+		r := p.Reports[name]
+		for _, c := range r.Columns {
+			if err := p.ValidateColumn(c); err != nil {
+				return fmt.Errorf("report %v: %w", name, err)
+			}
+		}
+		if _, err := p.ParseQuery(r.Filter); err != nil {
+			return fmt.Errorf("report %v: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// TableOpts returns the options for a TaskTable of the report
+func (r Report) TableOpts(p *Poet) (*TableOpts, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	for _, c := range r.Columns {
+		if err := p.ValidateColumn(c); err != nil {
+			return nil, err
+		}
+	}
+	opts := &TableOpts{
+		Columns:      append([]string{}, r.Columns...),
+		SortBy:       ByUrgency{},
+		FilterParams: FilterParams{Limit: r.Limit},
+	}
+	if len(opts.Columns) == 0 {
+		opts.Columns = append(opts.Columns, DefaultReportColumns...)
+	}
+	if len(r.States) == 0 {
+		opts.Prefixes = p.OpenStatePaths()
+	}
+	for _, state := range r.States {
+		switch {
+		case state == StateActive, state == StateCompleted, state == StateDeleted, p.WorkflowStates.Has(state):
+			opts.Prefixes = append(opts.Prefixes, "/"+state)
+		default:
+			return nil, fmt.Errorf("unknown state: %v", state)
+		}
+	}
+	if len(r.Sort) > 0 {
+		keys, err := ParseSortKeys(r.Sort)
+		if err != nil {
+			return nil, err
+		}
+		opts.SortBy = keys
+	}
+	q, err := p.ParseQuery(r.Filter)
+	if err != nil {
+		return nil, err
+	}
+	opts.Filters = []Filter{FilterHidden, q.Filter()}
+	return opts, nil
+}
+
+// WithReports sets the reports from the config
+func WithReports(r Reports) Option {
+	for _, name := range r.Names() {
+		if err := r[name].Validate(); err != nil {
+			return failure(fmt.Errorf("report %v: %w", name, err))
+		}
+	}
+	return success(func(p *Poet) {
+		p.Reports = r
+	})
+}
+
+// SortKey is a field to sort tasks by
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// SortKeys sorts by each key in turn, with later keys breaking ties
+type SortKeys []SortKey
+
+// sortField compares a field of two tasks. Tasks where the field is missing,
+// like those without a due date, go last no matter the direction
+type sortField struct {
+	missing func(*Task) bool
+	cmp     func(a, b *Task) int
+}
+
+func dateSortField(get func(*Task) *time.Time) sortField {
+	return sortField{
+		missing: func(t *Task) bool { return get(t) == nil },
+		cmp:     func(a, b *Task) int { return get(a).Compare(*get(b)) },
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+var sortFields = map[string]sortField{
+	"id": {cmp: func(a, b *Task) int { return strings.Compare(a.ID, b.ID) }},
+	"description": {cmp: func(a, b *Task) int {
+		return strings.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description))
+	}},
+	"project": {
+		missing: func(t *Task) bool { return t.Project == "" },
+		cmp:     func(a, b *Task) int { return strings.Compare(a.Project, b.Project) },
+	},
+	"state":   {cmp: func(a, b *Task) int { return strings.Compare(a.State(), b.State()) }},
+	"urgency": {cmp: func(a, b *Task) int { return compareFloats(a.Urgency, b.Urgency) }},
+	"ei": {
+		missing: func(t *Task) bool { return t.EffortImpact == EffortImpactUnset },
+		cmp:     func(a, b *Task) int { return int(a.EffortImpact) - int(b.EffortImpact) },
+	},
+	"estimate": {
+		missing: func(t *Task) bool { return t.Estimate == 0 },
+		cmp:     func(a, b *Task) int { return compareFloats(float64(a.Estimate), float64(b.Estimate)) },
+	},
+	"added":     dateSortField(func(t *Task) *time.Time { return &t.Added }),
+	"due":       dateSortField(func(t *Task) *time.Time { return t.Due }),
+	"wait":      dateSortField(func(t *Task) *time.Time { return t.HideUntil }),
+	"until":     dateSortField(func(t *Task) *time.Time { return t.CancelAfter }),
+	"completed": dateSortField(func(t *Task) *time.Time { return t.Completed }),
+	"deleted":   dateSortField(func(t *Task) *time.Time { return t.Deleted }),
+	"reviewed":  dateSortField(func(t *Task) *time.Time { return t.Reviewed }),
+}
+
+// SortFieldNames returns the fields tasks can be sorted by
+func SortFieldNames() []string {
+	ret := make([]string, 0, len(sortFields))
+	for name := range sortFields {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ParseSortKeys parses keys like due+ or urgency-. Without a direction, keys
+// are ascending
+func ParseSortKeys(s []string) (SortKeys, error) {
+	ret := make(SortKeys, len(s))
+	for idx, item := range s {
+		key := SortKey{Field: strings.ToLower(strings.TrimSpace(item))}
+		switch {
+		case strings.HasSuffix(key.Field, "-"):
+			key.Field, key.Descending = strings.TrimSuffix(key.Field, "-"), true
+		case strings.HasSuffix(key.Field, "+"):
+			key.Field = strings.TrimSuffix(key.Field, "+")
+		}
+		if _, ok := sortFields[key.Field]; !ok {
+			return nil, fmt.Errorf("can't sort by %v, use one of: %v", key.Field, strings.Join(SortFieldNames(), ", "))
+		}
+		ret[idx] = key
+	}
+	return ret, nil
+}
+
+// less is true when task a sorts before task b
+func (s SortKeys) less(a, b *Task) bool {
+	for _, key := range s {
+		field := sortFields[key.Field]
+		if field.missing != nil {
+			am, bm := field.missing(a), field.missing(b)
+			if am != bm {
+				return bm
+			}
+			if am {
+				continue
+			}
+		}
+		c := field.cmp(a, b)
+		if key.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
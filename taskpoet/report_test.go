@@ -0,0 +1,91 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSortKeys(t *testing.T) {
+	got, err := ParseSortKeys([]string{"due+", "Urgency-", "project"})
+	require.NoError(t, err)
+	require.Equal(t, SortKeys{{Field: "due"}, {Field: "urgency", Descending: true}, {Field: "project"}}, got)
+
+	_, err = ParseSortKeys([]string{"colour-"})
+	require.ErrorContains(t, err, "can't sort by colour, use one of: added, completed")
+}
+
+func TestSortKeys(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	tasks := Tasks{
+		{ID: "no-due-high", Urgency: 9},
+		{ID: "later-low", Due: &later, Urgency: 1},
+		{ID: "later-high", Due: &later, Urgency: 5},
+		{ID: "now", Due: &now},
+	}
+	ids := func() []string {
+		ret := []string{}
+		for _, task := range tasks {
+			ret = append(ret, task.ID)
+		}
+		return ret
+	}
+
+	tasks.SortBy(SortKeys{{Field: "due"}, {Field: "urgency", Descending: true}})
+	require.Equal(t, []string{"now", "later-high", "later-low", "no-due-high"}, ids())
+
+	// Tasks without a due date stay last when it is descending too
+	tasks.SortBy(SortKeys{{Field: "due", Descending: true}, {Field: "urgency"}})
+	require.Equal(t, []string{"later-low", "later-high", "now", "no-due-high"}, ids())
+}
+
+func TestReportValidate(t *testing.T) {
+	require.NoError(t, Report{}.Validate())
+	require.Error(t, Report{Sort: []string{"colour"}}.Validate())
+	require.Error(t, Report{Limit: -1}.Validate())
+
+	_, err := New(WithDatabasePath(mustTempDB(t)), WithReports(Reports{"broken": {Filter: "colour:red"}}))
+	require.ErrorContains(t, err, "report broken: invalid query")
+	_, err = New(WithDatabasePath(mustTempDB(t)), WithReports(Reports{"broken": {Columns: []string{"Colour"}}}))
+	require.EqualError(t, err, "report broken: column not defined: Colour")
+
+	// UDAs can be used no matter the order of the options
+	_, err = New(
+		WithDatabasePath(mustTempDB(t)),
+		WithReports(Reports{"tickets": {Columns: []string{"ticket"}, Filter: "env:prod"}}),
+		WithUDAs(testUDAs),
+	)
+	require.NoError(t, err)
+}
+
+func TestReportTable(t *testing.T) {
+	p := MustNew(
+		WithDatabasePath(mustTempDB(t)),
+		WithWorkflowStates(WorkflowStates{"waiting": {}}),
+	)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		MustNewTask("overdue task", WithDue(&yesterday)),
+		MustNewTask("no due date"),
+		MustNewTask("waiting on someone", WithWorkflowState("waiting"), WithDue(&yesterday)),
+	}))
+
+	opts, err := Report{Filter: "due.before:now", Columns: []string{"ID", "Description", "State"}, Sort: []string{"due+"}}.TableOpts(p)
+	require.NoError(t, err)
+	got := p.TaskTable(*opts)
+	require.Contains(t, got, "overdue task")
+	require.Contains(t, got, "waiting on someone")
+	require.NotContains(t, got, "no due date")
+
+	opts, err = Report{States: []string{"waiting"}}.TableOpts(p)
+	require.NoError(t, err)
+	require.Equal(t, DefaultReportColumns, opts.Columns)
+	got = p.TaskTable(*opts)
+	require.Contains(t, got, "waiting on someone")
+	require.NotContains(t, got, "overdue task")
+
+	_, err = Report{States: []string{"someday"}}.TableOpts(p)
+	require.EqualError(t, err, "unknown state: someday")
+}
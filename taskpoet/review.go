@@ -0,0 +1,96 @@
+package taskpoet
+
+import (
+	"sort"
+	"time"
+)
+
+/*
+Reviewing is stepping through active tasks that haven't been looked at in a
+while and deciding what to do with each. Whatever is decided, Reviewed gets
+stamped, so the task drops out of the stale list until it is due for another
+look.
+*/
+
+// WithReviewed sets when the task was last reviewed on create
+func WithReviewed(d *time.Time) TaskOption {
+	return func(t *Task) {
+		t.Reviewed = d
+	}
+}
+
+// LastReviewed is when the task was last reviewed, or when it was added if it
+// never has been
+func (t Task) LastReviewed() time.Time {
+	if t.Reviewed != nil {
+		return *t.Reviewed
+	}
+	return t.Added
+}
+
+// IsStale is true when an active task hasn't been reviewed within age
+func (t Task) IsStale(age time.Duration) bool {
+	if t.Completed != nil || t.Deleted != nil {
+		return false
+	}
+	return t.LastReviewed().Before(time.Now().Add(-age))
+}
+
+// StaleTasks returns the active tasks not reviewed within age, least recently
+// reviewed first
+func (p *Poet) StaleTasks(age time.Duration) (Tasks, error) {
+	active, err := p.ListOpen()
+	if err != nil {
+		return nil, err
+	}
+	ret := Tasks{}
+	for _, t := range active {
+		if t.IsStale(age) {
+			ret = append(ret, t)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].LastReviewed().Before(ret[j].LastReviewed())
+	})
+	return ret, nil
+}
+
+// Review stamps the task as reviewed now, saving any other changes made to it
+// along the way
+func (p *Poet) Review(t *Task) error {
+	t.Reviewed = nowPTR()
+	if err := p.validateTask(t); err != nil {
+		return err
+	}
+	return p.update(OperationReview, func(tx StoreTx) error {
+		before, err := p.getTaskIn(tx, t.DetectKeyPath())
+		if err != nil {
+			return err
+		}
+		if err := p.putTask(tx, *t); err != nil {
+			return err
+		}
+		return p.recordRevision(tx, OperationReview, before, t)
+	})
+}
+
+// ReviewComplete stamps the task as reviewed and completes it
+func (p *Poet) ReviewComplete(t *Task) error {
+	t.Reviewed = nowPTR()
+	return p.Task.Complete(t)
+}
+
+// ReviewDelete stamps the task as reviewed and deletes it
+func (p *Poet) ReviewDelete(t *Task) error {
+	t.Reviewed = nowPTR()
+	return p.Delete(t)
+}
+
+// FilterStale keeps only tasks not reviewed within FilterParams.StaleAfter,
+// when it is set
+func FilterStale(p *FilterParams, task Task) bool {
+	if p.StaleAfter == 0 {
+		return true
+	}
+	return task.IsStale(p.StaleAfter)
+}
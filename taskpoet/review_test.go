@@ -0,0 +1,75 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleTasks(t *testing.T) {
+	p := newTestPoet(t)
+	week := 7 * 24 * time.Hour
+	longAgo := time.Now().Add(-30 * 24 * time.Hour)
+	lastMonth := time.Now().Add(-20 * 24 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		{ID: "never", Description: "never reviewed", Added: longAgo},
+		{ID: "old", Description: "reviewed a while ago", Added: longAgo, Reviewed: &lastMonth},
+		{ID: "fresh", Description: "reviewed yesterday", Added: longAgo, Reviewed: &yesterday},
+		MustNewTask("just added", WithID("new")),
+	}))
+
+	stale, err := p.StaleTasks(week)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(stale))
+	// Least recently reviewed first
+	require.Equal(t, "never", stale[0].ID)
+	require.Equal(t, "old", stale[1].ID)
+
+	got := ApplyFilters(p.MustList("/active"), &FilterParams{StaleAfter: week}, FilterStale)
+	require.Equal(t, 2, len(got))
+	got = ApplyFilters(p.MustList("/active"), &FilterParams{}, FilterStale)
+	require.Equal(t, 4, len(got))
+}
+
+func TestReview(t *testing.T) {
+	p := newTestPoet(t)
+	task, err := p.Task.Add(MustNewTask("look at me", WithID("look")))
+	require.NoError(t, err)
+	require.Nil(t, task.Reviewed)
+
+	task.EffortImpact = EffortImpactHigh
+	require.NoError(t, p.Review(task))
+	got, err := p.Task.GetWithID("look", "", "/active")
+	require.NoError(t, err)
+	require.NotNil(t, got.Reviewed)
+	require.Equal(t, EffortImpactHigh, got.EffortImpact)
+	require.False(t, got.IsStale(time.Hour))
+
+	revs, err := p.History(*got)
+	require.NoError(t, err)
+	require.Equal(t, OperationReview, revs[len(revs)-1].Operation)
+
+	// Snoozing past the due date is still invalid
+	due := time.Now().Add(time.Hour)
+	got.Due = &due
+	later := due.Add(time.Hour)
+	got.HideUntil = &later
+	require.Error(t, p.Review(got))
+
+	done, err := p.Task.Add(MustNewTask("done", WithID("done")))
+	require.NoError(t, err)
+	require.NoError(t, p.ReviewComplete(done))
+	got, err = p.Task.GetWithID("done", "", "/completed")
+	require.NoError(t, err)
+	require.NotNil(t, got.Reviewed)
+	require.False(t, got.IsStale(0))
+
+	gone, err := p.Task.Add(MustNewTask("gone", WithID("gone")))
+	require.NoError(t, err)
+	require.NoError(t, p.ReviewDelete(gone))
+	got, err = p.Task.GetWithID("gone", "", "/deleted")
+	require.NoError(t, err)
+	require.NotNil(t, got.Reviewed)
+}
@@ -0,0 +1,103 @@
+package taskpoet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// minShortIDLen is the shortest a short ID will ever be
+const minShortIDLen = 4
+
+// AmbiguousIDError is returned when a partial ID matches more than one task
+type AmbiguousIDError struct {
+	PartialID  string
+	Candidates Tasks
+}
+
+// Error lists the IDs that matched
+func (e *AmbiguousIDError) Error() string {
+	ids := make([]string, len(e.Candidates))
+	for idx, c := range e.Candidates {
+		ids[idx] = c.ID
+	}
+	return fmt.Sprintf("%v is ambiguous, it matches %v tasks: %v", e.PartialID, len(e.Candidates), strings.Join(ids, ", "))
+}
+
+// uniquePrefixes returns the shortest prefix of each id that no other id
+// shares, but never shorter than minLen
+func uniquePrefixes(ids []string, minLen int) map[string]string {
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+	ret := make(map[string]string, len(sorted))
+	for idx, id := range sorted {
+		// After sorting, the ids sharing the longest prefix are the neighbors
+		need := 0
+		if idx > 0 {
+			need = max(need, commonPrefixLen(id, sorted[idx-1]))
+		}
+		if idx < len(sorted)-1 {
+			need = max(need, commonPrefixLen(id, sorted[idx+1]))
+		}
+		ret[id] = id[0:min(len(id), max(need+1, minLen))]
+	}
+	return ret
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// ShortIDs returns the shortest unique prefix of every task ID in a state,
+// such as /active. Partial IDs of open tasks are looked up in every open state
+// at once, so for an open state the prefixes are unique across all of them,
+// and the tasks of the other open states are included
+func (p *Poet) ShortIDs(state string) (map[string]string, error) {
+	states := []string{state}
+	if open := p.OpenStatePaths(); containsString(open, state) {
+		states = open
+	}
+	ids := []string{}
+	for _, state := range states {
+		paths, err := p.Task.GetIDsByPrefix(state + "/")
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			ids = append(ids, path[strings.LastIndex(path, "/")+1:])
+		}
+	}
+	return uniquePrefixes(ids, minShortIDLen), nil
+}
+
+// SetShortIDs fills in the short ID of each task, using every task in the same
+// state. Open states all share one set of short IDs. Tasks that are listed are
+// already refreshed, set them on any other task before showing its ShortID
+func (p Poet) SetShortIDs(ts ...*Task) {
+	byState := map[string]map[string]string{}
+	open := p.OpenStatePaths()
+	for _, task := range ts {
+		state := "/" + task.State()
+		key := state
+		if containsString(open, state) {
+			key = "open"
+		}
+		if _, ok := byState[key]; !ok {
+			short, err := p.ShortIDs(state)
+			if err != nil {
+				log.Debug("Could not work out short IDs", "state", state, "error", err)
+				return
+			}
+			byState[key] = short
+		}
+		task.shortID = byState[key][task.ID]
+	}
+}
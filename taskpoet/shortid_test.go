@@ -0,0 +1,113 @@
+package taskpoet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUniquePrefixes(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"abcdef":  "abcde",
+		"abcdxyz": "abcdx",
+		"zzzzzz":  "zzzz",
+		"ab":      "ab",
+		"abc":     "abc",
+	}, uniquePrefixes([]string{"abcdef", "abcdxyz", "zzzzzz", "ab", "abc"}, 4))
+	require.Equal(t, map[string]string{}, uniquePrefixes(nil, 4))
+}
+
+func TestShortIDsPerState(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		{ID: "12345678", Description: "first"},
+		{ID: "12349999", Description: "second"},
+		{ID: "12340000", Description: "done"},
+	}))
+	done, err := p.Task.GetWithID("12340000", "", "")
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Complete(done))
+
+	active, err := p.ShortIDs("/active")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"12345678": "12345", "12349999": "12349"}, active)
+
+	// Alone in its state, so it gets the minimum
+	completed, err := p.ShortIDs("/completed")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"12340000": "1234"}, completed)
+
+	tasks := p.MustList("")
+	p.refresh(tasks)
+	for _, task := range tasks {
+		switch task.ID {
+		case "12340000":
+			require.Equal(t, "1234", task.ShortID())
+		case "12345678":
+			require.Equal(t, "12345", task.ShortID())
+		}
+	}
+	require.Contains(t, p.DescribeTask(*done), "12340000 (1234)")
+}
+
+func TestShortIDsAcrossOpenStates(t *testing.T) {
+	p := newTestWorkflowPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		{ID: "12345678", Description: "active"},
+		{ID: "12349999", Description: "waiting", WorkflowState: "waiting"},
+	}))
+
+	want := map[string]string{"12345678": "12345", "12349999": "12349"}
+	for _, state := range []string{"/active", "/waiting"} {
+		got, err := p.ShortIDs(state)
+		require.NoError(t, err)
+		require.Equal(t, want, got, state)
+	}
+
+	tasks, err := p.ListOpen()
+	require.NoError(t, err)
+	p.refresh(tasks)
+	for _, task := range tasks {
+		require.Equal(t, want[task.ID], task.ShortID())
+		got, err := p.Task.GetOpenWithPartialID(task.ShortID(), "")
+		require.NoError(t, err)
+		require.Equal(t, task.ID, got.ID)
+	}
+}
+
+func TestShortIDsMatchTables(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		{ID: "abcdef12", Description: "first"},
+		{ID: "abcdef34", Description: "second"},
+		{ID: "9876", Description: "third"},
+	}))
+
+	// Completions use the same short IDs as tables, not a fixed length
+	require.ElementsMatch(t, []string{"abcdef1\tfirst", "abcdef3\tsecond", "9876\tthird"}, p.CompleteIDsWithPrefix("/active", ""))
+
+	got, err := p.Task.GetOpenWithPartialID("abcdef1", "")
+	require.NoError(t, err)
+	p.SetShortIDs(got)
+	require.Equal(t, "abcdef1", got.ShortID())
+}
+
+func TestGetWithPartialIDAmbiguous(t *testing.T) {
+	p := newTestPoet(t)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		{ID: "abc", Description: "short"},
+		{ID: "abcd", Description: "longer"},
+		{ID: "abce", Description: "other"},
+	}))
+	_, err := p.Task.GetWithPartialID("ab", "", "/active")
+	var ambiguous *AmbiguousIDError
+	require.ErrorAs(t, err, &ambiguous)
+	require.Equal(t, "ab", ambiguous.PartialID)
+	require.Equal(t, 3, len(ambiguous.Candidates))
+	require.EqualError(t, err, "ab is ambiguous, it matches 3 tasks: abc, abcd, abce")
+
+	// An exact ID is never ambiguous
+	got, err := p.Task.GetWithPartialID("abc", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, "short", got.Description)
+}
@@ -0,0 +1,69 @@
+package taskpoet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Store is the storage backend that a Poet keeps tasks in. Everything is a
+// key/value pair inside of a named bucket, where the keys are task key paths
+// like /active/builtin/some-id
+type Store interface {
+	// View runs fn inside of a read-only transaction
+	View(fn func(StoreTx) error) error
+	// Update runs fn inside of a read-write transaction. If fn returns an
+	// error, none of the changes are kept
+	Update(fn func(StoreTx) error) error
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// StoreTx is a single transaction against a Store. Values returned by Get and
+// ForEach are only valid for the life of the transaction
+type StoreTx interface {
+	// CreateBucket creates the bucket if it does not already exist
+	CreateBucket(bucket []byte) error
+	// DeleteBucket removes a bucket and all of its keys
+	DeleteBucket(bucket []byte) error
+	// Buckets returns the name of every bucket in the store
+	Buckets() ([][]byte, error)
+	// Get returns the value for a key, or nil if it does not exist
+	Get(bucket, key []byte) ([]byte, error)
+	// Put sets the value for a key, creating the bucket if needed
+	Put(bucket, key, value []byte) error
+	// Delete removes a key. Removing a missing key is not an error
+	Delete(bucket, key []byte) error
+	// ForEach calls fn for every key in the bucket starting with prefix, in
+	// key order
+	ForEach(bucket, prefix []byte, fn func(k, v []byte) error) error
+}
+
+var errReadOnlyTx = errors.New("cannot write in a read-only transaction")
+
+// WithStore sets the storage backend for a poet. When this isn't used, a bolt
+// store is opened at the database path
+func WithStore(s Store) Option {
+	if s == nil {
+		return failure(errors.New("store cannot be nil"))
+	}
+	return success(func(p *Poet) {
+		p.Store = s
+	})
+}
+
+// copyBytes returns a copy of b that is safe to use outside of a transaction
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func hasPrefix(k, prefix []byte) bool {
+	return len(prefix) == 0 || bytes.HasPrefix(k, prefix)
+}
+
+func bucketNotFound(bucket []byte) error {
+	return fmt.Errorf("bucket not found: %s", bucket)
+}
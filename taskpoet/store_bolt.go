@@ -0,0 +1,117 @@
+package taskpoet
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore keeps everything in a single bbolt file. This is the default
+type BoltStore struct {
+	DB *bolt.DB
+}
+
+// NewBoltStore opens (or creates) a bolt database at the given path
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltStore{DB: db}, nil
+}
+
+// View runs fn in a bolt read transaction
+func (s *BoltStore) View(fn func(StoreTx) error) error {
+	return s.DB.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx: tx})
+	})
+}
+
+// Update runs fn in a bolt read-write transaction
+func (s *BoltStore) Update(fn func(StoreTx) error) error {
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx: tx})
+	})
+}
+
+// Close closes the bolt database
+func (s *BoltStore) Close() error {
+	return s.DB.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (b boltTx) CreateBucket(bucket []byte) error {
+	if !b.tx.Writable() {
+		return errReadOnlyTx
+	}
+	_, err := b.tx.CreateBucketIfNotExists(bucket)
+	return err
+}
+
+func (b boltTx) DeleteBucket(bucket []byte) error {
+	if b.tx.Bucket(bucket) == nil {
+		return bucketNotFound(bucket)
+	}
+	return b.tx.DeleteBucket(bucket)
+}
+
+func (b boltTx) Buckets() ([][]byte, error) {
+	ret := [][]byte{}
+	err := b.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		ret = append(ret, copyBytes(name))
+		return nil
+	})
+	return ret, err
+}
+
+func (b boltTx) Get(bucket, key []byte) ([]byte, error) {
+	bu := b.tx.Bucket(bucket)
+	if bu == nil {
+		return nil, nil
+	}
+	return bu.Get(key), nil
+}
+
+func (b boltTx) Put(bucket, key, value []byte) error {
+	if !b.tx.Writable() {
+		return errReadOnlyTx
+	}
+	bu, err := b.tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return err
+	}
+	return bu.Put(key, value)
+}
+
+func (b boltTx) Delete(bucket, key []byte) error {
+	bu := b.tx.Bucket(bucket)
+	if bu == nil {
+		return nil
+	}
+	return bu.Delete(key)
+}
+
+func (b boltTx) ForEach(bucket, prefix []byte, fn func(k, v []byte) error) error {
+	bu := b.tx.Bucket(bucket)
+	if bu == nil {
+		return nil
+	}
+	c := bu.Cursor()
+	var k, v []byte
+	if len(prefix) == 0 {
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(prefix)
+	}
+	for ; k != nil && hasPrefix(k, prefix); k, v = c.Next() {
+		// Nested buckets have a nil value, and are not something we store
+		if v == nil {
+			continue
+		}
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package taskpoet
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps everything in memory. Nothing is persisted, which makes
+// it handy for tests, or for embedding taskpoet where a file lock isn't an
+// option
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string]map[string][]byte
+}
+
+// NewMemoryStore returns a new, empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: map[string]map[string][]byte{},
+	}
+}
+
+// View runs fn against the current data
+func (s *MemoryStore) View(fn func(StoreTx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&memoryTx{data: s.data})
+}
+
+// Update runs fn against a copy of the data, and only keeps the copy if fn
+// succeeds
+func (s *MemoryStore) Update(fn func(StoreTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	working := make(map[string]map[string][]byte, len(s.data))
+	for name, bucket := range s.data {
+		working[name] = make(map[string][]byte, len(bucket))
+		for k, v := range bucket {
+			working[name][k] = v
+		}
+	}
+	if err := fn(&memoryTx{data: working, writable: true}); err != nil {
+		return err
+	}
+	s.data = working
+	return nil
+}
+
+// Close is a no-op for the MemoryStore
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+type memoryTx struct {
+	data     map[string]map[string][]byte
+	writable bool
+}
+
+func (m *memoryTx) CreateBucket(bucket []byte) error {
+	if !m.writable {
+		return errReadOnlyTx
+	}
+	if _, ok := m.data[string(bucket)]; !ok {
+		m.data[string(bucket)] = map[string][]byte{}
+	}
+	return nil
+}
+
+func (m *memoryTx) DeleteBucket(bucket []byte) error {
+	if !m.writable {
+		return errReadOnlyTx
+	}
+	if _, ok := m.data[string(bucket)]; !ok {
+		return bucketNotFound(bucket)
+	}
+	delete(m.data, string(bucket))
+	return nil
+}
+
+func (m *memoryTx) Buckets() ([][]byte, error) {
+	names := make([]string, 0, len(m.data))
+	for name := range m.data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ret := make([][]byte, len(names))
+	for idx, name := range names {
+		ret[idx] = []byte(name)
+	}
+	return ret, nil
+}
+
+func (m *memoryTx) Get(bucket, key []byte) ([]byte, error) {
+	return m.data[string(bucket)][string(key)], nil
+}
+
+func (m *memoryTx) Put(bucket, key, value []byte) error {
+	if err := m.CreateBucket(bucket); err != nil {
+		return err
+	}
+	m.data[string(bucket)][string(key)] = copyBytes(value)
+	return nil
+}
+
+func (m *memoryTx) Delete(bucket, key []byte) error {
+	if !m.writable {
+		return errReadOnlyTx
+	}
+	delete(m.data[string(bucket)], string(key))
+	return nil
+}
+
+func (m *memoryTx) ForEach(bucket, prefix []byte, fn func(k, v []byte) error) error {
+	b := m.data[string(bucket)]
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		if hasPrefix([]byte(k), prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, ok := b[k]
+		if !ok {
+			continue
+		}
+		if err := fn([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
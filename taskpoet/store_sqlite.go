@@ -0,0 +1,175 @@
+package taskpoet
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	// Pure go SQLite driver, so we don't need cgo
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS buckets (
+	name BLOB PRIMARY KEY
+);
+CREATE TABLE IF NOT EXISTS kv (
+	bucket BLOB NOT NULL,
+	key    BLOB NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+);`
+
+// SQLiteStore keeps everything in a SQLite database. Unlike bolt, multiple
+// processes can have the database open at the same time
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) a SQLite database at the given path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only allows a single writer, so don't bother pooling
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// View runs fn in a transaction that is always rolled back
+func (s *SQLiteStore) View(fn func(StoreTx) error) error {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	return fn(&sqliteTx{tx: tx})
+}
+
+// Update runs fn in a transaction, committing it if fn succeeds
+func (s *SQLiteStore) Update(fn func(StoreTx) error) error {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&sqliteTx{tx: tx, writable: true}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close closes the SQLite database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+type sqliteTx struct {
+	tx       *sql.Tx
+	writable bool
+}
+
+func (s *sqliteTx) CreateBucket(bucket []byte) error {
+	if !s.writable {
+		return errReadOnlyTx
+	}
+	_, err := s.tx.Exec(`INSERT OR IGNORE INTO buckets (name) VALUES (?)`, bucket)
+	return err
+}
+
+func (s *sqliteTx) DeleteBucket(bucket []byte) error {
+	if !s.writable {
+		return errReadOnlyTx
+	}
+	res, err := s.tx.Exec(`DELETE FROM buckets WHERE name = ?`, bucket)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return bucketNotFound(bucket)
+	}
+	_, err = s.tx.Exec(`DELETE FROM kv WHERE bucket = ?`, bucket)
+	return err
+}
+
+func (s *sqliteTx) Buckets() ([][]byte, error) {
+	rows, err := s.tx.Query(`SELECT name FROM buckets ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	ret := [][]byte{}
+	for rows.Next() {
+		var name []byte
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		ret = append(ret, name)
+	}
+	return ret, rows.Err()
+}
+
+func (s *sqliteTx) Get(bucket, key []byte) ([]byte, error) {
+	var v []byte
+	err := s.tx.QueryRow(`SELECT value FROM kv WHERE bucket = ? AND key = ?`, bucket, key).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
+
+func (s *sqliteTx) Put(bucket, key, value []byte) error {
+	if err := s.CreateBucket(bucket); err != nil {
+		return err
+	}
+	_, err := s.tx.Exec(
+		`INSERT INTO kv (bucket, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`,
+		bucket, key, value)
+	return err
+}
+
+func (s *sqliteTx) Delete(bucket, key []byte) error {
+	if !s.writable {
+		return errReadOnlyTx
+	}
+	_, err := s.tx.Exec(`DELETE FROM kv WHERE bucket = ? AND key = ?`, bucket, key)
+	return err
+}
+
+func (s *sqliteTx) ForEach(bucket, prefix []byte, fn func(k, v []byte) error) error {
+	rows, err := s.tx.Query(
+		`SELECT key, value FROM kv WHERE bucket = ? AND key >= ? ORDER BY key`, bucket, append([]byte{}, prefix...))
+	if err != nil {
+		return err
+	}
+	// Read everything up front, so fn is free to write to the same transaction
+	type pair struct{ k, v []byte }
+	var pairs []pair
+	for rows.Next() {
+		var p pair
+		if err := rows.Scan(&p.k, &p.v); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if !hasPrefix(p.k, prefix) {
+			break
+		}
+		pairs = append(pairs, p)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		if err := fn(p.k, p.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package taskpoet
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	bs, err := NewBoltStore(path.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+	ss, err := NewSQLiteStore(path.Join(t.TempDir(), "store.sqlite"))
+	require.NoError(t, err)
+	stores := map[string]Store{
+		"bolt":   bs,
+		"memory": NewMemoryStore(),
+		"sqlite": ss,
+	}
+	t.Cleanup(func() {
+		for _, s := range stores {
+			_ = s.Close()
+		}
+	})
+	return stores
+}
+
+func TestStoreGetPutDelete(t *testing.T) {
+	bucket := []byte("/default/tasks")
+	for name, s := range testStores(t) {
+		require.NoError(t, s.Update(func(tx StoreTx) error {
+			require.NoError(t, tx.Put(bucket, []byte("/active/builtin/a"), []byte("a")))
+			require.NoError(t, tx.Put(bucket, []byte("/active/builtin/b"), []byte("b")))
+			return tx.Put(bucket, []byte("/completed/builtin/c"), []byte("c"))
+		}), name)
+		require.NoError(t, s.View(func(tx StoreTx) error {
+			got, err := tx.Get(bucket, []byte("/active/builtin/a"))
+			require.NoError(t, err, name)
+			require.Equal(t, []byte("a"), got, name)
+
+			got, err = tx.Get(bucket, []byte("/never/exists"))
+			require.NoError(t, err, name)
+			require.Nil(t, got, name)
+
+			got, err = tx.Get([]byte("never-a-bucket"), []byte("/never/exists"))
+			require.NoError(t, err, name)
+			require.Nil(t, got, name)
+
+			keys := []string{}
+			require.NoError(t, tx.ForEach(bucket, []byte("/active"), func(k, v []byte) error {
+				keys = append(keys, string(k))
+				return nil
+			}), name)
+			require.Equal(t, []string{"/active/builtin/a", "/active/builtin/b"}, keys, name)
+
+			require.Error(t, tx.Put(bucket, []byte("no"), []byte("writes")), name)
+			return nil
+		}), name)
+		require.NoError(t, s.Update(func(tx StoreTx) error {
+			return tx.Delete(bucket, []byte("/active/builtin/a"))
+		}), name)
+		require.NoError(t, s.View(func(tx StoreTx) error {
+			count := 0
+			require.NoError(t, tx.ForEach(bucket, nil, func(k, v []byte) error {
+				count++
+				return nil
+			}), name)
+			require.Equal(t, 2, count, name)
+			return nil
+		}), name)
+	}
+}
+
+func TestStoreRollback(t *testing.T) {
+	bucket := []byte("/default/tasks")
+	for name, s := range testStores(t) {
+		require.Error(t, s.Update(func(tx StoreTx) error {
+			require.NoError(t, tx.Put(bucket, []byte("/active/builtin/a"), []byte("a")))
+			return errors.New("nope")
+		}), name)
+		require.NoError(t, s.View(func(tx StoreTx) error {
+			got, err := tx.Get(bucket, []byte("/active/builtin/a"))
+			require.NoError(t, err, name)
+			require.Nil(t, got, name)
+			return nil
+		}), name)
+	}
+}
+
+func TestStoreBuckets(t *testing.T) {
+	for name, s := range testStores(t) {
+		require.NoError(t, s.Update(func(tx StoreTx) error {
+			require.NoError(t, tx.CreateBucket([]byte("/foo/tasks")))
+			require.NoError(t, tx.CreateBucket([]byte("/foo/tasks")))
+			return tx.Put([]byte("/bar/tasks"), []byte("k"), []byte("v"))
+		}), name)
+		require.NoError(t, s.View(func(tx StoreTx) error {
+			got, err := tx.Buckets()
+			require.NoError(t, err, name)
+			require.ElementsMatch(t, [][]byte{[]byte("/foo/tasks"), []byte("/bar/tasks")}, got, name)
+			return nil
+		}), name)
+		require.NoError(t, s.Update(func(tx StoreTx) error {
+			require.Error(t, tx.DeleteBucket([]byte("/never/tasks")))
+			return tx.DeleteBucket([]byte("/bar/tasks"))
+		}), name)
+		require.NoError(t, s.View(func(tx StoreTx) error {
+			got, err := tx.Buckets()
+			require.NoError(t, err, name)
+			require.Equal(t, [][]byte{[]byte("/foo/tasks")}, got, name)
+			v, err := tx.Get([]byte("/bar/tasks"), []byte("k"))
+			require.NoError(t, err, name)
+			require.Nil(t, v, name)
+			return nil
+		}), name)
+	}
+}
+
+func TestWithStore(t *testing.T) {
+	_, err := New(WithStore(nil))
+	require.EqualError(t, err, "store cannot be nil")
+
+	for name, s := range testStores(t) {
+		p, err := New(WithStore(s))
+		require.NoError(t, err, name)
+		task, err := p.Task.Add(MustNewTask("store me"))
+		require.NoError(t, err, name)
+		require.NoError(t, p.Task.Complete(task), name)
+		require.Equal(t, 1, len(p.MustList("/completed")), name)
+		require.NoError(t, p.Delete(task), name)
+		require.Equal(t, 0, len(p.MustList("/completed")), name)
+		require.Equal(t, 1, len(p.MustList("/deleted")), name)
+	}
+}
@@ -10,56 +10,85 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	bolt "go.etcd.io/bbolt"
 )
 
-var prefixes []string
-
 // Task is the actual task item
 type Task struct {
-	ID           string       `json:"id"`
-	PluginID     string       `json:"plugin_id"`
-	Description  string       `json:"description"`
-	Due          *time.Time   `json:"due,omitempty"`
-	HideUntil    *time.Time   `json:"hide_until,omitempty"`   // HideUntil is similar to 'wait' in taskwarrior
-	CancelAfter  *time.Time   `json:"cancel_after,omitempty"` // CancelAfter is similar to 'until' in taskwarrior
-	Completed    *time.Time   `json:"completed,omitempty"`
-	Reviewed     *time.Time   `json:"reviewed,omitempty"`
-	Deleted      *time.Time   `json:"deleted,omitempty"`
-	Added        time.Time    `json:"added,omitempty"`
-	EffortImpact EffortImpact `json:"effort_impact"`
-	Children     []string     `json:"children,omitempty"`
-	Parents      []string     `json:"parents,omitempty"`
-	Tags         []string     `json:"tags,omitempty"`
-	Comments     []Comment    `json:"comments,omitempty"`
-	Project      string       `json:"project,omitempty"`
-	Urgency      float64      `json:"urgency,omitempty"`
-}
-
-// DescriptionDetails is the details along with any comments or extra info we like to include
+	ID          string     `json:"id"`
+	PluginID    string     `json:"plugin_id"`
+	Description string     `json:"description"`
+	Due         *time.Time `json:"due,omitempty"`
+	HideUntil   *time.Time `json:"hide_until,omitempty"`   // HideUntil is similar to 'wait' in taskwarrior
+	CancelAfter *time.Time `json:"cancel_after,omitempty"` // CancelAfter is similar to 'until' in taskwarrior
+	Completed   *time.Time `json:"completed,omitempty"`
+	Reviewed    *time.Time `json:"reviewed,omitempty"`
+	Deleted     *time.Time `json:"deleted,omitempty"`
+	// DeletedReason is why a task was deleted, when it wasn't by hand
+	DeletedReason string            `json:"deleted_reason,omitempty"`
+	Added         time.Time         `json:"added,omitempty"`
+	EffortImpact  EffortImpact      `json:"effort_impact"`
+	Estimate      time.Duration     `json:"estimate,omitempty"`
+	Children      []string          `json:"children,omitempty"`
+	Parents       []string          `json:"parents,omitempty"`
+	DependsOn     []string          `json:"depends_on,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+	Comments      []Comment         `json:"comments,omitempty"`
+	Intervals     []Interval        `json:"intervals,omitempty"`
+	Project       string            `json:"project,omitempty"`
+	UDAs          map[string]string `json:"udas,omitempty"`
+	// RecurID is the recurring task template this is an instance of
+	RecurID string `json:"recur_id,omitempty"`
+	// WorkflowState is the configured state an open task is in, empty for
+	// active
+	WorkflowState string  `json:"workflow_state,omitempty"`
+	Urgency       float64 `json:"urgency,omitempty"`
+
+	// shortID is the shortest unique prefix of ID within its state, set by
+	// refresh before display
+	shortID string
+	// blockedBy are the short IDs of the active tasks this depends on, and
+	// blocking is how many active tasks depend on this. Both are set by refresh
+	blockedBy []string
+	blocking  int
+}
+
+// DescriptionDetails is the details along with any comments or extra info we
+// like to include
 func (t Task) DescriptionDetails() string {
+	return t.descriptionDetails(0)
+}
+
+// descriptionDetails is DescriptionDetails with only the latest limit comments,
+// or all of them when limit is 0
+func (t Task) descriptionDetails(limit int) string {
 	ret := strings.Builder{}
 	ret.WriteString(t.Description + "\n")
-	if len(t.Comments) > 0 {
-		for _, c := range t.Comments {
-			ret.WriteString(fmt.Sprintf(" %v - %v\n", c.Added.Format("2006-01-02"), c.Text))
-		}
+	comments := t.Comments
+	if limit > 0 && len(comments) > limit {
+		ret.WriteString(fmt.Sprintf(" (%v earlier comments)\n", len(comments)-limit))
+		comments = comments[len(comments)-limit:]
+	}
+	for _, c := range comments {
+		ret.WriteString(fmt.Sprintf(" %v - %v\n", c.Added.Format("2006-01-02"), c.Text))
 	}
 	return strings.TrimSpace(ret.String())
 }
 
 // Comment is just a little comment/note on a task
 type Comment struct {
-	Added time.Time `json:"added,omitempty"`
-	Text  string    `json:"text,omitempty"`
+	ID     string     `json:"id,omitempty"`
+	Added  time.Time  `json:"added,omitempty"`
+	Edited *time.Time `json:"edited,omitempty"`
+	Text   string     `json:"text,omitempty"`
 }
 
 // NewComment returns a new comment item using functional arguments
 func NewComment(s string) (*Comment, error) {
-	if s == "" {
+	if strings.TrimSpace(s) == "" {
 		return nil, errors.New("text must not be empty")
 	}
 	return &Comment{
+		ID:    uuid.New().String(),
 		Added: time.Now(),
 		Text:  s,
 	}, nil
@@ -85,8 +114,13 @@ func (t *Tasks) SortBy(s any) {
 		sort.Sort(ByDue(*t))
 	case ByCompleted:
 		sort.Sort(ByCompleted(*t))
+	case ByDeleted:
+		sort.Sort(ByDeleted(*t))
 	case ByUrgency:
 		sort.Sort(ByUrgency(*t))
+	case SortKeys:
+		keys := s.(SortKeys)
+		sort.SliceStable(*t, func(i, j int) bool { return keys.less((*t)[i], (*t)[j]) })
 	default:
 		sort.Sort(*t)
 	}
@@ -119,11 +153,18 @@ func (t *Task) DetectKeyPath() []byte {
 		return []byte(filepath.Join("/deleted", pluginID, t.ID))
 	case t.Completed != nil:
 		return []byte(filepath.Join("/completed", pluginID, t.ID))
+	case t.WorkflowState != "":
+		return []byte(filepath.Join("/", t.WorkflowState, pluginID, t.ID))
 	default:
 		return []byte(filepath.Join("/active", pluginID, t.ID))
 	}
 }
 
+// State is the state the task is stored under, such as active
+func (t *Task) State() string {
+	return strings.SplitN(strings.TrimPrefix(string(t.DetectKeyPath()), "/"), "/", 2)[0]
+}
+
 func (t *Task) setDefaults(d *Task) {
 	// Handle defaults due
 	if d != nil {
@@ -133,8 +174,12 @@ func (t *Task) setDefaults(d *Task) {
 	}
 }
 
-// ShortID is just the first 5 characters of the ID
+// ShortID is the shortest prefix of the ID that is unique within its state,
+// when that is known, otherwise just the first 5 characters of the ID
 func (t *Task) ShortID() string {
+	if t.shortID != "" {
+		return t.shortID
+	}
 	return t.ID[0:min(len(t.ID), 5)]
 }
 
@@ -179,6 +224,12 @@ type TaskService interface {
 
 	// This on prolly needs work
 	List(prefix string) (Tasks, error)
+
+	// These use the secondary indexes instead of walking every task
+	ListWithTag(prefix, tag string) (Tasks, error)
+	ListWithProject(prefix, project string) (Tasks, error)
+	ListWithPluginID(prefix, pluginID string) (Tasks, error)
+	ListDueBetween(prefix string, start, end time.Time) (Tasks, error)
 	/*
 	   Path Conventions
 	   /${state}/${plugin-id}/${id}
@@ -193,15 +244,19 @@ type TaskService interface {
 	// New way to get stuff
 	GetWithID(id, pluginID, state string) (*Task, error)
 	GetWithPartialID(partialID, pluginID, state string) (*Task, error)
+	GetOpenWithPartialID(partialID, pluginID string) (*Task, error)
 	GetWithExactPath(path []byte) (*Task, error)
 
 	// Old way to get stuff, delete soon
 	GetIDsByPrefix(prefix string) ([]string, error)
 }
 
-// GetStates returns types of states
+// GetStates returns types of states, with any workflow states between active
+// and completed
 func (svc *TaskServiceOp) GetStates() []string {
-	return []string{"active", "completed", "deleted"}
+	ret := []string{StateActive}
+	ret = append(ret, svc.localClient.WorkflowStates.Names()...)
+	return append(ret, StateCompleted, StateDeleted)
 }
 
 // GetStatePaths is each of the states with a / prefix i guess
@@ -232,32 +287,14 @@ func (svc *TaskServiceOp) GetPlugins() (map[string]Creator, error) {
 	return TaskPlugins, nil
 }
 
-// AddParent adds a parent to a child command
-func (svc *TaskServiceOp) AddParent(c, p *Task) error {
-	c.Parents = append(c.Parents, p.ID)
-	p.Children = append(p.Children, c.ID)
-
-	if err := svc.EditSet([]Task{*c, *p}); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// AddChild adds a child to a parent
-func (svc *TaskServiceOp) AddChild(p, c *Task) error {
-	p.Children = append(p.Children, c.ID)
-	c.Parents = append(c.Parents, p.ID)
-
-	if err := svc.EditSet([]Task{*c, *p}); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // AddOrEditSet adds or edits a set of tasks
 func (svc *TaskServiceOp) AddOrEditSet(tasks []Task) error {
+	return svc.localClient.inBatch(OperationImport, func() error {
+		return svc.addOrEditSet(tasks)
+	})
+}
+
+func (svc *TaskServiceOp) addOrEditSet(tasks []Task) error {
 	var addSet Tasks
 	var editSet []Task
 	for _, t := range tasks {
@@ -317,21 +354,46 @@ func (svc *TaskServiceOp) EditSet(tasks []Task) error { //nolint:funlen,gocognit
 		if t.HideUntil == nil {
 			t.HideUntil = originalTask.HideUntil
 		}
+		if t.CancelAfter == nil {
+			t.CancelAfter = originalTask.CancelAfter
+		}
+		if t.Reviewed == nil {
+			t.Reviewed = originalTask.Reviewed
+		}
+		if t.RecurID == "" {
+			t.RecurID = originalTask.RecurID
+		}
 
 		if t.EffortImpact == 0 {
 			t.EffortImpact = originalTask.EffortImpact
 		}
 
+		if t.Project == "" {
+			t.Project = originalTask.Project
+		}
+		if t.UDAs == nil {
+			t.UDAs = originalTask.UDAs
+		}
+		if t.DependsOn == nil {
+			t.DependsOn = originalTask.DependsOn
+		}
+		if t.Intervals == nil {
+			t.Intervals = originalTask.Intervals
+		}
+		if t.Estimate == 0 {
+			t.Estimate = originalTask.Estimate
+		}
+
+		if err := svc.localClient.validateTask(&t); err != nil {
+			return err
+		}
 		mergedTasks = append(mergedTasks, t)
 	}
 
-	err := svc.localClient.DB.Update(func(tx *bolt.Tx) error {
+	err := svc.localClient.update(OperationEdit, func(tx StoreTx) error {
 		for _, t := range mergedTasks {
-			taskSerial, err := json.Marshal(t)
-			if err != nil {
-				return err
-			}
-			if perr := svc.localClient.getBucket(tx).Put(t.DetectKeyPath(), taskSerial); perr != nil {
+			t := t
+			if perr := svc.localClient.editTask(tx, &t); perr != nil {
 				return perr
 			}
 		}
@@ -351,16 +413,9 @@ func (svc *TaskServiceOp) Purge(t *Task) error {
 		return errors.New("Cannot delete a task that did not previously exist: " + t.ID)
 	}
 
-	if svc.localClient.DB.Update(func(tx *bolt.Tx) error {
-		if derr := svc.localClient.getBucket(tx).Delete(originalTask.DetectKeyPath()); derr != nil {
-			return err
-		}
-		return nil
-	}) != nil {
-		return err
-	}
-
-	return nil
+	return svc.localClient.update(OperationPurge, func(tx StoreTx) error {
+		return svc.localClient.purgeIn(tx, originalTask)
+	})
 }
 
 // Edit edits an existing task
@@ -370,7 +425,7 @@ func (svc *TaskServiceOp) Edit(t *Task) (*Task, error) {
 		return nil, errors.New("cannot edit a task that did not previously exist: " + t.ID)
 	}
 
-	if verr := t.Validate(); verr != nil {
+	if verr := svc.localClient.validateTask(t); verr != nil {
 		return nil, verr
 	}
 
@@ -379,16 +434,8 @@ func (svc *TaskServiceOp) Edit(t *Task) (*Task, error) {
 		return nil, errors.New("editing the Completed field is not yet supported as it changes the path")
 	}
 
-	taskSerial, err := json.Marshal(t)
-	if err != nil {
-		return nil, err
-	}
-
-	if uerr := svc.localClient.DB.Update(func(tx *bolt.Tx) error {
-		if err := svc.localClient.getBucket(tx).Put(t.DetectKeyPath(), taskSerial); err != nil {
-			return err
-		}
-		return nil
+	if uerr := svc.localClient.update(OperationEdit, func(tx StoreTx) error {
+		return svc.localClient.editTask(tx, t)
 	}); uerr != nil {
 		return nil, uerr
 	}
@@ -463,12 +510,19 @@ const DefaultPluginID string = "builtin"
 
 // GetWithPartialID returns using a partial id of the task
 func (svc *TaskServiceOp) GetWithPartialID(partialID, pluginID, state string) (*Task, error) {
-	var possibleStates []string
 	if state == "" {
-		possibleStates = svc.GetStatePaths()
-	} else {
-		possibleStates = append(possibleStates, state)
+		return svc.getWithPartialID(partialID, pluginID, svc.GetStatePaths())
 	}
+	return svc.getWithPartialID(partialID, pluginID, []string{state})
+}
+
+// GetOpenWithPartialID returns an open task using a partial id, looking in
+// active and every workflow state
+func (svc *TaskServiceOp) GetOpenWithPartialID(partialID, pluginID string) (*Task, error) {
+	return svc.getWithPartialID(partialID, pluginID, svc.localClient.OpenStatePaths())
+}
+
+func (svc *TaskServiceOp) getWithPartialID(partialID, pluginID string, possibleStates []string) (*Task, error) {
 	matches := []string{}
 	if pluginID == "" {
 		pluginID = DefaultPluginID
@@ -486,10 +540,21 @@ func (svc *TaskServiceOp) GetWithPartialID(partialID, pluginID, state string) (*
 		}
 	}
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("no matches for %v found in %v", partialID, prefixes)
+		return nil, fmt.Errorf("no matches for %v found in %v", partialID, possibleStates)
 	} else if len(matches) > 1 {
-		return nil, fmt.Errorf(
-			"more than 1 match for %v found in %v, please try using more of the ID. Returned: %v", partialID, prefixes, matches)
+		candidates := Tasks{}
+		for _, match := range matches {
+			task, err := svc.GetWithExactPath([]byte(match))
+			if err != nil {
+				return nil, err
+			}
+			// An exact match always wins over longer IDs it is a prefix of
+			if task.ID == partialID {
+				return task, nil
+			}
+			candidates = append(candidates, task)
+		}
+		return nil, &AmbiguousIDError{PartialID: partialID, Candidates: candidates}
 	}
 	return svc.GetWithExactPath([]byte(matches[0]))
 }
@@ -497,8 +562,11 @@ func (svc *TaskServiceOp) GetWithPartialID(partialID, pluginID, state string) (*
 // GetWithExactPath returns a task from an exact path
 func (svc *TaskServiceOp) GetWithExactPath(path []byte) (*Task, error) {
 	var task Task
-	if err := svc.localClient.DB.View(func(tx *bolt.Tx) error {
-		taskBytes := svc.localClient.getBucket(tx).Get(path)
+	if err := svc.localClient.Store.View(func(tx StoreTx) error {
+		taskBytes, err := tx.Get(svc.localClient.bucket, path)
+		if err != nil {
+			return err
+		}
 		if taskBytes == nil {
 			return fmt.Errorf("could not find task: %s", path)
 		}
@@ -536,17 +604,19 @@ func (svc *TaskServiceOp) GetWithID(id, pluginID, state string) (*Task, error) {
 func (svc *TaskServiceOp) Complete(t *Task) error {
 	activePath := t.DetectKeyPath()
 	t.Completed = nowPTR()
-	completePath := t.DetectKeyPath()
-	if err := svc.localClient.DB.Update(func(tx *bolt.Tx) error {
-		taskSerial, err := json.Marshal(t)
-		if err != nil {
-			return err
+	t.stopTimer(*t.Completed)
+	if err := svc.localClient.update(OperationComplete, func(tx StoreTx) error {
+		before, gerr := svc.localClient.getTaskIn(tx, activePath)
+		if gerr != nil {
+			return gerr
+		}
+		if rerr := svc.localClient.removeTask(tx, activePath); rerr != nil {
+			return rerr
 		}
-		b := svc.localClient.getBucket(tx)
-		if perr := b.Put(completePath, taskSerial); perr != nil {
+		if perr := svc.localClient.putTask(tx, *t); perr != nil {
 			return perr
 		}
-		return b.Delete(activePath)
+		return svc.localClient.recordRevision(tx, OperationComplete, before, t)
 	}); err != nil {
 		return err
 	}
@@ -556,15 +626,13 @@ func (svc *TaskServiceOp) Complete(t *Task) error {
 // List lists items under a given prefix
 func (svc *TaskServiceOp) List(prefix string) (Tasks, error) {
 	var tasks Tasks
-	if err := svc.localClient.DB.View(func(tx *bolt.Tx) error {
-		if err := svc.localClient.getBucket(tx).ForEach(func(k, v []byte) error {
+	if err := svc.localClient.Store.View(func(tx StoreTx) error {
+		if err := tx.ForEach(svc.localClient.bucket, []byte(dirPrefix(prefix)), func(k, v []byte) error {
 			var task Task
 			if err := json.Unmarshal(v, &task); err != nil {
 				return err
 			}
-			if strings.HasPrefix(string(k), prefix) {
-				tasks = append(tasks, &task)
-			}
+			tasks = append(tasks, &task)
 			return nil
 		}); err != nil {
 			return err
@@ -585,56 +653,81 @@ func (svc *TaskServiceOp) Log(t *Task, d *Task) (*Task, error) {
 	return svc.Add(t)
 }
 
-// AddSet adds a task set
+// AddSet adds a task set in a single transaction. If any of the tasks can't
+// be added, none of them are
 func (svc *TaskServiceOp) AddSet(t Tasks) error {
-	for _, task := range t {
-		task := task
-		if _, err := svc.Add(task); err != nil {
-			return err
+	return svc.localClient.update(OperationAdd, func(tx StoreTx) error {
+		for _, task := range t {
+			if err := svc.add(tx, task); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // Add adds a new task
 func (svc *TaskServiceOp) Add(t *Task) (*Task, error) {
+	if uerr := svc.localClient.update(OperationAdd, func(tx StoreTx) error {
+		return svc.add(tx, t)
+	}); uerr != nil {
+		return nil, uerr
+	}
+
+	return t, nil
+}
+
+func (svc *TaskServiceOp) add(tx StoreTx, t *Task) error {
 	// t is the new task
 	t.setDefaults(&svc.localClient.Default)
 
+	if err := svc.localClient.validateTask(t); err != nil {
+		return err
+	}
+
 	// Assign a weight/urgency
 	t.Urgency = svc.localClient.curator.Weigh(*t)
 
 	// Does this already exist??
-	if svc.localClient.exists(t) {
-		return nil, errExists
+	exists, err := svc.existsIn(tx, t)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errExists
 	}
 
-	taskSerial, err := json.Marshal(t)
-	if err != nil {
-		return nil, err
+	if err := svc.localClient.putTask(tx, *t); err != nil {
+		return err
 	}
+	return svc.localClient.recordRevision(tx, OperationAdd, nil, t)
+}
 
-	if uerr := svc.localClient.DB.Update(func(tx *bolt.Tx) error {
-		if perr := svc.localClient.getBucket(tx).Put(t.DetectKeyPath(), taskSerial); perr != nil {
-			return perr
+// existsIn checks every state for the task, inside of an existing transaction
+func (svc *TaskServiceOp) existsIn(tx StoreTx, t *Task) (bool, error) {
+	pluginID := t.PluginID
+	if pluginID == "" {
+		pluginID = DefaultPluginID
+	}
+	for _, state := range svc.GetStatePaths() {
+		got, err := tx.Get(svc.localClient.bucket, []byte(filepath.Join(state, pluginID, t.ID)))
+		if err != nil {
+			return false, err
+		}
+		if got != nil {
+			return true, nil
 		}
-		return nil
-	}); uerr != nil {
-		return nil, uerr
 	}
-
-	return t, nil
+	return false, nil
 }
 
 // GetIDsByPrefix returns a list of ids matching the given prefix
 func (svc *TaskServiceOp) GetIDsByPrefix(prefix string) ([]string, error) {
 	allIDs := []string{}
 
-	if err := svc.localClient.DB.View(func(tx *bolt.Tx) error {
-		if err := svc.localClient.getBucket(tx).ForEach(func(k, v []byte) error {
-			if strings.HasPrefix(string(k), prefix) {
-				allIDs = append(allIDs, string(k))
-			}
+	if err := svc.localClient.Store.View(func(tx StoreTx) error {
+		if err := tx.ForEach(svc.localClient.bucket, []byte(prefix), func(k, v []byte) error {
+			allIDs = append(allIDs, string(k))
 			return nil
 		}); err != nil {
 			return err
@@ -651,26 +744,31 @@ func (svc *TaskServiceOp) GetIDsByPrefix(prefix string) ([]string, error) {
 // autocomplete pattern
 func (p Poet) CompleteIDsWithPrefix(prefix, toComplete string) []string {
 	allIDs := []string{}
+	_, contextQuery := p.contextQuery()
 
-	if err := p.DB.View(func(tx *bolt.Tx) error {
-		if err := p.getBucket(tx).ForEach(func(k, v []byte) error {
-			if strings.HasPrefix(string(k), prefix) {
-				idPieces := strings.Split(string(k), "/")
-				id := idPieces[len(idPieces)-1]
-				var task Task
-				panicIfErr(json.Unmarshal(v, &task))
-				if strings.HasPrefix(id, toComplete) || strings.Contains(task.Description, toComplete) {
-					allIDs = append(allIDs, fmt.Sprintf("%v\t%v", id[0:5], task.Description))
-				}
-			}
+	tasks := Tasks{}
+	if err := p.Store.View(func(tx StoreTx) error {
+		return tx.ForEach(p.bucket, []byte(dirPrefix(prefix)), func(k, v []byte) error {
+			var task Task
+			panicIfErr(json.Unmarshal(v, &task))
+			tasks = append(tasks, &task)
 			return nil
-		}); err != nil {
-			return err
-		}
-		return nil
+		})
 	}); err != nil {
 		return nil
 	}
+	// The context can filter on things like blocked and urgency, which are
+	// only set once the tasks are refreshed
+	p.refresh(tasks)
+
+	for _, task := range tasks {
+		if !contextQuery.Match(*task) {
+			continue
+		}
+		if strings.HasPrefix(task.ID, toComplete) || strings.Contains(task.Description, toComplete) {
+			allIDs = append(allIDs, fmt.Sprintf("%v\t%v", task.ShortID(), task.Description))
+		}
+	}
 
 	return allIDs
 }
@@ -764,10 +862,13 @@ func WithTaskWarriorTask(twItem TaskWarriorTask) TaskOption {
 			t.ID = uuid.New().String()
 		}
 		t.Tags = twItem.Tags
+		t.Project = twItem.Project
+		t.DependsOn = twItem.Depends
 		t.Due = (*time.Time)(twItem.Due)
 		t.Completed = (*time.Time)(twItem.End)
 		t.Reviewed = (*time.Time)(twItem.Reviewed)
 		t.CancelAfter = (*time.Time)(twItem.Until)
+		t.RecurID = twItem.Parent
 		if twItem.Status == "deleted" {
 			t.Deleted = (*time.Time)(twItem.End)
 		}
@@ -776,13 +877,7 @@ func WithTaskWarriorTask(twItem TaskWarriorTask) TaskOption {
 		} else {
 			t.Added = time.Time(*twItem.Entry)
 		}
-		if twItem.Annotations != nil {
-			t.Comments = make([]Comment, len(twItem.Annotations))
-			for idx, a := range twItem.Annotations {
-				t.Comments[idx].Text = a.Description
-				t.Comments[idx].Added = time.Time(*a.Entry)
-			}
-		}
+		t.Comments = twComments(twItem.Annotations, t.Added)
 
 		if (twItem.Wait != nil) && (twItem.Due != nil) && (*time.Time)(twItem.Wait).After((time.Time)(*twItem.Due)) {
 			nh := t.Due.Add(-1 * time.Minute)
@@ -809,6 +904,12 @@ func (t Task) Validate() error {
 		return errors.New("missing description for Task")
 	case strings.Contains(t.ID, "/"):
 		return errors.New("ID Cannot contain a slash (/)")
+	case ValidateProject(t.Project) != nil:
+		return ValidateProject(t.Project)
+	case validateWorkflowState(t.WorkflowState) != nil:
+		return validateWorkflowState(t.WorkflowState)
+	case t.Estimate < 0:
+		return errors.New("estimate cannot be negative")
 		// If both HideUntil and Due are set, make sure HideUntil isn't after Due
 	case (t.HideUntil != nil && t.Due != nil) && t.HideUntil.After(*t.Due):
 		return fmt.Errorf("HideUntil cannot be later than Due")
@@ -817,6 +918,10 @@ func (t Task) Validate() error {
 		return fmt.Errorf("self id is set in the parents, we don't do that")
 	case containsString(t.Children, t.ID):
 		return fmt.Errorf("self id is set in the children, we don't do that")
+	case containsString(t.DependsOn, t.ID):
+		return fmt.Errorf("task cannot depend on itself")
+	case !CheckUniqueStringSlice(t.DependsOn):
+		return fmt.Errorf("found duplicate ids in the DependsOn field")
 	// Make sure Parents contains no duplicates
 	case !CheckUniqueStringSlice(t.Parents):
 		return fmt.Errorf("found duplicate ids in the Parents field")
@@ -825,6 +930,15 @@ func (t Task) Validate() error {
 	}
 }
 
+// validateTask makes sure the task isn't malformed, and that its UDA values fit
+// the Poet's definitions, putting them in their stored form
+func (p *Poet) validateTask(t *Task) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	return p.UDAs.normalizeValues(t.UDAs)
+}
+
 // NewTask returns a new task given functional options
 func NewTask(desc string, options ...TaskOption) (*Task, error) {
 	task := &Task{
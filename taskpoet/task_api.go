@@ -60,8 +60,6 @@ func taskAPIList(c *gin.Context) {
 		})
 	}
 
-	var tasks Tasks
-
 	includeCompleted, err := getBoolParam(c, "include_completed", false)
 	checkAPIErr(c, err)
 
@@ -75,17 +73,21 @@ func taskAPIList(c *gin.Context) {
 			map[string]string{"message": "Must set either include_completed or include_active to true"})
 	}
 
-	if includeActive {
-		aTasks, err := client.Task.List("/active")
-		checkAPIErr(c, err)
-		tasks = append(tasks, aTasks...)
+	q, err := client.ParseQuery(c.Query("q"))
+	if err != nil {
+		c.AbortWithStatusJSON(400, map[string]string{"message": err.Error()})
+		return
 	}
 
+	prefixes := []string{}
+	if includeActive {
+		prefixes = append(prefixes, client.OpenStatePaths()...)
+	}
 	if includeCompleted {
-		cTasks, err := client.Task.List("/completed")
-		checkAPIErr(c, err)
-		tasks = append(tasks, cTasks...)
+		prefixes = append(prefixes, "/completed")
 	}
+	tasks, err := client.listQuery(q, prefixes)
+	checkAPIErr(c, err)
 
 	// Do some calculations
 	totalTasks := len(tasks)
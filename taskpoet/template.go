@@ -0,0 +1,304 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+/*
+Task templates are repeatable procedures, like an on-call handoff, that expand
+in to a parent task with a child for each step. They are declared in the
+config, like:
+
+	templates:
+	  provision:
+	    description: Provision {{host}}
+	    due: 1w
+	    tags: [ops]
+	    vars:
+	      env: staging
+	    steps:
+	      - description: Create the {{env}} VM for {{host}}
+	        due: 2d
+	      - description: Add {{host}} to monitoring
+	        tags: [monitoring]
+
+or stored in the database, at /${namespace}/templates. Variables in
+descriptions are written as {{name}}, and vars holds their defaults. Due dates
+are relative to when the template is applied.
+*/
+
+// TemplateStep is a single child task of a template
+type TemplateStep struct {
+	Description  string       `mapstructure:"description" yaml:"description" json:"description"`
+	Due          string       `mapstructure:"due" yaml:"due,omitempty" json:"due,omitempty"`
+	Tags         []string     `mapstructure:"tags" yaml:"tags,omitempty" json:"tags,omitempty"`
+	EffortImpact EffortImpact `mapstructure:"effort_impact" yaml:"effort_impact,omitempty" json:"effort_impact,omitempty"`
+}
+
+// TaskTemplate is a parent task and the steps that go under it
+type TaskTemplate struct {
+	Description string   `mapstructure:"description" yaml:"description" json:"description"`
+	Due         string   `mapstructure:"due" yaml:"due,omitempty" json:"due,omitempty"`
+	Tags        []string `mapstructure:"tags" yaml:"tags,omitempty" json:"tags,omitempty"`
+	Project     string   `mapstructure:"project" yaml:"project,omitempty" json:"project,omitempty"`
+	// Vars are the default values of variables
+	Vars  map[string]string `mapstructure:"vars" yaml:"vars,omitempty" json:"vars,omitempty"`
+	Steps []TemplateStep    `mapstructure:"steps" yaml:"steps" json:"steps"`
+}
+
+// TaskTemplates maps the name of each template to its definition
+type TaskTemplates map[string]TaskTemplate
+
+// Names returns the template names, sorted
+func (tt TaskTemplates) Names() []string {
+	ret := make([]string, 0, len(tt))
+	for name := range tt {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+var templateVarRe = regexp.MustCompile(`{{\s*([\w-]+)\s*}}`)
+
+// Variables returns the names of every variable the template uses, sorted
+func (t TaskTemplate) Variables() []string {
+	seen := map[string]bool{}
+	for _, s := range t.descriptions() {
+		for _, m := range templateVarRe.FindAllStringSubmatch(s, -1) {
+			seen[m[1]] = true
+		}
+	}
+	ret := make([]string, 0, len(seen))
+	for name := range seen {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (t TaskTemplate) descriptions() []string {
+	ret := []string{t.Description}
+	for _, step := range t.Steps {
+		ret = append(ret, step.Description)
+	}
+	return ret
+}
+
+// Validate makes sure the template can be applied
+func (t TaskTemplate) Validate() error {
+	switch {
+	case t.Description == "":
+		return errors.New("template is missing a description")
+	case len(t.Steps) == 0:
+		return errors.New("template needs at least one step")
+	}
+	for idx, step := range t.Steps {
+		if step.Description == "" {
+			return fmt.Errorf("step %v is missing a description", idx+1)
+		}
+	}
+	return ValidateProject(t.Project)
+}
+
+// interpolate fills in the variables of s. Expand makes sure they all have a
+// value first
+func interpolate(s string, vars map[string]string) string {
+	return templateVarRe.ReplaceAllStringFunc(s, func(m string) string {
+		return vars[templateVarRe.FindStringSubmatch(m)[1]]
+	})
+}
+
+// Expand returns the parent task and its children, in order, with vars
+// filled in over the defaults. Nothing is stored
+func (t TaskTemplate) Expand(vars map[string]string, cal *Calendar) (Tasks, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	merged := map[string]string{}
+	for k, v := range t.Vars {
+		merged[k] = v
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	if missing := missingVars(t.Variables(), merged); len(missing) > 0 {
+		return nil, fmt.Errorf("missing template variables: %v", strings.Join(missing, ", "))
+	}
+
+	parent, err := t.task(t.Description, t.Due, t.Tags, EffortImpactUnset, merged, cal)
+	if err != nil {
+		return nil, err
+	}
+	ret := Tasks{parent}
+	graph := linkGraph(ret)
+	for _, step := range t.Steps {
+		child, err := t.task(step.Description, step.Due, append(append([]string{}, t.Tags...), step.Tags...), step.EffortImpact, merged, cal)
+		if err != nil {
+			return nil, err
+		}
+		if err := addLink(graph, parent, child); err != nil {
+			return nil, err
+		}
+		ret = append(ret, child)
+	}
+	return ret, nil
+}
+
+func missingVars(names []string, vars map[string]string) []string {
+	ret := []string{}
+	for _, name := range names {
+		if _, ok := vars[name]; !ok {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// task returns a single task of the template
+func (t TaskTemplate) task(desc, due string, tags []string, ei EffortImpact, vars map[string]string, cal *Calendar) (*Task, error) {
+	desc = interpolate(desc, vars)
+	opts := []TaskOption{
+		WithID(uuid.New().String()),
+		WithProject(t.Project),
+		WithTags(tags),
+		WithEffortImpact(ei),
+	}
+	if due != "" {
+		d, err := cal.Date(due)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due for %q: %w", desc, err)
+		}
+		opts = append(opts, WithDue(d))
+	}
+	return NewTask(desc, opts...)
+}
+
+// WithTaskTemplates sets the templates from the config
+func WithTaskTemplates(tt TaskTemplates) Option {
+	for _, name := range tt.Names() {
+		if err := tt[name].Validate(); err != nil {
+			return failure(fmt.Errorf("template %v: %w", name, err))
+		}
+	}
+	return success(func(p *Poet) {
+		p.TaskTemplates = tt
+	})
+}
+
+// Templates returns the templates from the config along with the stored ones
+func (p *Poet) Templates() (TaskTemplates, error) {
+	ret := TaskTemplates{}
+	for name, t := range p.TaskTemplates {
+		ret[name] = t
+	}
+	if err := p.Store.View(func(tx StoreTx) error {
+		return tx.ForEach(p.templateBucket, nil, func(k, v []byte) error {
+			var t TaskTemplate
+			if err := json.Unmarshal(v, &t); err != nil {
+				return fmt.Errorf("could not decode template %s: %w", k, err)
+			}
+			// The config wins over anything stored before it was there
+			if _, ok := ret[string(k)]; !ok {
+				ret[string(k)] = t
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SaveTemplate stores a template in the database, replacing any stored one
+// with the same name. Templates from the config can't be replaced
+func (p *Poet) SaveTemplate(name string, t TaskTemplate) error {
+	if name == "" || strings.ContainsAny(name, "/ ") {
+		return fmt.Errorf("invalid template name: %q", name)
+	}
+	if _, ok := p.TaskTemplates[name]; ok {
+		return fmt.Errorf("template is defined in the config: %v", name)
+	}
+	if err := t.Validate(); err != nil {
+		return fmt.Errorf("template %v: %w", name, err)
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return p.Store.Update(func(tx StoreTx) error {
+		return tx.Put(p.templateBucket, []byte(name), b)
+	})
+}
+
+// DeleteTemplate removes a stored template
+func (p *Poet) DeleteTemplate(name string) error {
+	if _, ok := p.TaskTemplates[name]; ok {
+		return fmt.Errorf("template is defined in the config, remove it there instead: %v", name)
+	}
+	return p.Store.Update(func(tx StoreTx) error {
+		got, err := tx.Get(p.templateBucket, []byte(name))
+		if err != nil {
+			return err
+		}
+		if got == nil {
+			return fmt.Errorf("no such template: %v", name)
+		}
+		return tx.Delete(p.templateBucket, []byte(name))
+	})
+}
+
+// ApplyTemplate expands the named template and adds the whole tree in a single
+// transaction. The parent comes first in the returned tasks
+func (p *Poet) ApplyTemplate(name string, vars map[string]string) (Tasks, error) {
+	templates, err := p.Templates()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("no such template: %v", name)
+	}
+	tasks, err := t.Expand(vars, NewCalendar())
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Task.AddSet(tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ParseTemplateVars parses key=value arguments in to template variables
+func ParseTemplateVars(args []string) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("template variables look like key=value, not: %v", arg)
+		}
+		ret[k] = v
+	}
+	return ret, nil
+}
+
+// TemplateTable returns a table of the templates
+func (p *Poet) TemplateTable(tt TaskTemplates) string {
+	rows := [][]string{}
+	for _, name := range tt.Names() {
+		t := tt[name]
+		source := "db"
+		if _, ok := p.TaskTemplates[name]; ok {
+			source = "config"
+		}
+		rows = append(rows, []string{name, t.Description, fmt.Sprint(len(t.Steps)), strings.Join(t.Variables(), ","), source})
+	}
+	return p.listTable([]string{"Name", "Description", "Steps", "Variables", "Source"}, rows)
+}
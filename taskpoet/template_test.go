@@ -0,0 +1,102 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testTemplate = TaskTemplate{
+	Description: "Provision {{host}}",
+	Due:         "1w",
+	Tags:        []string{"ops"},
+	Project:     "work.infra",
+	Vars:        map[string]string{"env": "staging"},
+	Steps: []TemplateStep{
+		{Description: "Create the {{ env }} VM for {{host}}", Due: "2d"},
+		{Description: "Add {{host}} to monitoring", Tags: []string{"monitoring"}, EffortImpact: EffortImpactHigh},
+	},
+}
+
+func TestTemplateExpand(t *testing.T) {
+	require.Equal(t, []string{"env", "host"}, testTemplate.Variables())
+
+	_, err := testTemplate.Expand(nil, NewCalendar())
+	require.EqualError(t, err, "missing template variables: host")
+
+	now := time.Now()
+	tasks, err := testTemplate.Expand(map[string]string{"host": "db3"}, NewCalendar(WithPresent(&now)))
+	require.NoError(t, err)
+	require.Equal(t, 3, len(tasks))
+	parent := tasks[0]
+	require.Equal(t, "Provision db3", parent.Description)
+	require.Equal(t, now.Add(7*24*time.Hour), *parent.Due)
+	require.Equal(t, []string{tasks[1].ID, tasks[2].ID}, parent.Children)
+
+	require.Equal(t, "Create the staging VM for db3", tasks[1].Description)
+	require.Equal(t, []string{parent.ID}, tasks[1].Parents)
+	require.Equal(t, "work.infra", tasks[1].Project)
+	require.Equal(t, []string{"monitoring", "ops"}, tasks[2].Tags)
+	require.Equal(t, EffortImpactHigh, tasks[2].EffortImpact)
+
+	// Given variables win over the defaults
+	tasks, err = testTemplate.Expand(map[string]string{"host": "db3", "env": "prod"}, NewCalendar())
+	require.NoError(t, err)
+	require.Equal(t, "Create the prod VM for db3", tasks[1].Description)
+
+	bad := testTemplate
+	bad.Steps = []TemplateStep{{Description: "sometime", Due: "whenever"}}
+	_, err = bad.Expand(map[string]string{"host": "db3"}, NewCalendar())
+	require.Error(t, err)
+	require.Error(t, TaskTemplate{Description: "no steps"}.Validate())
+}
+
+func TestApplyTemplate(t *testing.T) {
+	p, err := New(
+		WithDatabasePath(mustTempDB(t)),
+		WithTaskTemplates(TaskTemplates{"provision": testTemplate}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, p.SaveTemplate("handoff", TaskTemplate{
+		Description: "Hand on-call to {{to}}",
+		Steps:       []TemplateStep{{Description: "Review open incidents"}, {Description: "Walk {{to}} through the runbook"}},
+	}))
+	require.Error(t, p.SaveTemplate("provision", testTemplate))
+	require.Error(t, p.SaveTemplate("has space", testTemplate))
+
+	templates, err := p.Templates()
+	require.NoError(t, err)
+	require.Equal(t, []string{"handoff", "provision"}, templates.Names())
+	require.Contains(t, p.TemplateTable(templates), "config")
+
+	_, err = p.ApplyTemplate("nope", nil)
+	require.EqualError(t, err, "no such template: nope")
+	_, err = p.ApplyTemplate("handoff", nil)
+	require.Error(t, err)
+	require.Equal(t, 0, len(p.MustList("/active")))
+
+	tasks, err := p.ApplyTemplate("handoff", map[string]string{"to": "sam"})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(p.MustList("/active")))
+	parent, err := p.Task.GetWithID(tasks[0].ID, "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(parent.Children))
+
+	// The whole tree is a single operation
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(p.MustList("/active")))
+
+	require.Error(t, p.DeleteTemplate("provision"))
+	require.NoError(t, p.DeleteTemplate("handoff"))
+	require.Error(t, p.DeleteTemplate("handoff"))
+}
+
+func TestParseTemplateVars(t *testing.T) {
+	got, err := ParseTemplateVars([]string{"host=db3", "note=a=b", "empty="})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "db3", "note": "a=b", "empty": ""}, got)
+	_, err = ParseTemplateVars([]string{"nope"})
+	require.Error(t, err)
+}
@@ -0,0 +1,223 @@
+package taskpoet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+/*
+Time spent on a task is kept as a list of intervals on the task. The last
+interval of a task that is being worked on has no End. Only one task runs at a
+time, so starting a task stops whatever was running before.
+*/
+
+// ErrNotRunning is returned when stopping while nothing is running
+var ErrNotRunning = errors.New("no task is running")
+
+// Interval is a stretch of time spent working on a task
+type Interval struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// Duration is how long the interval lasted, or has lasted so far
+func (i Interval) Duration() time.Duration {
+	if i.End == nil {
+		return time.Since(i.Start)
+	}
+	return i.End.Sub(i.Start)
+}
+
+// IsRunning is true when the task is being worked on right now
+func (t Task) IsRunning() bool {
+	return len(t.Intervals) > 0 && t.Intervals[len(t.Intervals)-1].End == nil
+}
+
+// Spent is the total time worked on the task
+func (t Task) Spent() time.Duration {
+	var total time.Duration
+	for _, i := range t.Intervals {
+		total += i.Duration()
+	}
+	return total
+}
+
+// stopTimer closes the running interval, if there is one
+func (t *Task) stopTimer(at time.Time) {
+	if t.IsRunning() {
+		t.Intervals[len(t.Intervals)-1].End = &at
+	}
+}
+
+// clockDuration formats a duration as hours and minutes, like 1:05
+func clockDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Running returns the task being worked on, or nil if there isn't one
+func (p *Poet) Running() (*Task, error) {
+	active, err := p.ListOpen()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range active {
+		if t.IsRunning() {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// Start starts working on an active task. Whatever task was running is stopped
+// first, and returned
+func (p *Poet) Start(t *Task) (*Task, error) {
+	if !t.IsOpen() {
+		return nil, fmt.Errorf("only active tasks can be started: %v", t.ID)
+	}
+	if t.IsRunning() {
+		return nil, fmt.Errorf("task is already running: %v", t.ID)
+	}
+	running, err := p.Running()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := p.update(OperationStart, func(tx StoreTx) error {
+		if running != nil {
+			running.stopTimer(now)
+			if err := p.editTask(tx, running); err != nil {
+				return err
+			}
+		}
+		t.Intervals = append(t.Intervals, Interval{Start: now})
+		return p.editTask(tx, t)
+	}); err != nil {
+		return nil, err
+	}
+	return running, nil
+}
+
+// Stop stops working on the running task, and returns it
+func (p *Poet) Stop() (*Task, error) {
+	running, err := p.Running()
+	if err != nil {
+		return nil, err
+	}
+	if running == nil {
+		return nil, ErrNotRunning
+	}
+	running.stopTimer(time.Now())
+	if err := p.update(OperationStop, func(tx StoreTx) error {
+		return p.editTask(tx, running)
+	}); err != nil {
+		return nil, err
+	}
+	return running, nil
+}
+
+// TimesheetRow is the time spent on a single day, tag or project
+type TimesheetRow struct {
+	Name  string
+	Spent time.Duration
+}
+
+// Timesheet is the time worked between Start and End. A task with more than
+// one tag counts towards each of them, so ByTag can add up to more than Total
+type Timesheet struct {
+	Start     time.Time
+	End       time.Time
+	Total     time.Duration
+	ByDay     []TimesheetRow
+	ByTag     []TimesheetRow
+	ByProject []TimesheetRow
+}
+
+// noneName is used for time on tasks without a tag or project
+const noneName = "(none)"
+
+// Timesheet adds up the time worked on active and completed tasks between
+// start and end. Intervals are cut at the edges of the range, and split at
+// midnight so each day gets its own share
+func (p *Poet) Timesheet(start, end time.Time) (*Timesheet, error) {
+	days, tags, projects := map[string]time.Duration{}, map[string]time.Duration{}, map[string]time.Duration{}
+	sheet := &Timesheet{Start: start, End: end}
+	now := time.Now()
+	for _, state := range append(p.OpenStatePaths(), "/completed") {
+		tasks, err := p.Task.List(state)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			for _, i := range t.Intervals {
+				from, to := i.Start, now
+				if i.End != nil {
+					to = *i.End
+				}
+				if from.Before(start) {
+					from = start
+				}
+				if to.After(end) {
+					to = end
+				}
+				if !to.After(from) {
+					continue
+				}
+				spent := to.Sub(from)
+				sheet.Total += spent
+				for day := from; day.Before(to); day = floorDay(day.AddDate(0, 0, 1)) {
+					dayEnd := floorDay(day.AddDate(0, 0, 1))
+					if dayEnd.After(to) {
+						dayEnd = to
+					}
+					days[day.Format("2006-01-02 Mon")] += dayEnd.Sub(day)
+				}
+				if len(t.Tags) == 0 {
+					tags[noneName] += spent
+				}
+				for _, tag := range t.Tags {
+					tags[tag] += spent
+				}
+				project := t.Project
+				if project == "" {
+					project = noneName
+				}
+				projects[project] += spent
+			}
+		}
+	}
+	sheet.ByDay = timesheetRows(days)
+	sheet.ByTag = timesheetRows(tags)
+	sheet.ByProject = timesheetRows(projects)
+	return sheet, nil
+}
+
+func timesheetRows(m map[string]time.Duration) []TimesheetRow {
+	ret := make([]TimesheetRow, 0, len(m))
+	for name, spent := range m {
+		ret = append(ret, TimesheetRow{Name: name, Spent: spent})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// TimesheetTable renders a timesheet as a table for each grouping
+func (p *Poet) TimesheetTable(sheet Timesheet) string {
+	table := func(header string, rows []TimesheetRow) string {
+		cells := make([][]string, len(rows))
+		for idx, row := range rows {
+			cells[idx] = []string{row.Name, clockDuration(row.Spent)}
+		}
+		cells = append(cells, []string{"Total", clockDuration(sheet.Total)})
+		return p.listTable([]string{header, "Spent"}, cells)
+	}
+	return fmt.Sprintf("%v\n%v\n%v\n",
+		table("Day", sheet.ByDay),
+		table("Tag", sheet.ByTag),
+		table("Project", sheet.ByProject),
+	)
+}
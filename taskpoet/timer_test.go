@@ -0,0 +1,123 @@
+package taskpoet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartStop(t *testing.T) {
+	p := newTestPoet(t)
+	certs, err := p.Task.Add(MustNewTask("get certs", WithID("certs")))
+	require.NoError(t, err)
+	dns, err := p.Task.Add(MustNewTask("update dns", WithID("dns")))
+	require.NoError(t, err)
+
+	_, err = p.Stop()
+	require.ErrorIs(t, err, ErrNotRunning)
+
+	stopped, err := p.Start(certs)
+	require.NoError(t, err)
+	require.Nil(t, stopped)
+	_, err = p.Start(certs)
+	require.EqualError(t, err, "task is already running: certs")
+
+	// Only one task runs at a time
+	stopped, err = p.Start(dns)
+	require.NoError(t, err)
+	require.Equal(t, "certs", stopped.ID)
+	running, err := p.Running()
+	require.NoError(t, err)
+	require.Equal(t, "dns", running.ID)
+
+	stopped, err = p.Stop()
+	require.NoError(t, err)
+	require.Equal(t, "dns", stopped.ID)
+	running, err = p.Running()
+	require.NoError(t, err)
+	require.Nil(t, running)
+
+	got, err := p.Task.GetWithID("certs", "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got.Intervals))
+	require.False(t, got.IsRunning())
+
+	// Completing a running task stops it
+	_, err = p.Start(got)
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Complete(got))
+	got, err = p.Task.GetWithID("certs", "", "/completed")
+	require.NoError(t, err)
+	require.False(t, got.IsRunning())
+	require.Equal(t, 2, len(got.Intervals))
+	_, err = p.Start(got)
+	require.EqualError(t, err, "only active tasks can be started: certs")
+
+	entries, err := p.Journal()
+	require.NoError(t, err)
+	require.Equal(t, OperationStart, entries[2].Operation)
+}
+
+func TestSpentAndColumns(t *testing.T) {
+	start := time.Now().Add(-90 * time.Minute)
+	end := start.Add(time.Hour)
+	task := Task{Intervals: []Interval{{Start: start, End: &end}}}
+	p := &Poet{}
+	require.Equal(t, time.Hour, task.Spent())
+	require.Equal(t, "1:00", p.mustColumnValue("Spent", task))
+	require.Equal(t, "", p.mustColumnValue("Active", task))
+
+	task.Intervals = append(task.Intervals, Interval{Start: time.Now().Add(-5 * time.Minute)})
+	require.True(t, task.IsRunning())
+	require.Equal(t, "0:05", p.mustColumnValue("Active", task))
+	require.Equal(t, "1:05", p.mustColumnValue("Spent", task))
+	require.Equal(t, "", p.mustColumnValue("Spent", Task{}))
+}
+
+func TestTimesheet(t *testing.T) {
+	p := newTestPoet(t)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	at := func(hours float64) time.Time {
+		return day.Add(time.Duration(hours * float64(time.Hour)))
+	}
+	interval := func(from, to float64) Interval {
+		end := at(to)
+		return Interval{Start: at(from), End: &end}
+	}
+	_, err := p.Task.Add(MustNewTask("dns", WithTags([]string{"ops", "dns"}), WithProject("work"),
+		func(t *Task) { t.Intervals = []Interval{interval(9, 11), interval(23, 25)} }))
+	require.NoError(t, err)
+	done := MustNewTask("lunch", func(t *Task) { t.Intervals = []Interval{interval(-2, 1)} })
+	_, err = p.Task.Log(done, nil)
+	require.NoError(t, err)
+
+	sheet, err := p.Timesheet(day, at(48))
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Hour, sheet.Total)
+	require.Equal(t, []TimesheetRow{
+		{Name: "2024-03-04 Mon", Spent: 4 * time.Hour},
+		{Name: "2024-03-05 Tue", Spent: time.Hour},
+	}, sheet.ByDay)
+	require.Equal(t, []TimesheetRow{
+		{Name: "(none)", Spent: time.Hour},
+		{Name: "dns", Spent: 4 * time.Hour},
+		{Name: "ops", Spent: 4 * time.Hour},
+	}, sheet.ByTag)
+	require.Equal(t, []TimesheetRow{
+		{Name: "(none)", Spent: time.Hour},
+		{Name: "work", Spent: 4 * time.Hour},
+	}, sheet.ByProject)
+	require.Contains(t, p.TimesheetTable(*sheet), "5:00")
+}
+
+func TestCalendarPast(t *testing.T) {
+	present := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	c := NewCalendar(WithPresent(&present))
+	got, err := c.Past("2d")
+	require.NoError(t, err)
+	require.Equal(t, present.AddDate(0, 0, -2), *got)
+	got, err = c.Past("socw")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), *got)
+}
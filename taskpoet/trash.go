@@ -0,0 +1,115 @@
+package taskpoet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Trash returns the deleted tasks, most recently deleted first
+func (p *Poet) Trash() (Tasks, error) {
+	tasks, err := p.Task.List("/deleted")
+	if err != nil {
+		return nil, err
+	}
+	tasks.SortBy(ByDeleted{})
+	return tasks, nil
+}
+
+// RestoreDeleted brings a deleted task back to the state it was deleted from.
+// Expired tasks lose their CancelAfter, so they don't expire again right away
+func (p *Poet) RestoreDeleted(t *Task) error {
+	if t.Deleted == nil {
+		return fmt.Errorf("task is not deleted: %v", t.ID)
+	}
+	deletedPath := t.DetectKeyPath()
+	restored := *t
+	restored.Deleted = nil
+	if t.IsExpired() {
+		restored.CancelAfter = nil
+	}
+	restored.DeletedReason = ""
+	if err := p.update(OperationRestore, func(tx StoreTx) error {
+		before, err := p.getTaskIn(tx, deletedPath)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("could not find task: %s", deletedPath)
+		}
+		existing, err := p.getTaskIn(tx, restored.DetectKeyPath())
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("a task already exists at %s", restored.DetectKeyPath())
+		}
+		if err := p.removeTask(tx, deletedPath); err != nil {
+			return err
+		}
+		if err := p.putTask(tx, restored); err != nil {
+			return err
+		}
+		return p.recordRevision(tx, OperationRestore, before, &restored)
+	}); err != nil {
+		return err
+	}
+	*t = restored
+	return nil
+}
+
+// EmptyTrash purges every task that was deleted more than olderThan ago, and
+// returns the purged tasks. An olderThan of 0 empties the whole trash
+func (p *Poet) EmptyTrash(olderThan time.Duration) (Tasks, error) {
+	if olderThan < 0 {
+		return nil, errors.New("olderThan cannot be negative")
+	}
+	cutoff := time.Now().Add(-olderThan)
+	trash, err := p.Trash()
+	if err != nil {
+		return nil, err
+	}
+	purged := Tasks{}
+	for _, t := range trash {
+		if t.Deleted.Before(cutoff) {
+			purged = append(purged, t)
+		}
+	}
+	if len(purged) == 0 {
+		return purged, nil
+	}
+	if err := p.update(OperationPurge, func(tx StoreTx) error {
+		for _, t := range purged {
+			if err := p.purgeIn(tx, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// AutoPurgeTrash empties the trash of anything older than retention, every
+// interval, until ctx is done
+func (p *Poet) AutoPurgeTrash(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := p.EmptyTrash(retention)
+		if err != nil {
+			log.Warn("Could not purge the trash", "error", err)
+		} else if len(purged) > 0 {
+			log.Info("Purged old tasks from the trash", "count", len(purged), "retention", retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
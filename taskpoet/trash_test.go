@@ -0,0 +1,92 @@
+package taskpoet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashRestore(t *testing.T) {
+	p := newTestPoet(t)
+	active, err := p.Task.Add(MustNewTask("active", WithID("active")))
+	require.NoError(t, err)
+	done, err := p.Task.Add(MustNewTask("done", WithID("done")))
+	require.NoError(t, err)
+	require.NoError(t, p.Task.Complete(done))
+	require.NoError(t, p.Delete(active))
+	require.NoError(t, p.Delete(done))
+
+	trash, err := p.Trash()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(trash))
+	// Most recently deleted first
+	require.Equal(t, "done", trash[0].ID)
+
+	require.EqualError(t, p.RestoreDeleted(MustNewTask("never deleted", WithID("never"))), "task is not deleted: never")
+
+	// Each task goes back to where it came from
+	require.NoError(t, p.RestoreDeleted(trash[0]))
+	require.Equal(t, "/completed/builtin/done", string(trash[0].DetectKeyPath()))
+	require.NoError(t, p.RestoreDeleted(trash[1]))
+	_, err = p.Task.GetWithID("active", "", "/active")
+	require.NoError(t, err)
+	_, err = p.Task.GetWithID("done", "", "/completed")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(p.MustList("/deleted")))
+
+	revs, err := p.History(*trash[1])
+	require.NoError(t, err)
+	require.Equal(t, OperationRestore, revs[len(revs)-1].Operation)
+
+	// And can be undone
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(p.MustList("/deleted")))
+}
+
+func TestEmptyTrash(t *testing.T) {
+	p := newTestPoet(t)
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	require.NoError(t, p.Task.AddSet(Tasks{
+		{ID: "old", Description: "old", Deleted: &old},
+		{ID: "recent", Description: "recent", Deleted: &recent},
+		{ID: "active", Description: "active"},
+	}))
+
+	_, err := p.EmptyTrash(-1)
+	require.Error(t, err)
+
+	purged, err := p.EmptyTrash(MustParseDuration("90d"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(purged))
+	require.Equal(t, "old", purged[0].ID)
+	require.Equal(t, 1, len(p.MustList("/deleted")))
+
+	purged, err = p.EmptyTrash(0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(purged))
+	require.Equal(t, 0, len(p.MustList("/deleted")))
+	require.Equal(t, 1, len(p.MustList("/active")))
+}
+
+func TestAutoPurgeTrash(t *testing.T) {
+	p := newTestPoet(t)
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	_, err := p.Task.Add(&Task{ID: "old", Description: "old", Deleted: &old})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		p.AutoPurgeTrash(ctx, MustParseDuration("90d"), time.Hour)
+		close(finished)
+	}()
+	require.Eventually(t, func() bool {
+		return len(p.MustList("/deleted")) == 0
+	}, time.Second*5, time.Millisecond*10)
+	cancel()
+	<-finished
+}
@@ -0,0 +1,287 @@
+package taskpoet
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+User defined attributes (UDAs) are extra, typed fields declared in the config,
+like:
+
+	udas:
+	  ticket:
+	    type: string
+	  env:
+	    type: enum
+	    values: [prod, staging, dev]
+	    value_urgency:
+	      prod: 3
+	  points:
+	    type: number
+	    urgency: 0.5
+
+Values are stored as strings in Task.UDAs, in a normalized form for their type.
+Definitions belong to a Poet, which checks the values of tasks as they are
+saved.
+*/
+
+// UDAType is the type of value a UDA holds
+type UDAType string
+
+const (
+	// UDAString is any text
+	UDAString UDAType = "string"
+	// UDANumber is a number, like 3 or 2.5
+	UDANumber UDAType = "number"
+	// UDADate is a point in time, stored as RFC3339
+	UDADate UDAType = "date"
+	// UDADuration is a length of time, like 3d or 2h
+	UDADuration UDAType = "duration"
+	// UDAEnum is one of a fixed set of values
+	UDAEnum UDAType = "enum"
+)
+
+// UDA defines a single user defined attribute
+type UDA struct {
+	Type  UDAType `mapstructure:"type" yaml:"type"`
+	Label string  `mapstructure:"label" yaml:"label"`
+	// Values are the allowed values of an enum
+	Values []string `mapstructure:"values" yaml:"values"`
+	// Urgency is added to a task that has the UDA set. Numbers add their
+	// value times Urgency instead
+	Urgency float64 `mapstructure:"urgency" yaml:"urgency"`
+	// ValueUrgency is added to a task with a given enum value
+	ValueUrgency map[string]float64 `mapstructure:"value_urgency" yaml:"value_urgency"`
+}
+
+// UDAs maps the name of each UDA to its definition
+type UDAs map[string]UDA
+
+// Names returns the UDA names, sorted
+func (u UDAs) Names() []string {
+	ret := make([]string, 0, len(u))
+	for name := range u {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// udaNameRe matches the names a query can use as a field. Dots are left out,
+// since they start a modifier, like due.before
+var udaNameRe = regexp.MustCompile(`^[a-zA-Z][\w-]*$`)
+
+// Validate makes sure every definition is usable
+func (u UDAs) Validate() error {
+	for _, name := range u.Names() {
+		def := u[name]
+		if !udaNameRe.MatchString(name) {
+			return fmt.Errorf("invalid uda name: %q", name)
+		}
+		if _, ok := columnMap[name]; ok {
+			return fmt.Errorf("uda %v has the same name as a built in column", name)
+		}
+		if isQueryField(name) {
+			return fmt.Errorf("uda %v has the same name as a built in query field", name)
+		}
+		switch def.Type {
+		case UDAString, UDANumber, UDADate, UDADuration:
+			if len(def.Values) > 0 {
+				return fmt.Errorf("uda %v: only enums can have values", name)
+			}
+		case UDAEnum:
+			if len(def.Values) == 0 {
+				return fmt.Errorf("uda %v: enums need at least one value", name)
+			}
+			for value := range def.ValueUrgency {
+				if !containsString(def.Values, value) {
+					return fmt.Errorf("uda %v: urgency set for unknown value %v", name, value)
+				}
+			}
+		default:
+			return fmt.Errorf("uda %v: unknown type %q", name, def.Type)
+		}
+	}
+	return nil
+}
+
+// WithUDAs sets the UDA definitions, and adds their urgency to the curator
+func WithUDAs(u UDAs) Option {
+	if err := u.Validate(); err != nil {
+		return failure(err)
+	}
+	return success(func(p *Poet) {
+		p.UDAs = u
+		p.curator.weights = u.weights(p.curator.weights)
+	})
+}
+
+// WithUDA sets a UDA on create. Values are checked, and normalized, by the Poet
+// when the task is added
+func WithUDA(name, value string) TaskOption {
+	return func(t *Task) {
+		if t.UDAs == nil {
+			t.UDAs = map[string]string{}
+		}
+		t.UDAs[name] = value
+	}
+}
+
+// Normalize checks a value given by a person, and returns it in the form it is
+// stored. Dates may be anything Calendar.Date understands
+func (u UDAs) Normalize(name, value string) (string, error) {
+	def, ok := u[name]
+	if !ok {
+		return "", fmt.Errorf("unknown uda: %v", name)
+	}
+	if def.Type == UDADate {
+		if _, err := time.Parse(time.RFC3339, value); err == nil {
+			return value, nil
+		}
+		if d, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+			return d.Format(time.RFC3339), nil
+		}
+		d, err := NewCalendar().Date(value)
+		if err != nil {
+			return "", fmt.Errorf("uda %v: invalid date %q", name, value)
+		}
+		return d.Format(time.RFC3339), nil
+	}
+	return def.normalize(name, value)
+}
+
+// ParseFlags parses name=value pairs in to normalized UDA values
+func (u UDAs) ParseFlags(pairs []string) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("uda must be name=value, got: %v", pair)
+		}
+		normalized, err := u.Normalize(name, value)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = normalized
+	}
+	return ret, nil
+}
+
+// normalize checks a stored value, and returns its canonical form
+func (def UDA) normalize(name, value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("uda %v: value cannot be empty", name)
+	}
+	switch def.Type {
+	case UDANumber:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("uda %v: invalid number %q", name, value)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case UDADate:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "", fmt.Errorf("uda %v: invalid date %q", name, value)
+		}
+	case UDADuration:
+		d, err := ParseDuration(value)
+		if err != nil {
+			return "", fmt.Errorf("uda %v: invalid duration %q", name, value)
+		}
+		return d.String(), nil
+	case UDAEnum:
+		if !containsString(def.Values, value) {
+			return "", fmt.Errorf("uda %v: %q is not one of %v", name, value, strings.Join(def.Values, ", "))
+		}
+	}
+	return value, nil
+}
+
+// normalizeValues checks the value of every defined UDA on a task, and puts it
+// in its stored form. Values of UDAs that are no longer defined are kept as is
+func (u UDAs) normalizeValues(values map[string]string) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def, ok := u[name]
+		if !ok {
+			continue
+		}
+		normalized, err := def.normalize(name, values[name])
+		if err != nil {
+			return err
+		}
+		values[name] = normalized
+	}
+	return nil
+}
+
+// columnValue shows a UDA in a table. Dates are shown like Due
+func (u UDAs) columnValue(name string, t Task) (string, bool) {
+	def, ok := u[name]
+	if !ok {
+		return "", false
+	}
+	value := t.UDAs[name]
+	if def.Type == UDADate && value != "" {
+		if d, err := time.Parse(time.RFC3339, value); err == nil {
+			return shortDuration(time.Since(d) * -1), true
+		}
+	}
+	return value, true
+}
+
+// FilterUDA keeps the tasks that have every UDA value in the FilterParams
+func FilterUDA(p *FilterParams, task Task) bool {
+	for name, value := range p.UDAs {
+		if task.UDAs[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// weights adds a weighter for each UDA with an urgency to the given weights
+func (u UDAs) weights(base weightMap) weightMap {
+	ret := make(weightMap, len(base)+len(u))
+	for name, w := range base {
+		ret[name] = w
+	}
+	for _, name := range u.Names() {
+		name, def := name, u[name]
+		if def.Urgency == 0 && len(def.ValueUrgency) == 0 {
+			continue
+		}
+		ret["uda."+name] = func(t Task) (float64, int, string) {
+			value, ok := t.UDAs[name]
+			if !ok {
+				return 0, 0, ""
+			}
+			switch {
+			case def.Type == UDANumber:
+				f, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return 0, 0, ""
+				}
+				return def.Urgency * f, 1, fmt.Sprintf("%v is %v", name, value)
+			case def.ValueUrgency != nil:
+				if w, ok := def.ValueUrgency[value]; ok {
+					return w, 1, fmt.Sprintf("%v is %v", name, value)
+				}
+			}
+			if def.Urgency == 0 {
+				return 0, 0, ""
+			}
+			return def.Urgency, 1, fmt.Sprintf("has %v", name)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,142 @@
+package taskpoet
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testUDAs = UDAs{
+	"ticket": {Type: UDAString, Label: "Ticket"},
+	"env":    {Type: UDAEnum, Values: []string{"prod", "staging"}, ValueUrgency: map[string]float64{"prod": 3}},
+	"points": {Type: UDANumber, Urgency: 0.5},
+	"when":   {Type: UDADate},
+	"spent":  {Type: UDADuration, Urgency: 1},
+}
+
+func newUDAPoet(t *testing.T) *Poet {
+	return MustNew(WithDatabasePath(mustTempDB(t)), WithUDAs(testUDAs))
+}
+
+func TestUDAsValidate(t *testing.T) {
+	require.NoError(t, testUDAs.Validate())
+	require.EqualError(t, UDAs{"x": {Type: "color"}}.Validate(), `uda x: unknown type "color"`)
+	require.EqualError(t, UDAs{"x": {Type: UDAEnum}}.Validate(), "uda x: enums need at least one value")
+	require.EqualError(t, UDAs{"x": {Type: UDAString, Values: []string{"a"}}}.Validate(), "uda x: only enums can have values")
+	require.EqualError(t, UDAs{"Due": {Type: UDADate}}.Validate(), "uda Due has the same name as a built in column")
+	require.EqualError(t, UDAs{"a=b": {Type: UDAString}}.Validate(), `invalid uda name: "a=b"`)
+	require.EqualError(t, UDAs{"ticket.id": {Type: UDAString}}.Validate(), `invalid uda name: "ticket.id"`)
+	require.EqualError(t, UDAs{"blocked": {Type: UDAString}}.Validate(), "uda blocked has the same name as a built in query field")
+	require.Error(t, UDAs{"proj": {Type: UDAString}}.Validate())
+	require.Error(t, UDAs{"wait": {Type: UDADate}}.Validate())
+	require.Error(t, UDAs{"x": {Type: UDAEnum, Values: []string{"a"}, ValueUrgency: map[string]float64{"b": 1}}}.Validate())
+}
+
+func TestNormalizeUDA(t *testing.T) {
+	for _, tt := range []struct {
+		name, value, want, err string
+	}{
+		{name: "ticket", value: "OPS-1", want: "OPS-1"},
+		{name: "ticket", value: "", err: "uda ticket: value cannot be empty"},
+		{name: "points", value: "2.50", want: "2.5"},
+		{name: "points", value: "lots", err: `uda points: invalid number "lots"`},
+		{name: "spent", value: "1d", want: "24h0m0s"},
+		{name: "spent", value: "a while", err: `uda spent: invalid duration "a while"`},
+		{name: "env", value: "prod", want: "prod"},
+		{name: "env", value: "qa", err: `uda env: "qa" is not one of prod, staging`},
+		{name: "when", value: "2024-01-02T03:04:05Z", want: "2024-01-02T03:04:05Z"},
+		{name: "when", value: "eventually", err: `uda when: invalid date "eventually"`},
+		{name: "nope", value: "x", err: "unknown uda: nope"},
+	} {
+		got, err := testUDAs.Normalize(tt.name, tt.value)
+		if tt.err != "" {
+			require.EqualError(t, err, tt.err, tt.name)
+			continue
+		}
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.want, got, tt.name)
+	}
+
+	got, err := testUDAs.Normalize("when", "tomorrow")
+	require.NoError(t, err)
+	when, err := time.Parse(time.RFC3339, got)
+	require.NoError(t, err)
+	require.True(t, when.After(time.Now()))
+
+	pairs, err := testUDAs.ParseFlags([]string{"ticket=a=b", "points=1"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ticket": "a=b", "points": "1"}, pairs)
+	_, err = testUDAs.ParseFlags([]string{"ticket"})
+	require.EqualError(t, err, "uda must be name=value, got: ticket")
+}
+
+func TestAddValidatesUDAs(t *testing.T) {
+	p := newUDAPoet(t)
+	added, err := p.Task.Add(MustNewTask("ok", WithUDA("env", "prod"), WithUDA("points", "03")))
+	require.NoError(t, err)
+	require.Equal(t, "3", added.UDAs["points"])
+	_, err = p.Task.Add(MustNewTask("bad", WithUDA("env", "qa")))
+	require.EqualError(t, err, `uda env: "qa" is not one of prod, staging`)
+	_, err = p.Task.Add(MustNewTask("bad", WithUDA("when", "not a date")))
+	require.Error(t, err)
+	// Values for UDAs no longer in the config are kept
+	_, err = p.Task.Add(MustNewTask("old", WithUDA("removed", "anything")))
+	require.NoError(t, err)
+
+	// Edits of many tasks at once are checked too
+	edited := *added
+	edited.UDAs = map[string]string{"env": "qa"}
+	require.EqualError(t, p.Task.EditSet([]Task{edited}), `uda env: "qa" is not one of prod, staging`)
+	edited.UDAs = map[string]string{"points": "04"}
+	require.NoError(t, p.Task.EditSet([]Task{edited}))
+	got, err := p.Task.GetWithID(added.ID, "", "/active")
+	require.NoError(t, err)
+	require.Equal(t, "4", got.UDAs["points"])
+
+	// Another Poet without the UDAs doesn't check them
+	_, err = newTestPoet(t).Task.Add(MustNewTask("unchecked", WithUDA("env", "qa")))
+	require.NoError(t, err)
+}
+
+func TestUDAColumnsAndFilter(t *testing.T) {
+	p := newUDAPoet(t)
+	certs, err := p.Task.Add(MustNewTask("rotate certs", WithUDA("ticket", "OPS-1"), WithUDA("env", "prod")))
+	require.NoError(t, err)
+	_, err = p.Task.Add(MustNewTask("clean up staging", WithUDA("env", "staging")))
+	require.NoError(t, err)
+
+	require.NoError(t, p.ValidateColumn("ticket"))
+	require.NoError(t, p.ValidateColumn("Due"))
+	require.EqualError(t, p.ValidateColumn("nope"), "column not defined: nope")
+	require.Error(t, newTestPoet(t).ValidateColumn("ticket"))
+
+	got := p.TaskTable(TableOpts{
+		Prefix:       "/active",
+		Columns:      []string{"Description", "ticket", "env"},
+		FilterParams: FilterParams{UDAs: map[string]string{"env": "prod"}, Regex: regexp.MustCompile(".*")},
+		Filters:      []Filter{FilterRegex, FilterUDA},
+	})
+	require.Contains(t, got, "OPS-1")
+	require.Contains(t, got, "ticket")
+	require.NotContains(t, got, "clean up staging")
+	require.Contains(t, p.DescribeTask(*certs), "Ticket")
+}
+
+func TestUDAWeights(t *testing.T) {
+	c := NewCurator(WithWeights(testUDAs.weights(weightMap{})))
+	require.Equal(t, float64(0), c.Weigh(Task{}))
+	require.Equal(t, float64(3), c.Weigh(Task{UDAs: map[string]string{"env": "prod"}}))
+	require.Equal(t, float64(0), c.Weigh(Task{UDAs: map[string]string{"env": "staging"}}))
+	require.Equal(t, float64(2), c.Weigh(Task{UDAs: map[string]string{"points": "4"}}))
+	require.Equal(t, float64(1), c.Weigh(Task{UDAs: map[string]string{"spent": "1h0m0s"}}))
+	// UDAs without urgency don't add a weight
+	require.Equal(t, 3, len(testUDAs.weights(weightMap{})))
+
+	p := MustNew(WithDatabasePath(t.TempDir()+"/uda.db"), WithUDAs(testUDAs), WithWorkflowStates(testWorkflowStates()))
+	added, err := p.Task.Add(MustNewTask("urgent", WithUDA("env", "prod")))
+	require.NoError(t, err)
+	p.refresh(Tasks{added})
+	require.Equal(t, float64(3), added.Urgency)
+}
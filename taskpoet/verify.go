@@ -0,0 +1,88 @@
+package taskpoet
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VerifyProblem is something wrong with a single stored task
+type VerifyProblem struct {
+	Path    string
+	Problem string
+}
+
+// VerifyReport is the outcome of checking the database
+type VerifyReport struct {
+	Checked  int
+	Problems []VerifyProblem
+}
+
+// OK is true when no problems were found
+func (r VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify checks every task in the namespace. Each value must decode as a
+// Task, be stored at the path DetectKeyPath gives it, and have an ID that is
+// only used once across all of the states
+func (p *Poet) Verify() (*VerifyReport, error) {
+	report := &VerifyReport{Problems: []VerifyProblem{}}
+	// plugin/id => every path it was found at
+	seen := map[string][]string{}
+	err := p.Store.View(func(tx StoreTx) error {
+		return tx.ForEach(p.bucket, nil, func(k, v []byte) error {
+			report.Checked++
+			var t Task
+			if err := json.Unmarshal(v, &t); err != nil {
+				report.Problems = append(report.Problems, VerifyProblem{
+					Path:    string(k),
+					Problem: fmt.Sprintf("does not decode as a task: %v", err),
+				})
+				return nil
+			}
+			if t.WorkflowState != "" && t.IsOpen() && !p.WorkflowStates.Has(t.WorkflowState) {
+				report.Problems = append(report.Problems, VerifyProblem{
+					Path:    string(k),
+					Problem: fmt.Sprintf("in workflow state %v, which is not in the config", t.WorkflowState),
+				})
+			}
+			if want := string(t.DetectKeyPath()); want != string(k) {
+				report.Problems = append(report.Problems, VerifyProblem{
+					Path:    string(k),
+					Problem: fmt.Sprintf("stored at the wrong path, should be %v", want),
+				})
+			}
+			pluginID := t.PluginID
+			if pluginID == "" {
+				pluginID = DefaultPluginID
+			}
+			id := filepath.Join(pluginID, t.ID)
+			seen[id] = append(seen[id], string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		paths := seen[id]
+		if len(paths) < 2 {
+			continue
+		}
+		for _, path := range paths {
+			report.Problems = append(report.Problems, VerifyProblem{
+				Path:    path,
+				Problem: fmt.Sprintf("duplicate ID %v, also at %v", id, strings.Join(without(paths, path), ", ")),
+			})
+		}
+	}
+	return report, nil
+}
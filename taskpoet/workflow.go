@@ -0,0 +1,260 @@
+package taskpoet
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+Workflow states are extra states an open task can be in, on top of the built
+in active, completed and deleted. They are declared in the config, like:
+
+	states:
+	  active:
+	    transitions: [in-progress, waiting]
+	  waiting:
+	    urgency: -3
+	    transitions: [active, in-progress]
+	  in-progress:
+	    urgency: 4
+	    transitions: [in-review, waiting, completed]
+	  in-review:
+	    urgency: 2
+	    transitions: [in-progress, completed]
+
+Each state is stored under its own key path, /${state}/${plugin-id}/${id}.
+Tasks in a workflow state are still open, they show up with the active ones and
+can be completed or deleted like any other. Transitions limit which states a
+task can be moved to, a state without any can be moved to anything. The
+active entry is optional, it only sets the transitions and urgency of the
+built in active state.
+*/
+
+const (
+	// StateActive is the state of an open task that isn't in a workflow state
+	StateActive = "active"
+	// StateCompleted is the state of a completed task
+	StateCompleted = "completed"
+	// StateDeleted is the state of a deleted task
+	StateDeleted = "deleted"
+)
+
+var workflowStateNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// WorkflowState is a single state from the config
+type WorkflowState struct {
+	Description string `mapstructure:"description" yaml:"description,omitempty"`
+	// Urgency is added to the urgency of every task in this state
+	Urgency float64 `mapstructure:"urgency" yaml:"urgency,omitempty"`
+	// Transitions are the states a task can be moved to from this one
+	Transitions []string `mapstructure:"transitions" yaml:"transitions,omitempty"`
+}
+
+// WorkflowStates maps the name of each state to its definition
+type WorkflowStates map[string]WorkflowState
+
+// Names returns the workflow state names, sorted, without the built in active
+// state
+func (ws WorkflowStates) Names() []string {
+	ret := make([]string, 0, len(ws))
+	for name := range ws {
+		if name != StateActive {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Has is true when name is a built in state or one of the workflow states
+func (ws WorkflowStates) Has(name string) bool {
+	switch name {
+	case StateActive, StateCompleted, StateDeleted:
+		return true
+	}
+	_, ok := ws[name]
+	return ok
+}
+
+// Validate makes sure the names and transitions make sense
+func (ws WorkflowStates) Validate() error {
+	for name, state := range ws {
+		switch {
+		case name == StateCompleted || name == StateDeleted:
+			return fmt.Errorf("%v is a built in state and can't be configured", name)
+		case !workflowStateNameRe.MatchString(name):
+			return fmt.Errorf("invalid state name, use lowercase letters, numbers and dashes: %q", name)
+		}
+		for _, to := range state.Transitions {
+			if !ws.Has(to) {
+				return fmt.Errorf("state %v has a transition to unknown state %v", name, to)
+			}
+			if to == name {
+				return fmt.Errorf("state %v has a transition to itself", name)
+			}
+		}
+	}
+	return nil
+}
+
+// CanMove returns an error if a task in from can't be moved to to
+func (ws WorkflowStates) CanMove(from, to string) error {
+	switch {
+	case !ws.Has(to):
+		return fmt.Errorf("unknown state: %v", to)
+	case from == StateCompleted || from == StateDeleted:
+		return fmt.Errorf("only open tasks can be moved, this one is %v", from)
+	case from == to:
+		return fmt.Errorf("task is already %v", to)
+	}
+	allowed := ws[from].Transitions
+	if len(allowed) == 0 || containsString(allowed, to) {
+		return nil
+	}
+	return fmt.Errorf("can't move a task from %v to %v, only to: %v", from, to, strings.Join(allowed, ", "))
+}
+
+// urgency returns the urgency adjustment of each state that has one
+func (ws WorkflowStates) urgency() map[string]float64 {
+	ret := map[string]float64{}
+	for name, state := range ws {
+		if state.Urgency != 0 {
+			ret[name] = state.Urgency
+		}
+	}
+	return ret
+}
+
+// WithWorkflowStates sets the workflow states from the config
+func WithWorkflowStates(ws WorkflowStates) Option {
+	if err := ws.Validate(); err != nil {
+		return failure(err)
+	}
+	return success(func(p *Poet) {
+		p.WorkflowStates = ws
+	})
+}
+
+// WithWorkflowState puts a new task in a workflow state on create
+func WithWorkflowState(s string) TaskOption {
+	return func(t *Task) {
+		if s == StateActive {
+			s = ""
+		}
+		t.WorkflowState = s
+	}
+}
+
+// IsOpen is true for tasks that are neither completed nor deleted
+func (t Task) IsOpen() bool {
+	return t.Completed == nil && t.Deleted == nil
+}
+
+// OpenStatePaths returns the paths of every state an open task can be in,
+// starting with /active
+func (p *Poet) OpenStatePaths() []string {
+	ret := []string{filepath.Join("/", StateActive)}
+	for _, name := range p.WorkflowStates.Names() {
+		ret = append(ret, filepath.Join("/", name))
+	}
+	return ret
+}
+
+// ListOpen returns the tasks in every open state
+func (p *Poet) ListOpen() (Tasks, error) {
+	return p.listPrefixes(p.OpenStatePaths())
+}
+
+// listPrefixes returns the tasks under each of the prefixes, in order
+func (p *Poet) listPrefixes(prefixes []string) (Tasks, error) {
+	ret := Tasks{}
+	for _, prefix := range prefixes {
+		tasks, err := p.Task.List(prefix)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, tasks...)
+	}
+	return ret, nil
+}
+
+// Move moves an open task to another state. Moving to completed or deleted is
+// the same as completing or deleting it
+func (p *Poet) Move(t *Task, state string) error {
+	if err := p.WorkflowStates.CanMove(t.State(), state); err != nil {
+		return err
+	}
+	switch state {
+	case StateCompleted:
+		return p.Task.Complete(t)
+	case StateDeleted:
+		return p.Delete(t)
+	}
+	curPath := t.DetectKeyPath()
+	moved := *t
+	WithWorkflowState(state)(&moved)
+	if err := p.update(OperationMove, func(tx StoreTx) error {
+		before, err := p.getTaskIn(tx, curPath)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("could not find task: %s", curPath)
+		}
+		if err := p.removeTask(tx, curPath); err != nil {
+			return err
+		}
+		if err := p.putTask(tx, moved); err != nil {
+			return err
+		}
+		return p.recordRevision(tx, OperationMove, before, &moved)
+	}); err != nil {
+		return err
+	}
+	*t = moved
+	return nil
+}
+
+// validateWorkflowState makes sure a task isn't claiming a built in state
+func validateWorkflowState(s string) error {
+	switch {
+	case s == "":
+		return nil
+	case s == StateActive || s == StateCompleted || s == StateDeleted:
+		return fmt.Errorf("workflow state cannot be the built in %v state", s)
+	case !workflowStateNameRe.MatchString(s):
+		return errors.New("invalid workflow state: " + s)
+	}
+	return nil
+}
+
+// FilterState keeps tasks in one of FilterParams.States, when any are set
+func FilterState(p *FilterParams, task Task) bool {
+	if len(p.States) == 0 {
+		return true
+	}
+	return containsString(p.States, task.State())
+}
+
+// WorkflowTable returns a table of the states, along with how many tasks are in
+// each
+func (p *Poet) WorkflowTable() (string, error) {
+	rows := [][]string{}
+	for _, state := range p.Task.GetStates() {
+		ids, err := p.Task.GetIDsByPrefix(filepath.Join("/", state) + "/")
+		if err != nil {
+			return "", err
+		}
+		ws := p.WorkflowStates[state]
+		urgency := ""
+		if ws.Urgency != 0 {
+			urgency = fmt.Sprintf("%+.2f", ws.Urgency)
+		}
+		rows = append(rows, []string{state, fmt.Sprint(len(ids)), urgency, strings.Join(ws.Transitions, ","), ws.Description})
+	}
+	return p.listTable([]string{"State", "Tasks", "Urgency", "Transitions", "Description"}, rows), nil
+}
@@ -0,0 +1,195 @@
+package taskpoet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testWorkflowStates() WorkflowStates {
+	return WorkflowStates{
+		"active":      {Transitions: []string{"in-progress", "waiting"}},
+		"waiting":     {Urgency: -3},
+		"in-progress": {Urgency: 4, Transitions: []string{"in-review", "waiting"}},
+		"in-review":   {Urgency: 2, Transitions: []string{"in-progress", "completed"}},
+	}
+}
+
+func newTestWorkflowPoet(t *testing.T) *Poet {
+	return MustNew(WithDatabasePath(mustTempDB(t)), WithWorkflowStates(testWorkflowStates()))
+}
+
+func TestWorkflowStatesValidate(t *testing.T) {
+	require.NoError(t, testWorkflowStates().Validate())
+	for name, ws := range map[string]WorkflowStates{
+		"built in":         {"completed": {}},
+		"bad name":         {"In Review": {}},
+		"slash":            {"in/review": {}},
+		"unknown target":   {"waiting": {Transitions: []string{"nowhere"}}},
+		"transition to me": {"waiting": {Transitions: []string{"waiting"}}},
+	} {
+		require.Error(t, ws.Validate(), name)
+	}
+	_, err := New(WithDatabasePath(mustTempDB(t)), WithWorkflowStates(WorkflowStates{"deleted": {}}))
+	require.Error(t, err)
+}
+
+func TestWorkflowCanMove(t *testing.T) {
+	ws := testWorkflowStates()
+	require.NoError(t, ws.CanMove("active", "in-progress"))
+	require.NoError(t, ws.CanMove("waiting", "in-review"), "no transitions means anything goes")
+	require.EqualError(t, ws.CanMove("active", "in-review"), "can't move a task from active to in-review, only to: in-progress, waiting")
+	require.EqualError(t, ws.CanMove("in-review", "in-review"), "task is already in-review")
+	require.EqualError(t, ws.CanMove("active", "someday"), "unknown state: someday")
+	require.Error(t, ws.CanMove("completed", "active"))
+}
+
+func TestGetStatesWorkflow(t *testing.T) {
+	p := newTestWorkflowPoet(t)
+	require.Equal(t, []string{"active", "in-progress", "in-review", "waiting", "completed", "deleted"}, p.Task.GetStates())
+	require.Equal(t, []string{"/active", "/in-progress", "/in-review", "/waiting"}, p.OpenStatePaths())
+	require.Equal(t, []string{"active", "completed", "deleted"}, newTestPoet(t).Task.GetStates())
+}
+
+func TestMove(t *testing.T) {
+	p := newTestWorkflowPoet(t)
+	task, err := p.Task.Add(MustNewTask("review me", WithID("review-me")))
+	require.NoError(t, err)
+
+	require.Error(t, p.Move(task, "in-review"))
+	require.NoError(t, p.Move(task, "in-progress"))
+	require.Equal(t, "in-progress", task.State())
+	require.Equal(t, "/in-progress/builtin/review-me", string(task.DetectKeyPath()))
+	require.Empty(t, p.MustList("/active"))
+
+	got, err := p.Task.GetOpenWithPartialID("review", "")
+	require.NoError(t, err)
+	require.Equal(t, "in-progress", got.WorkflowState)
+	open, err := p.ListOpen()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(open))
+
+	require.NoError(t, p.Move(got, "in-review"))
+	require.NoError(t, p.Move(got, "completed"))
+	require.Equal(t, "completed", got.State())
+	require.Equal(t, 1, len(p.MustList("/completed")))
+	open, err = p.ListOpen()
+	require.NoError(t, err)
+	require.Empty(t, open)
+
+	revs, err := p.History(*got)
+	require.NoError(t, err)
+	require.Equal(t, OperationMove, revs[1].Operation)
+	require.Equal(t, "/in-progress/builtin/review-me", revs[1].Path)
+}
+
+func TestOverlappingStateNames(t *testing.T) {
+	p := MustNew(WithDatabasePath(mustTempDB(t)), WithWorkflowStates(WorkflowStates{
+		"active":        {Transitions: []string{"active-hold", "in-progress"}},
+		"active-hold":   {},
+		"in-progress":   {Transitions: []string{"in-progress-x"}},
+		"in-progress-x": {},
+	}))
+	held, err := p.Task.Add(MustNewTask("held", WithID("held"), WithTags([]string{"ops"})))
+	require.NoError(t, err)
+	require.NoError(t, p.Move(held, "active-hold"))
+	moved, err := p.Task.Add(MustNewTask("moved", WithID("moved")))
+	require.NoError(t, err)
+	require.NoError(t, p.Move(moved, "in-progress"))
+	require.NoError(t, p.Move(moved, "in-progress-x"))
+
+	// Each task is only under its own state
+	open, err := p.ListOpen()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(open))
+	require.Empty(t, p.MustList("/active"))
+	require.Empty(t, p.MustList("/in-progress"))
+	require.Equal(t, 1, len(p.MustList("/active-hold")))
+	tagged, err := p.Task.ListWithTag("/active", "ops")
+	require.NoError(t, err)
+	require.Empty(t, tagged)
+	require.Empty(t, p.CompleteIDsWithPrefix("/active", ""))
+}
+
+func TestMoveBackToActive(t *testing.T) {
+	p := newTestWorkflowPoet(t)
+	task, err := p.Task.Add(MustNewTask("later", WithWorkflowState("waiting")))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(p.MustList("/waiting")))
+	require.NoError(t, p.Move(task, "active"))
+	require.Equal(t, "", task.WorkflowState)
+	require.Equal(t, 1, len(p.MustList("/active")))
+
+	// Undo puts it back where it was
+	_, err = p.Undo(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(p.MustList("/waiting")))
+	require.Empty(t, p.MustList("/active"))
+}
+
+func TestWorkflowStateValidate(t *testing.T) {
+	_, err := NewTask("built in", WithWorkflowState("completed"))
+	require.Error(t, err)
+	_, err = NewTask("slashed", WithWorkflowState("in/review"))
+	require.Error(t, err)
+	got, err := NewTask("active", WithWorkflowState("active"))
+	require.NoError(t, err)
+	require.Equal(t, "", got.WorkflowState)
+}
+
+func TestWorkflowUrgency(t *testing.T) {
+	p := newTestWorkflowPoet(t)
+	active := MustNewTask("active")
+	busy := MustNewTask("busy", WithWorkflowState("in-progress"))
+	require.Equal(t, p.curator.Weigh(*active)+4, p.curator.Weigh(*busy))
+
+	_, reasons := p.curator.WeighAndDescribe(*busy)
+	found := false
+	for _, r := range reasons {
+		if r.Name == "state" {
+			found = true
+			require.Equal(t, "in-progress", r.Unit)
+		}
+	}
+	require.True(t, found)
+
+	// UDAs build a new curator, the states have to survive that
+	p = MustNew(WithDatabasePath(mustTempDB(t)), WithWorkflowStates(testWorkflowStates()), WithUDAs(UDAs{}))
+	require.Equal(t, p.curator.Weigh(*active)+4, p.curator.Weigh(*busy))
+}
+
+func TestFilterState(t *testing.T) {
+	tasks := Tasks{
+		MustNewTask("active"),
+		MustNewTask("waiting", WithWorkflowState("waiting")),
+		MustNewTask("busy", WithWorkflowState("in-progress")),
+	}
+	got := ApplyFilters(tasks, &FilterParams{States: []string{"active", "waiting"}}, FilterState)
+	require.Equal(t, 2, len(got))
+	require.Equal(t, 3, len(ApplyFilters(tasks, &FilterParams{}, FilterState)))
+}
+
+func TestVerifyUnknownWorkflowState(t *testing.T) {
+	dbPath := mustTempDB(t)
+	p := MustNew(WithDatabasePath(dbPath), WithWorkflowStates(testWorkflowStates()))
+	_, err := p.Task.Add(MustNewTask("stranded", WithWorkflowState("waiting")))
+	require.NoError(t, err)
+	require.NoError(t, p.Close())
+
+	p = MustNew(WithDatabasePath(dbPath))
+	report, err := p.Verify()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(report.Problems))
+	require.Contains(t, report.Problems[0].Problem, "not in the config")
+}
+
+func TestStartInWorkflowState(t *testing.T) {
+	p := newTestWorkflowPoet(t)
+	task, err := p.Task.Add(MustNewTask("doing it", WithWorkflowState("in-progress")))
+	require.NoError(t, err)
+	_, err = p.Start(task)
+	require.NoError(t, err)
+	running, err := p.Running()
+	require.NoError(t, err)
+	require.Equal(t, task.ID, running.ID)
+}